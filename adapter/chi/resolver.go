@@ -34,6 +34,11 @@ func (r *resolver) Resolve(req *http.Request) (string, bool) {
 
 	p := strings.TrimPrefix(pt, r.doc.BasePath())
 	op, ok := r.doc.Analyzer.OperationFor(req.Method, p)
+	if !ok && req.Method == http.MethodHead {
+		// HEAD requests can be served by GET operations,
+		// see OperationRouter.WithHeadForGet.
+		op, ok = r.doc.Analyzer.OperationFor(http.MethodGet, p)
+	}
 	if !ok {
 		return "", false
 	}
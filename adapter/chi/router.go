@@ -3,10 +3,13 @@ package oas_chi
 import (
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/go-chi/chi"
 
 	"github.com/hypnoglow/oas2"
+	"github.com/hypnoglow/oas2/internal/routing"
 )
 
 // NewOperationRouter returns a new operation router based on chi router.
@@ -27,6 +30,9 @@ type OperationRouter struct {
 	// onMissingOperationHandler is invoked with operation name
 	// when operation handler is missing.
 	onMissingOperationHandler func(op string)
+
+	// headForGet enables serving HEAD requests with GET operation handlers.
+	headForGet bool
 }
 
 // WithDocument sets the OpenAPI specification to build routes on.
@@ -60,6 +66,15 @@ func (r *OperationRouter) WithMissingOperationHandlerFunc(fn func(string)) oas.O
 	return r
 }
 
+// WithHeadForGet enables serving HEAD requests to paths with GET operation
+// and without HEAD operation by GET operation handlers. The response body is
+// discarded. Operation context of such requests is the GET operation.
+// It returns the router for convenient chaining.
+func (r *OperationRouter) WithHeadForGet(enable bool) oas.OperationRouter {
+	r.headForGet = enable
+	return r
+}
+
 // Build builds routing based on the previously provided specification,
 // operation handlers, and other options.
 func (r *OperationRouter) Build() error {
@@ -70,15 +85,55 @@ func (r *OperationRouter) Build() error {
 		return fmt.Errorf("no operation handlers given")
 	}
 
-	var router chi.Router = chi.NewRouter()
+	base := chi.NewRouter()
 
 	mws := make([]func(http.Handler) http.Handler, len(r.mws))
 	for i, mw := range r.mws {
 		mws[i] = mw
 	}
 
-	router = router.With(mws...)
+	router := base.With(mws...)
+	head := base.With(append([]func(http.Handler) http.Handler{routing.Head}, mws...)...)
+
+	// Requests with methods not defined for the path are answered with 405
+	// Method Not Allowed, and OPTIONS requests are answered automatically,
+	// without middleware, as they have no operation.
+	paths := r.pathHandlers()
+	for path, handlers := range paths {
+		methods := make([]string, 0, len(handlers))
+		for method := range handlers {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		allow := routing.Allow(methods, r.headForGet && handlers[http.MethodHead] == nil)
+
+		// Handle must be registered before methods, as it registers the
+		// handler for all methods.
+		base.Handle(path, routing.MethodNotAllowed(allow))
+		if handlers[http.MethodOptions] == nil {
+			base.Method(http.MethodOptions, path, routing.Options(allow))
+		}
 
+		for _, method := range methods {
+			router.Method(method, path, handlers[method])
+		}
+		if get := handlers[http.MethodGet]; r.headForGet && get != nil && handlers[http.MethodHead] == nil {
+			head.Method(http.MethodHead, path, get)
+		}
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
+
+	r.router.Mount(r.doc.BasePath(), base)
+
+	return nil
+}
+
+// pathHandlers returns operation handlers by method by path.
+func (r *OperationRouter) pathHandlers() map[string]map[string]http.Handler {
+	paths := make(map[string]map[string]http.Handler)
 	for method, pathOps := range r.doc.Analyzer.Operations() {
 		for path, operation := range pathOps {
 			h, ok := r.handlers[operation.ID]
@@ -89,15 +144,11 @@ func (r *OperationRouter) Build() error {
 				continue
 			}
 
-			router.Method(method, path, h)
+			if paths[path] == nil {
+				paths[path] = make(map[string]http.Handler)
+			}
+			paths[path][strings.ToUpper(method)] = h
 		}
 	}
-
-	if len(router.Routes()) == 0 {
-		return nil
-	}
-
-	r.router.Mount(r.doc.BasePath(), router)
-
-	return nil
+	return paths
 }
@@ -47,6 +47,82 @@ func TestOperationRouter(t *testing.T) {
 	assert.ElementsMatch(t, []string{"addPet", "loginUser"}, notHandledOps)
 }
 
+func TestOperationRouter_methods(t *testing.T) {
+	doc, err := oas.LoadFile("testdata/petstore.yml")
+	assert.NoError(t, err)
+
+	r := chi.NewRouter()
+	basis := oas.NewResolvingBasis("chi", doc)
+
+	err = basis.OperationRouter(r).(*oas_chi.OperationRouter).
+		WithHeadForGet(true).
+		WithOperationHandlers(map[string]http.Handler{
+			"addPet":     addPetHandler{},
+			"getPetById": getPetHandler{},
+		}).
+		WithMiddleware(basis.PathParamsContext()).
+		Build()
+	assert.NoError(t, err)
+
+	testCases := map[string]struct {
+		method string
+		path   string
+
+		expectedStatus int
+		expectedAllow  string
+		expectedBody   string
+	}{
+		"operation": {
+			method:         http.MethodGet,
+			path:           "/v2/pet/12",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"age":3,"debug":true,"name":"Hooch"}` + "\n",
+		},
+		"head for get": {
+			method:         http.MethodHead,
+			path:           "/v2/pet/12",
+			expectedStatus: http.StatusOK,
+		},
+		"options": {
+			method:         http.MethodOptions,
+			path:           "/v2/pet/12",
+			expectedStatus: http.StatusNoContent,
+			expectedAllow:  "GET, HEAD, OPTIONS",
+		},
+		"method not allowed": {
+			method:         http.MethodDelete,
+			path:           "/v2/pet/12",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedAllow:  "GET, HEAD, OPTIONS",
+			expectedBody:   "Method Not Allowed\n",
+		},
+		"method not allowed without get": {
+			method:         http.MethodPut,
+			path:           "/v2/pet",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedAllow:  "OPTIONS, POST",
+			expectedBody:   "Method Not Allowed\n",
+		},
+		"not found": {
+			method:         http.MethodGet,
+			path:           "/v2/store",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "404 page not found\n",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedAllow, w.Header().Get("Allow"))
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
 type getPetHandler struct{}
 
 func (h getPetHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
@@ -71,3 +147,9 @@ func (h getPetHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		panic(err)
 	}
 }
+
+type addPetHandler struct{}
+
+func (h addPetHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.WriteHeader(http.StatusCreated)
+}
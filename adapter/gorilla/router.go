@@ -3,10 +3,13 @@ package oas_gorilla
 import (
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/gorilla/mux"
 
 	"github.com/hypnoglow/oas2"
+	"github.com/hypnoglow/oas2/internal/routing"
 )
 
 // NewOperationRouter returns a new operation router based on gorilla/mux
@@ -28,6 +31,9 @@ type OperationRouter struct {
 	// onMissingOperationHandler is invoked with operation name
 	// when operation handler is missing.
 	onMissingOperationHandler func(op string)
+
+	// headForGet enables serving HEAD requests with GET operation handlers.
+	headForGet bool
 }
 
 // WithDocument sets the OpenAPI specification to build routes on.
@@ -61,6 +67,15 @@ func (r *OperationRouter) WithMissingOperationHandlerFunc(fn func(string)) oas.O
 	return r
 }
 
+// WithHeadForGet enables serving HEAD requests to paths with GET operation
+// and without HEAD operation by GET operation handlers. The response body is
+// discarded. Operation context of such requests is the GET operation.
+// It returns the router for convenient chaining.
+func (r *OperationRouter) WithHeadForGet(enable bool) oas.OperationRouter {
+	r.headForGet = enable
+	return r
+}
+
 // Build builds routing based on the previously provided specification,
 // operation handlers, and other options.
 func (r *OperationRouter) Build() error {
@@ -75,12 +90,54 @@ func (r *OperationRouter) Build() error {
 		PathPrefix(r.doc.BasePath()).
 		Subrouter()
 
-	mws := make([]mux.MiddlewareFunc, len(r.mws))
-	for i, mw := range r.mws {
-		mws[i] = mux.MiddlewareFunc(mw)
+	paths := r.pathHandlers()
+
+	// Routes are matched in order, so literal paths must come first.
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
 	}
-	router.Use(mws...)
+	routing.SortPaths(sorted)
 
+	for _, path := range sorted {
+		handlers := paths[path]
+		methods := make([]string, 0, len(handlers))
+		for method := range handlers {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		for _, method := range methods {
+			router.Path(path).Methods(method).Handler(r.chain(handlers[method]))
+		}
+		if get := handlers[http.MethodGet]; r.headForGet && get != nil && handlers[http.MethodHead] == nil {
+			router.Path(path).Methods(http.MethodHead).Handler(routing.Head(r.chain(get)))
+		}
+
+		// Requests with methods not defined for the path are answered with
+		// 405 Method Not Allowed, and OPTIONS requests are answered
+		// automatically, without middleware, as they have no operation.
+		allow := routing.Allow(methods, r.headForGet && handlers[http.MethodHead] == nil)
+		if handlers[http.MethodOptions] == nil {
+			router.Path(path).Methods(http.MethodOptions).Handler(routing.Options(allow))
+		}
+		router.Path(path).Handler(routing.MethodNotAllowed(allow))
+	}
+
+	return nil
+}
+
+// chain returns the handler wrapped with the middleware.
+func (r *OperationRouter) chain(h http.Handler) http.Handler {
+	for i := len(r.mws) - 1; i >= 0; i-- {
+		h = r.mws[i](h)
+	}
+	return h
+}
+
+// pathHandlers returns operation handlers by method by path.
+func (r *OperationRouter) pathHandlers() map[string]map[string]http.Handler {
+	paths := make(map[string]map[string]http.Handler)
 	for method, pathOps := range r.doc.Analyzer.Operations() {
 		for path, operation := range pathOps {
 			h, ok := r.handlers[operation.ID]
@@ -91,9 +148,11 @@ func (r *OperationRouter) Build() error {
 				continue
 			}
 
-			router.Path(path).Methods(method).Handler(h)
+			if paths[path] == nil {
+				paths[path] = make(map[string]http.Handler)
+			}
+			paths[path][strings.ToUpper(method)] = h
 		}
 	}
-
-	return nil
+	return paths
 }
@@ -3,6 +3,8 @@ package oas
 import (
 	"fmt"
 	"net/http"
+
+	"github.com/hypnoglow/oas2/validate"
 )
 
 // Resolver resolves operation id from the request.
@@ -39,18 +41,12 @@ type ResolvingBasis struct {
 
 func (b *ResolvingBasis) initCache() {
 	b.cache = make(map[string]operationInfo)
+	subtypes := validate.NewSubtypes(b.doc.Spec().Definitions, b.doc.OrigSpec().Definitions)
 	// _ is method
 	for _, pathOps := range b.doc.Analyzer.Operations() {
 		// _ is path
 		for _, operation := range pathOps {
-			key := operation.ID
-			value := operationInfo{
-				operation: operation,
-				params:    b.doc.Analyzer.ParametersFor(operation.ID),
-				consumes:  b.doc.Analyzer.ConsumesFor(operation),
-				produces:  b.doc.Analyzer.ProducesFor(operation),
-			}
-			b.cache[key] = value
+			b.cache[operation.ID] = newOperationInfo(b.doc, operation, subtypes)
 		}
 	}
 }
@@ -152,6 +148,8 @@ func (mw *resolvingPathParamExtractor) ServeHTTP(w http.ResponseWriter, req *htt
 }
 
 // QueryValidator returns a middleware that validates request query parameters.
+//
+// Use WithApplyDefaults option to set default values for missing parameters.
 func (b *ResolvingBasis) QueryValidator(opts ...MiddlewareOption) Middleware {
 	options := parseMiddlewareOptions(opts...)
 	if options.problemHandler == nil {
@@ -164,6 +162,7 @@ func (b *ResolvingBasis) QueryValidator(opts ...MiddlewareOption) Middleware {
 				next:              next,
 				problemHandler:    options.problemHandler,
 				continueOnProblem: options.continueOnProblem,
+				applyDefaults:     options.applyDefaults,
 			},
 			strict: b.strict,
 		}
@@ -231,6 +230,11 @@ func (mw *resolvingRequestContentTypeValidator) ServeHTTP(w http.ResponseWriter,
 }
 
 // RequestBodyValidator returns a middleware that validates request body.
+//
+// By default, requests with properties marked as readOnly are rejected.
+// Use WithStripReadOnly option to remove such properties from the request
+// body instead. Use WithApplyDefaults option to set default values for
+// missing properties.
 func (b *ResolvingBasis) RequestBodyValidator(opts ...MiddlewareOption) Middleware {
 	options := parseMiddlewareOptions(opts...)
 	if options.problemHandler == nil {
@@ -244,6 +248,8 @@ func (b *ResolvingBasis) RequestBodyValidator(opts ...MiddlewareOption) Middlewa
 				jsonSelectors:     options.jsonSelectors,
 				problemHandler:    options.problemHandler,
 				continueOnProblem: options.continueOnProblem,
+				stripReadOnly:     options.stripReadOnly,
+				applyDefaults:     options.applyDefaults,
 			},
 			strict: b.strict,
 		}
@@ -264,11 +270,11 @@ func (mw *resolvingRequestBodyValidator) ServeHTTP(w http.ResponseWriter, req *h
 		if mw.strict {
 			panic("request body validator middleware: cannot find operation info in the request context")
 		}
-		mw.rbv.ServeHTTP(w, req, nil, false)
+		mw.rbv.ServeHTTP(w, req, nil, nil, false)
 		return
 	}
 
-	mw.rbv.ServeHTTP(w, req, oi.params, true)
+	mw.rbv.ServeHTTP(w, req, oi.params, oi.bodyValidator, true)
 }
 
 // ResponseContentTypeValidator returns a middleware that validates
@@ -276,7 +282,7 @@ func (mw *resolvingRequestBodyValidator) ServeHTTP(w http.ResponseWriter, req *h
 func (b *ResolvingBasis) ResponseContentTypeValidator(opts ...MiddlewareOption) Middleware {
 	options := parseMiddlewareOptions(opts...)
 	if options.problemHandler == nil {
-		options.problemHandler = newProblemHandlerWarnLogger("response")
+		options.problemHandler = NewLoggingProblemHandler(stdLogger)
 	}
 
 	return func(next http.Handler) http.Handler {
@@ -315,7 +321,7 @@ func (mw *resolvingResponseContentTypeValidator) ServeHTTP(w http.ResponseWriter
 func (b *ResolvingBasis) ResponseBodyValidator(opts ...MiddlewareOption) Middleware {
 	options := parseMiddlewareOptions(opts...)
 	if options.problemHandler == nil {
-		options.problemHandler = newProblemHandlerWarnLogger("response")
+		options.problemHandler = NewLoggingProblemHandler(stdLogger)
 	}
 
 	return func(next http.Handler) http.Handler {
@@ -344,11 +350,11 @@ func (mw *resolvingResponseBodyValidator) ServeHTTP(w http.ResponseWriter, req *
 		if mw.strict {
 			panic("response body validator middleware: cannot find operation info in the request context")
 		}
-		mw.rbv.ServeHTTP(w, req, nil, false)
+		mw.rbv.ServeHTTP(w, req, nil, nil, false)
 		return
 	}
 
-	mw.rbv.ServeHTTP(w, req, oi.operation.Responses, true)
+	mw.rbv.ServeHTTP(w, req, oi.operation.Responses, oi.responseValidators, true)
 }
 
 // ContextualMiddleware represents a middleware that works based on request
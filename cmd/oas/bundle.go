@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-openapi/jsonpointer"
+)
+
+const bundleHelp = `Bundle OpenAPI specification
+
+Bundled spec is a single file. Schemas referenced from external files are
+imported to definitions, other objects referenced from external files are
+inlined. References within the spec are kept as is.
+
+Usage:
+    oas bundle [FLAGS] <SPEC_FILE>
+
+Flags:
+    -h, -help      Print help message
+    -o, -output    Output format: json or yaml (default "json")
+`
+
+func init() {
+	register(command{
+		name:    "bundle",
+		summary: "Bundle external references into a single file",
+		run:     runBundle,
+	})
+}
+
+func runBundle(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("bundle")
+	output := fs.String("output", "json", "")
+	fs.StringVar(output, "o", "json", "")
+
+	specFile, code, ok := parseFlags(fs, bundleHelp, args, stdout, stderr)
+	if !ok {
+		return code
+	}
+	if *output != "json" && *output != "yaml" {
+		fmt.Fprintf(stderr, "Error: unknown output format %q\n\n%s", *output, bundleHelp)
+		return exitError
+	}
+
+	bundled, err := bundle(specFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+
+	b, err := json.MarshalIndent(bundled, "", "  ")
+	if err == nil && *output == "yaml" {
+		b, err = yaml.JSONToYAML(b)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+
+	if _, err := fmt.Fprintf(stdout, "%s\n", strings.TrimSuffix(string(b), "\n")); err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// Contexts of values in the document, used to tell schemas from other
+// objects.
+const (
+	ctxAny     = ""
+	ctxSchema  = "schema"
+	ctxSchemas = "schemas"
+)
+
+// bundler resolves external references of the spec.
+type bundler struct {
+	rootFile string
+
+	// docs are loaded documents by absolute file path.
+	docs map[string]interface{}
+
+	// defs are definitions of the root document, including imported.
+	defs map[string]interface{}
+
+	// imported are names of imported definitions by reference key.
+	imported map[string]string
+}
+
+// bundle returns the spec from the file with external references resolved.
+func bundle(file string) (map[string]interface{}, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bundler{
+		rootFile: abs,
+		docs:     make(map[string]interface{}),
+		defs:     make(map[string]interface{}),
+		imported: make(map[string]string),
+	}
+
+	doc, err := b.load(abs)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: spec must be an object", file)
+	}
+
+	if defs, ok := root["definitions"].(map[string]interface{}); ok {
+		for name, def := range defs {
+			b.defs[name] = def
+		}
+	}
+
+	bundled, err := b.walk(root, abs, ctxAny)
+	if err != nil {
+		return nil, err
+	}
+
+	result := bundled.(map[string]interface{})
+	if defs, ok := result["definitions"].(map[string]interface{}); ok {
+		for name, def := range defs {
+			b.defs[name] = def
+		}
+	}
+	if len(b.defs) > 0 {
+		result["definitions"] = b.defs
+	}
+	return result, nil
+}
+
+// load returns the document from the file, which is either JSON or YAML.
+func (b *bundler) load(file string) (interface{}, error) {
+	if doc, ok := b.docs[file]; ok {
+		return doc, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	data, err = yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+
+	b.docs[file] = doc
+	return doc, nil
+}
+
+// walk returns a copy of the value with external references resolved.
+// References are relative to the file.
+func (b *bundler) walk(v interface{}, file, ctx string) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			return b.resolve(ref, file, ctx)
+		}
+
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			var err error
+			result[key], err = b.walk(value, file, childContext(ctx, key))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, value := range v {
+			var err error
+			result[i], err = b.walk(value, file, childContext(ctx, ""))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+
+	default:
+		return v, nil
+	}
+}
+
+// childContext returns the context of the value under the key.
+func childContext(ctx, key string) string {
+	switch {
+	case ctx == ctxSchema || ctx == ctxSchemas:
+		return ctxSchema
+	case key == "schema":
+		return ctxSchema
+	case key == "definitions":
+		return ctxSchemas
+	default:
+		return ctxAny
+	}
+}
+
+// resolve resolves the reference found in the file.
+func (b *bundler) resolve(ref, file, ctx string) (interface{}, error) {
+	if file == b.rootFile && strings.HasPrefix(ref, "#") {
+		return map[string]interface{}{"$ref": ref}, nil
+	}
+
+	target, fragment := splitRef(ref)
+	if target == "" {
+		target = file
+	} else {
+		if strings.Contains(target, "://") {
+			return nil, fmt.Errorf("%s: remote reference %q is not supported", file, ref)
+		}
+		target = filepath.Join(filepath.Dir(file), target)
+	}
+
+	// Reference to the root document from an external file.
+	if target == b.rootFile && strings.HasPrefix(fragment, "/definitions/") && ctx == ctxSchema {
+		return map[string]interface{}{"$ref": "#" + fragment}, nil
+	}
+
+	if ctx == ctxSchema {
+		name, err := b.importSchema(target, fragment)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + jsonpointer.Escape(name)}, nil
+	}
+
+	value, err := b.get(target, fragment)
+	if err != nil {
+		return nil, err
+	}
+	return b.walk(value, target, ctx)
+}
+
+// importSchema imports the schema referenced from external file to
+// definitions and returns its name.
+func (b *bundler) importSchema(file, fragment string) (string, error) {
+	key := file + "#" + fragment
+	if name, ok := b.imported[key]; ok {
+		return name, nil
+	}
+
+	value, err := b.get(file, fragment)
+	if err != nil {
+		return "", err
+	}
+
+	name := b.definitionName(file, fragment)
+	b.imported[key] = name
+	b.defs[name] = nil // reserve the name
+
+	schema, err := b.walk(value, file, ctxSchema)
+	if err != nil {
+		return "", err
+	}
+	b.defs[name] = schema
+	return name, nil
+}
+
+// definitionName returns unique name for the imported schema.
+func (b *bundler) definitionName(file, fragment string) string {
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	if i := strings.LastIndex(fragment, "/"); i >= 0 && i < len(fragment)-1 {
+		name = jsonpointer.Unescape(fragment[i+1:])
+	}
+
+	unique := name
+	for i := 2; ; i++ {
+		if _, ok := b.defs[unique]; !ok {
+			return unique
+		}
+		unique = name + strconv.Itoa(i)
+	}
+}
+
+// get returns the value located by the JSON Pointer in the file.
+func (b *bundler) get(file, fragment string) (interface{}, error) {
+	doc, err := b.load(file)
+	if err != nil {
+		return nil, err
+	}
+	if fragment == "" {
+		return doc, nil
+	}
+
+	p, err := jsonpointer.New(fragment)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid reference %q: %s", file, fragment, err)
+	}
+	value, _, err := p.Get(doc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: cannot resolve reference %q: %s", file, fragment, err)
+	}
+	return value, nil
+}
+
+// splitRef splits the reference to the file and the JSON Pointer.
+func splitRef(ref string) (file, fragment string) {
+	if i := strings.IndexByte(ref, '#'); i >= 0 {
+		return ref[:i], ref[i+1:]
+	}
+	return ref, ""
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hypnoglow/oas2"
+	"github.com/hypnoglow/oas2/diff"
+)
+
+const diffHelp = `Compare two versions of OpenAPI specification
+
+Reports changes between the old and the new spec, classified as breaking or
+non-breaking for API clients. Exits with code 1 if any change is breaking.
+
+Usage:
+    oas diff [FLAGS] <OLD_SPEC_FILE> <NEW_SPEC_FILE>
+
+Flags:
+    -h, -help      Print help message
+    -breaking      Report only breaking changes
+    -format        Output format: text or json (default "text")
+`
+
+func init() {
+	register(command{
+		name:    "diff",
+		summary: "Detect breaking changes between two specs",
+		run:     runDiff,
+	})
+}
+
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("diff")
+	format := formatFlag(fs)
+	breakingOnly := fs.Bool("breaking", false, "")
+
+	files, code, ok := parseFlagsN(fs, diffHelp, 2, args, stdout, stderr)
+	if !ok {
+		return code
+	}
+
+	var docs [2]*oas.Document
+	for i, file := range files {
+		doc, err := oas.LoadFile(file)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %s\n", err)
+			return exitError
+		}
+		docs[i] = doc
+	}
+
+	r := diff.Compare(docs[0], docs[1])
+	breaking := r.Breaking()
+
+	if *breakingOnly {
+		var changes []diff.Change
+		for _, c := range r.Changes {
+			if c.Breaking {
+				changes = append(changes, c)
+			}
+		}
+		r.Changes = changes
+	}
+
+	if err := writeDiff(stdout, r, *format); err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+
+	if breaking {
+		return exitIssues
+	}
+	return exitOK
+}
+
+func writeDiff(w io.Writer, r *diff.Report, format string) error {
+	if format == formatJSON {
+		out := struct {
+			Breaking bool          `json:"breaking"`
+			Changes  []diff.Change `json:"changes"`
+		}{
+			Breaking: r.Breaking(),
+			Changes:  r.Changes,
+		}
+		if out.Changes == nil {
+			out.Changes = []diff.Change{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	for _, c := range r.Changes {
+		kind := "non-breaking"
+		if c.Breaking {
+			kind = "breaking"
+		}
+		where := c.Pointer
+		if c.Operation != "" {
+			where = c.Operation
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s: %s\n", kind, where, c.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hypnoglow/oas2"
+	"github.com/hypnoglow/oas2/lint"
+)
+
+const examplesHelp = `Validate examples of OpenAPI specification
+
+Checks "example" values of schemas and "examples" of responses for JSON media
+types against their schemas, the same way request and response bodies are
+validated, and prints examples that do not match with their locations in the
+file.
+
+Usage:
+    oas examples [FLAGS] <SPEC_FILE>
+
+Flags:
+    -h, -help      Print help message
+    -format        Output format: text or json (default "text")
+`
+
+func init() {
+	register(command{
+		name:    "examples",
+		summary: "Check examples of the spec match their schemas",
+		run:     runExamples,
+	})
+}
+
+func runExamples(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("examples")
+	format := formatFlag(fs)
+
+	specFile, code, ok := parseFlags(fs, examplesHelp, args, stdout, stderr)
+	if !ok {
+		return code
+	}
+
+	doc, err := oas.LoadFile(specFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+
+	var r report
+	for _, e := range oas.ValidateExamples(doc) {
+		r.Issues = append(r.Issues, issue{
+			File:     specFile,
+			Pointer:  e.Pointer,
+			Severity: string(lint.SeverityError),
+			Message:  strings.TrimSpace(e.Err.Error()),
+		})
+	}
+
+	return writeReport(r, *format, stdout, stderr)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hypnoglow/oas2"
+)
+
+const expandHelp = `Expand OpenAPI specification
+
+Expanded spec has all references resolved, so loading it is much faster.
+
+Usage:
+    oas expand [FLAGS] <SPEC_FILE>
+
+Flags:
+    -h, -help          Print help message
+    -t, -target-dir    Save expanded spec to directory instead of printing it
+`
+
+func init() {
+	register(command{
+		name:    "expand",
+		summary: "Expand all references in the spec",
+		run:     runExpand,
+	})
+}
+
+func runExpand(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("expand")
+	targetDir := fs.String("target-dir", "", "")
+	fs.StringVar(targetDir, "t", "", "")
+
+	specFile, code, ok := parseFlags(fs, expandHelp, args, stdout, stderr)
+	if !ok {
+		return code
+	}
+
+	// Save expanded spec to file in dir
+	if *targetDir != "" {
+		if _, err := oas.LoadFile(specFile, oas.LoadCacheDir(*targetDir)); err != nil {
+			fmt.Fprintf(stderr, "Error: %s\n", err)
+			return exitError
+		}
+		fmt.Fprintln(stdout, "Spec expanded successfully")
+		return exitOK
+	}
+
+	// Print expanded spec to stdout
+	document, err := oas.LoadFile(specFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+	if err := json.NewEncoder(stdout).Encode(document.Spec()); err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/hypnoglow/oas2"
+	"github.com/hypnoglow/oas2/codegen"
+)
+
+const generateHelp = `Generate Go code from OpenAPI specification
+
+Generates structs of operation query parameters to decode with
+oas.DecodeQuery, types of request and response bodies and definitions, and
+a Handler interface with OperationHandlers function that maps operation ids
+to the handler methods.
+
+References to other files are not supported; bundle the spec first.
+
+Usage:
+    oas generate [FLAGS] <SPEC_FILE>
+
+Flags:
+    -h, -help      Print help message
+    -package       Package name of the generated code (default "api")
+    -o, -output    Write the code to file instead of printing it
+`
+
+func init() {
+	register(command{
+		name:    "generate",
+		summary: "Generate Go types and handler interface from the spec",
+		run:     runGenerate,
+	})
+}
+
+func runGenerate(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("generate")
+	pkg := fs.String("package", codegen.DefaultPackage, "")
+	output := fs.String("output", "", "")
+	fs.StringVar(output, "o", "", "")
+
+	specFile, code, ok := parseFlags(fs, generateHelp, args, stdout, stderr)
+	if !ok {
+		return code
+	}
+
+	doc, err := oas.LoadFile(specFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+
+	src, err := codegen.Generate(doc, codegen.Config{Package: *pkg})
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+
+	if *output != "" {
+		if err := ioutil.WriteFile(*output, src, 0644); err != nil {
+			fmt.Fprintf(stderr, "Error: %s\n", err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	if _, err := stdout.Write(src); err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+	"github.com/go-openapi/loads"
+
+	"github.com/hypnoglow/oas2/lint"
+)
+
+const lintHelp = `Lint OpenAPI specification
+
+Checks the spec against style rules, and for things this library does not
+handle. Rule severities can be changed in the config file, which is YAML or
+JSON. Note that "off" must be quoted in YAML:
+
+    rules:
+      operation-tags: error
+      path-case: "off"
+
+Issues can be suppressed in the spec with x-lint-ignore extension set to
+a rule name, a list of rule names, or true for all rules. It applies to the
+object it is set on and to all objects within it:
+
+    /petOwners:
+      x-lint-ignore: [path-case]
+
+Usage:
+    oas lint [FLAGS] <SPEC_FILE>
+
+Flags:
+    -h, -help      Print help message
+    -config        Config file
+    -format        Output format: text or json (default "text")
+
+Rules:
+%s`
+
+func init() {
+	register(command{
+		name:    "lint",
+		summary: "Check the spec against style and library rules",
+		run:     runLint,
+	})
+}
+
+func runLint(args []string, stdout, stderr io.Writer) int {
+	help := fmt.Sprintf(lintHelp, rulesHelp())
+
+	fs := newFlagSet("lint")
+	format := formatFlag(fs)
+	configFile := fs.String("config", "", "")
+
+	specFile, code, ok := parseFlags(fs, help, args, stdout, stderr)
+	if !ok {
+		return code
+	}
+
+	var cfg lint.Config
+	if *configFile != "" {
+		b, err := ioutil.ReadFile(*configFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error: %s\n", err)
+			return exitError
+		}
+		if err := yaml.Unmarshal(b, &cfg); err != nil {
+			fmt.Fprintf(stderr, "Error: invalid config: %s\n", err)
+			return exitError
+		}
+		if err := checkConfig(cfg); err != nil {
+			fmt.Fprintf(stderr, "Error: invalid config: %s\n", err)
+			return exitError
+		}
+	}
+
+	doc, err := loads.Spec(specFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+
+	var r report
+	for _, i := range lint.Lint(doc.Spec(), cfg) {
+		r.Issues = append(r.Issues, issue{
+			File:     specFile,
+			Pointer:  i.Pointer,
+			Rule:     i.Rule,
+			Severity: string(i.Severity),
+			Message:  i.Message,
+		})
+	}
+
+	return writeReport(r, *format, stdout, stderr)
+}
+
+// checkConfig checks the config refers to known rules and severities.
+func checkConfig(cfg lint.Config) error {
+	known := make(map[string]bool)
+	for _, rule := range lint.Rules() {
+		known[rule.Name] = true
+	}
+
+	for name, sev := range cfg.Rules {
+		if !known[name] {
+			return fmt.Errorf("unknown rule %q", name)
+		}
+		switch sev {
+		case lint.SeverityError, lint.SeverityWarning, lint.SeverityOff:
+		default:
+			return fmt.Errorf("unknown severity %q of rule %q", sev, name)
+		}
+	}
+	return nil
+}
+
+func rulesHelp() string {
+	var s string
+	for _, rule := range lint.Rules() {
+		s += fmt.Sprintf("    %-24s %-8s %s\n", rule.Name, rule.Severity, rule.Description)
+	}
+	return s
+}
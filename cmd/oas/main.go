@@ -0,0 +1,92 @@
+// CLI utility to work with OpenAPI specification files.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Exit codes.
+const (
+	// exitOK means the command succeeded and found no problems.
+	exitOK = 0
+
+	// exitIssues means the command found problems in the spec.
+	exitIssues = 1
+
+	// exitError means the command could not be run, e.g. due to invalid
+	// arguments or unreadable file.
+	exitError = 2
+)
+
+// command is a subcommand of the CLI.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) int
+}
+
+// commands are registered subcommands by name.
+var commands = map[string]command{}
+
+func register(cmd command) {
+	commands[cmd.name] = cmd
+}
+
+const help = `Work with OpenAPI specification files
+
+Usage:
+    oas <COMMAND> [FLAGS] <SPEC_FILE>
+
+Commands:
+%s
+Exit codes:
+    0    Success
+    1    Spec has problems
+    2    Command failed, e.g. invalid arguments or unreadable file
+
+Run "oas <COMMAND> -h" for help on the command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitError
+	}
+
+	switch args[0] {
+	case "-h", "-help", "--help", "help":
+		usage(stdout)
+		return exitOK
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "Unknown command %q\n\n", args[0])
+		usage(stderr)
+		return exitError
+	}
+
+	return cmd.run(args[1:], stdout, stderr)
+}
+
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var list string
+	for _, name := range names {
+		list += fmt.Sprintf("    %-10s %s\n", name, commands[name].summary)
+	}
+
+	fmt.Fprintf(w, help, list)
+}
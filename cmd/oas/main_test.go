@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("no command", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitError, run(nil, &stdout, &stderr))
+		assert.Contains(t, stderr.String(), "Commands:")
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitError, run([]string{"foo"}, &stdout, &stderr))
+		assert.Contains(t, stderr.String(), `Unknown command "foo"`)
+	})
+
+	t.Run("command help", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitOK, run([]string{"lint", "-h"}, &stdout, &stderr))
+		assert.Contains(t, stdout.String(), "operation-tags")
+	})
+
+	t.Run("unreadable file", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitError, run([]string{"validate", "testdata/missing.yml"}, &stdout, &stderr))
+		assert.Contains(t, stderr.String(), "no such file")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitOK, run([]string{"validate", "testdata/lint.yml"}, &stdout, &stderr))
+		assert.Empty(t, stdout.String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitIssues, run([]string{"validate", "testdata/invalid.yml"}, &stdout, &stderr))
+
+		expected := `testdata/invalid.yml#/definitions/Pet: error: "name" is present in required but not defined as property in definition "Pet"
+testdata/invalid.yml#/paths/~1pets~1{id}: error: path param "{id}" has no parameter definition
+testdata/invalid.yml#/paths/~1pets~1{id}/get/operationId: error: "getPet" is defined 2 times
+`
+		assert.Equal(t, expected, stdout.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitIssues, run([]string{"validate", "-format", "json", "testdata/invalid.yml"}, &stdout, &stderr))
+
+		var r report
+		if err := json.Unmarshal(stdout.Bytes(), &r); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		assert.Len(t, r.Issues, 3)
+		assert.Equal(t, issue{
+			File:     "testdata/invalid.yml",
+			Pointer:  "/definitions/Pet",
+			Severity: "error",
+			Message:  `"name" is present in required but not defined as property in definition "Pet"`,
+		}, r.Issues[0])
+	})
+}
+
+func TestExamples(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitOK, run([]string{"examples", "testdata/lint.yml"}, &stdout, &stderr))
+		assert.Empty(t, stdout.String())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitIssues, run([]string{"examples", "testdata/examples.yml"}, &stdout, &stderr))
+
+		expected := `testdata/examples.yml#/definitions/Pet/example/name: error: name in body is required
+testdata/examples.yml#/definitions/Pet/properties/birthday/example: error: in body must be of type date: "yesterday"
+testdata/examples.yml#/definitions/Pets/example/1/id: error: 1.id in body must be of type integer: "string"
+testdata/examples.yml#/paths/~1pets/post/responses/default/examples/application~1json/message: error: message in body must be of type string: "array"
+testdata/examples.yml#/paths/~1pets/post/responses/default/schema/properties/message/example: error: in body must be of type string: "number"
+`
+		assert.Equal(t, expected, stdout.String())
+	})
+}
+
+func TestLint(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitOK, run([]string{"lint", "testdata/lint.yml"}, &stdout, &stderr))
+
+		expected := `testdata/lint.yml#/definitions/owner: warning: definition name "owner" is not PascalCase (definition-case)
+testdata/lint.yml#/paths/~1petOwners: warning: path segment "petOwners" is not kebab-case (path-case)
+testdata/lint.yml#/paths/~1petOwners/get: warning: operation has no tags (operation-tags)
+testdata/lint.yml#/paths/~1petOwners/get/operationId: warning: operationId "ListOwners" is not camelCase (operation-id-case)
+`
+		assert.Equal(t, expected, stdout.String())
+	})
+
+	t.Run("config", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"lint", "-config", "testdata/lint-config.yml", "-format", "json", "testdata/lint.yml"}, &stdout, &stderr)
+		assert.Equal(t, exitIssues, code)
+
+		var r report
+		if err := json.Unmarshal(stdout.Bytes(), &r); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		var rules []string
+		for _, i := range r.Issues {
+			rules = append(rules, i.Rule+" "+i.Severity)
+		}
+		expected := []string{
+			"definition-case warning",
+			"property-case warning",
+			"operation-tags error",
+			"operation-id-case warning",
+		}
+		assert.Equal(t, expected, rules)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"lint", "-config", "testdata/lint-config-invalid.yml", "testdata/lint.yml"}, &stdout, &stderr)
+		assert.Equal(t, exitError, code)
+		assert.Contains(t, stderr.String(), `invalid config: unknown rule "no-such-rule"`)
+	})
+}
+
+func TestDiff(t *testing.T) {
+	t.Run("breaking", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"diff", "-breaking", "../../diff/testdata/old.yml", "../../diff/testdata/new.yml"}, &stdout, &stderr)
+		assert.Equal(t, exitIssues, code)
+		assert.Contains(t, stdout.String(), "breaking: DELETE /pets/{id}: operation removed\n")
+		assert.NotContains(t, stdout.String(), "non-breaking")
+	})
+
+	t.Run("same", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"diff", "-format", "json", "../../diff/testdata/old.yml", "../../diff/testdata/old.yml"}, &stdout, &stderr)
+		assert.Equal(t, exitOK, code)
+		assert.JSONEq(t, `{"breaking": false, "changes": []}`, stdout.String())
+	})
+
+	t.Run("one file", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitError, run([]string{"diff", "../../diff/testdata/old.yml"}, &stdout, &stderr))
+	})
+}
+
+func TestBundle(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, exitOK, run([]string{"bundle", "-o", "yaml", "testdata/bundle/api.yml"}, &stdout, &stderr))
+
+	var bundled map[string]interface{}
+	if err := yaml.Unmarshal(stdout.Bytes(), &bundled); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// internal references are kept
+	op := bundled["paths"].(map[string]interface{})["/pets"].(map[string]interface{})["get"].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"$ref": "#/parameters/Limit"}}, op["parameters"])
+	responses := op["responses"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"$ref": "#/responses/Error"}, responses["default"])
+
+	// external schemas are imported to definitions
+	schema := responses["200"].(map[string]interface{})["schema"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"$ref": "#/definitions/Pet"}, schema["items"])
+
+	defs := bundled["definitions"].(map[string]interface{})
+	assert.Len(t, defs, 3)
+	pet := defs["Pet"].(map[string]interface{})
+	category := pet["properties"].(map[string]interface{})["category"]
+	assert.Equal(t, map[string]interface{}{"$ref": "#/definitions/Category"}, category)
+	assert.Contains(t, defs, "Category")
+	assert.Contains(t, defs, "Error")
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("ok", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitOK, run([]string{"generate", "-package", "petstore", "testdata/lint.yml"}, &stdout, &stderr))
+		assert.Contains(t, stdout.String(), "package petstore\n")
+		assert.Contains(t, stdout.String(), "func OperationHandlers(h Handler) map[string]http.Handler {")
+	})
+
+	t.Run("external references", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, exitError, run([]string{"generate", "testdata/bundle/api.yml"}, &stdout, &stderr))
+		assert.Contains(t, stderr.String(), "unsupported reference")
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi"
+
+	"github.com/hypnoglow/oas2"
+	_ "github.com/hypnoglow/oas2/adapter/chi/init"
+)
+
+const mockHelp = `Serve mock API from OpenAPI specification
+
+Serves every operation of the spec. Requests are validated, and responded
+with the response examples, or with data synthesized from the response
+schemas. Clients pick the status code with the status header, e.g.
+"X-Mock-Status: 404"; otherwise the lowest 2xx code is used.
+
+Usage:
+    oas mock [FLAGS] <SPEC_FILE>
+
+Flags:
+    -h, -help          Print help message
+    -addr              Address to listen on (default ":8080")
+    -status-header     Request header to pick the status code (default "X-Mock-Status")
+`
+
+func init() {
+	register(command{
+		name:    "mock",
+		summary: "Serve mock API from the spec",
+		run:     runMock,
+	})
+}
+
+func runMock(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("mock")
+	addr := fs.String("addr", ":8080", "")
+	statusHeader := fs.String("status-header", oas.DefaultMockStatusHeader, "")
+
+	specFile, code, ok := parseFlags(fs, mockHelp, args, stdout, stderr)
+	if !ok {
+		return code
+	}
+
+	doc, err := oas.LoadFile(specFile)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+
+	h := oas.NewMockHandler(doc,
+		oas.MockRouter("chi", chi.NewRouter()),
+		oas.MockStatusHeader(*statusHeader),
+	)
+
+	logger := log.New(stderr, "", log.LstdFlags)
+	logger.Printf("Serving mock API on %s%s", *addr, doc.BasePath())
+
+	if err := http.ListenAndServe(*addr, logRequests(logger, h)); err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// logRequests returns handler that logs requests with response status codes.
+func logRequests(logger *log.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, req)
+		logger.Printf("%s %s %d", req.Method, req.URL.RequestURI(), sw.status)
+	})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
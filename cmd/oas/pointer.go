@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// document is the decoded spec document. It is used to find JSON Pointers
+// of the spec validation errors, which go-openapi reports with dotted paths.
+type document struct {
+	root interface{}
+}
+
+// newDocument decodes the raw JSON of the spec document.
+func newDocument(raw json.RawMessage) (*document, error) {
+	var root interface{}
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil, err
+	}
+	return &document{root: root}, nil
+}
+
+// has reports whether the document has the location defined by the tokens.
+func (d *document) has(tokens []string) bool {
+	v := d.root
+	for _, token := range tokens {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if v, ok = m[token]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupDotted returns the tokens of the location defined by the dotted
+// path, as reported by go-openapi, e.g. "paths./pets.get.responses". The keys
+// may contain dots, and sequence indexes may be omitted. If the location is
+// ambiguous, the one with the scalar value is preferred, if the value is not
+// empty. If only a part of the path is found, its tokens are returned.
+func (d *document) lookupDotted(path, value string) []string {
+	_, tokens := lookupDotted(d.root, path, value)
+	return tokens
+}
+
+func lookupDotted(v interface{}, path, value string) (interface{}, []string) {
+	if path == "" {
+		return v, nil
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if key := matchKey(v, path); key != "" {
+			rest := strings.TrimPrefix(strings.TrimPrefix(path, key), ".")
+			found, tokens := lookupDotted(v[key], rest, value)
+			return found, append([]string{key}, tokens...)
+		}
+	case []interface{}:
+		// Index may be missing in the path, so look for the key in sequence
+		// items.
+		var first interface{}
+		var firstTokens []string
+		for i, item := range v {
+			m, ok := item.(map[string]interface{})
+			if !ok || matchKey(m, path) == "" {
+				continue
+			}
+
+			found, tokens := lookupDotted(m, path, value)
+			tokens = append([]string{strconv.Itoa(i)}, tokens...)
+			if value == "" || scalar(found) == value {
+				return found, tokens
+			}
+			if firstTokens == nil {
+				first, firstTokens = found, tokens
+			}
+		}
+		if firstTokens != nil {
+			return first, firstTokens
+		}
+	}
+
+	return v, nil
+}
+
+// matchKey returns the longest key that prefixes the dotted path.
+func matchKey(m map[string]interface{}, path string) string {
+	var best string
+	for key := range m {
+		if (path == key || strings.HasPrefix(path, key+".")) && len(key) > len(best) {
+			best = key
+		}
+	}
+	return best
+}
+
+// search returns the tokens of the first key or string value that contains
+// s, or nil. Keys are searched in sorted order.
+func (d *document) search(s string) []string {
+	tokens, _ := search(d.root, s)
+	return tokens
+}
+
+func search(v interface{}, s string) ([]string, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if strings.Contains(key, s) {
+				return []string{key}, true
+			}
+			if tokens, ok := search(v[key], s); ok {
+				return append([]string{key}, tokens...), true
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if tokens, ok := search(item, s); ok {
+				return append([]string{strconv.Itoa(i)}, tokens...), true
+			}
+		}
+	case string:
+		return nil, strings.Contains(v, s)
+	}
+	return nil, false
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument(t *testing.T) {
+	raw := []byte(`{
+  "swagger": "2.0",
+  "paths": {
+    "/pets.json": {
+      "get": {
+        "operationId": "listPets",
+        "parameters": [
+          {"name": "limit", "in": "query"},
+          {"name": "id", "in": "header2"}
+        ],
+        "responses": {"200": {"description": "OK"}}
+      }
+    }
+  },
+  "definitions": {"Pet": {"type": "object"}}
+}`)
+	doc, err := newDocument(raw)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	assert.True(t, doc.has([]string{"definitions", "Pet"}))
+	assert.False(t, doc.has([]string{"definitions", "Owner"}))
+	assert.False(t, doc.has([]string{"swagger", "version"}))
+
+	tokens := doc.lookupDotted("paths./pets.json.get.parameters.in", "header2")
+	assert.Equal(t, []string{"paths", "/pets.json", "get", "parameters", "1", "in"}, tokens)
+
+	tokens = doc.lookupDotted("paths./pets.json.get.parameters.in", "")
+	assert.Equal(t, []string{"paths", "/pets.json", "get", "parameters", "0", "in"}, tokens)
+
+	tokens = doc.lookupDotted("paths./pets.json.get.responses.200.description", "")
+	assert.Equal(t, []string{"paths", "/pets.json", "get", "responses", "200", "description"}, tokens)
+
+	tokens = doc.lookupDotted("definitions.Pet.properties", "")
+	assert.Equal(t, []string{"definitions", "Pet"}, tokens)
+
+	assert.Nil(t, doc.lookupDotted("info.title", ""))
+
+	assert.Equal(t, []string{"paths", "/pets.json"}, doc.search("pets.json"))
+	assert.Equal(t, []string{"paths", "/pets.json", "get", "operationId"}, doc.search("listPets"))
+	assert.Nil(t, doc.search("missing"))
+}
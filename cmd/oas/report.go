@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// Output formats.
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+// issue is a problem found in the spec file.
+type issue struct {
+	File     string `json:"file"`
+	Pointer  string `json:"pointer,omitempty"`
+	Rule     string `json:"rule,omitempty"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// report is the output of commands that check the spec.
+type report struct {
+	Issues []issue `json:"issues"`
+}
+
+// write writes the report in the format.
+func (r report) write(w io.Writer, format string) error {
+	if format == formatJSON {
+		if r.Issues == nil {
+			r.Issues = []issue{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	}
+
+	for _, i := range r.Issues {
+		loc := i.File
+		if i.Pointer != "" {
+			loc += "#" + i.Pointer
+		}
+		msg := i.Message
+		if i.Rule != "" {
+			msg += " (" + i.Rule + ")"
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s: %s\n", loc, i.Severity, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newFlagSet returns flag set for the command. Errors and usage are printed
+// by parseFlags.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	return fs
+}
+
+// formatFlag defines the output format flag.
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", formatText, "")
+}
+
+// parseFlags parses the command flags and returns the spec file. If ok is
+// false, the command should exit with the code.
+func parseFlags(fs *flag.FlagSet, usage string, args []string, stdout, stderr io.Writer) (file string, code int, ok bool) {
+	files, code, ok := parseFlagsN(fs, usage, 1, args, stdout, stderr)
+	if !ok {
+		return "", code, false
+	}
+	return files[0], code, true
+}
+
+// parseFlagsN parses the command flags and returns n files.
+func parseFlagsN(fs *flag.FlagSet, usage string, n int, args []string, stdout, stderr io.Writer) (files []string, code int, ok bool) {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			fmt.Fprint(stdout, usage)
+			return nil, exitOK, false
+		}
+		fmt.Fprintf(stderr, "Error: %s\n\n%s", err, usage)
+		return nil, exitError, false
+	}
+
+	if f := fs.Lookup("format"); f != nil {
+		if v := f.Value.String(); v != formatText && v != formatJSON {
+			fmt.Fprintf(stderr, "Error: unknown format %q\n\n%s", v, usage)
+			return nil, exitError, false
+		}
+	}
+
+	if fs.NArg() != n {
+		fmt.Fprint(stderr, usage)
+		return nil, exitError, false
+	}
+
+	return fs.Args(), exitOK, true
+}
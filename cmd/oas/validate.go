@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/loads"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/validate"
+
+	"github.com/hypnoglow/oas2/lint"
+)
+
+const validateHelp = `Validate OpenAPI specification
+
+Checks the spec against the OpenAPI 2.0 schema and semantic rules, and prints
+found problems with JSON Pointers to their locations in the file.
+
+Usage:
+    oas validate [FLAGS] <SPEC_FILE>
+
+Flags:
+    -h, -help      Print help message
+    -format        Output format: text or json (default "text")
+`
+
+func init() {
+	register(command{
+		name:    "validate",
+		summary: "Check the spec is valid",
+		run:     runValidate,
+	})
+}
+
+func runValidate(args []string, stdout, stderr io.Writer) int {
+	fs := newFlagSet("validate")
+	format := formatFlag(fs)
+
+	specFile, code, ok := parseFlags(fs, validateHelp, args, stdout, stderr)
+	if !ok {
+		return code
+	}
+
+	if _, err := os.Stat(specFile); err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+
+	doc, err := loads.Spec(specFile)
+	if err != nil {
+		// The file is not a valid JSON or YAML document.
+		r := report{Issues: []issue{{File: specFile, Severity: string(lint.SeverityError), Message: err.Error()}}}
+		return writeReport(r, *format, stdout, stderr)
+	}
+
+	d, err := newDocument(doc.Raw())
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+
+	var r report
+	for _, e := range specErrors(validate.Spec(doc, strfmt.Default)) {
+		r.Issues = append(r.Issues, issue{
+			File:     specFile,
+			Pointer:  locate(d, e),
+			Severity: string(lint.SeverityError),
+			Message:  e.Error(),
+		})
+	}
+
+	// Errors are reported in no particular order, so order them by location.
+	sort.SliceStable(r.Issues, func(i, j int) bool {
+		return r.Issues[i].Pointer < r.Issues[j].Pointer
+	})
+
+	return writeReport(r, *format, stdout, stderr)
+}
+
+// writeReport writes the report and returns exit code depending on whether
+// there are any errors in the report.
+func writeReport(r report, format string, stdout, stderr io.Writer) int {
+	if err := r.write(stdout, format); err != nil {
+		fmt.Fprintf(stderr, "Error: %s\n", err)
+		return exitError
+	}
+
+	for _, i := range r.Issues {
+		if i.Severity == string(lint.SeverityError) {
+			return exitIssues
+		}
+	}
+	return exitOK
+}
+
+// specErrors flattens the error returned by spec validation.
+func specErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	ce, ok := err.(*errors.CompositeError)
+	if !ok {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, e := range ce.Errors {
+		errs = append(errs, specErrors(e)...)
+	}
+	return errs
+}
+
+var (
+	// leadingPath matches path at the start of go-openapi error message,
+	// e.g. `"paths./pets.get.responses" must validate ...`.
+	leadingPath = regexp.MustCompile(`^"([^"]+)"`)
+
+	// definitionName matches definition name in go-openapi error message,
+	// e.g. `... not defined as property in definition "Pet"`.
+	definitionName = regexp.MustCompile(`definition "([^"]+)"`)
+
+	// quoted matches any quoted token in the error message.
+	quoted = regexp.MustCompile(`"([^"]+)"`)
+)
+
+// locate returns the JSON Pointer of the spec validation error. Not every
+// error has location, so the best guess is returned. The pointer is empty
+// if the location is unknown.
+func locate(d *document, err error) string {
+	if ve, ok := err.(*errors.Validation); ok && ve.Name != "" {
+		if tokens := d.lookupDotted(ve.Name, scalar(ve.Value)); len(tokens) > 0 {
+			return lint.Pointer(tokens...)
+		}
+	}
+
+	msg := err.Error()
+
+	if m := leadingPath.FindStringSubmatch(msg); m != nil {
+		if tokens := d.lookupDotted(m[1], ""); len(tokens) > 0 {
+			return lint.Pointer(tokens...)
+		}
+	}
+
+	if m := definitionName.FindStringSubmatch(msg); m != nil {
+		if tokens := []string{"definitions", m[1]}; d.has(tokens) {
+			return lint.Pointer(tokens...)
+		}
+	}
+
+	// As a last resort, find the first key or value that contains the
+	// quoted value, e.g. `"getPet" is defined 2 times`.
+	if m := quoted.FindStringSubmatch(msg); m != nil {
+		if tokens := d.search(m[1]); len(tokens) > 0 {
+			return lint.Pointer(tokens...)
+		}
+	}
+
+	return ""
+}
+
+// scalar returns string representation of the scalar value, or empty string.
+func scalar(v interface{}) string {
+	switch v.(type) {
+	case string, bool, int, int64, float64:
+		return fmt.Sprint(v)
+	default:
+		return ""
+	}
+}
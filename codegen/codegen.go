@@ -0,0 +1,794 @@
+// Package codegen generates Go code from OpenAPI specification: structs for
+// operation query parameters to decode with oas.DecodeQuery, model types
+// from definitions and request and response bodies, and a handler interface
+// with an operation handlers map builder for oas.OperationRouter.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/jsonpointer"
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2"
+)
+
+// DefaultPackage is the package name of the generated code if none is given.
+const DefaultPackage = "api"
+
+// Config is the code generation configuration.
+type Config struct {
+	// Package is the package name of the generated code.
+	Package string
+}
+
+// Generate generates Go code for the spec document. The code is formatted
+// with gofmt.
+//
+// The document is generated from the original spec, so definition
+// references become named types. References to other files are not
+// supported; bundle the spec into a single file before generation.
+func Generate(doc *oas.Document, cfg Config) ([]byte, error) {
+	if cfg.Package == "" {
+		cfg.Package = DefaultPackage
+	}
+
+	g := &generator{
+		spec:    doc.OrigSpec(),
+		names:   make(map[string]string),
+		imports: map[string]bool{"net/http": true},
+	}
+	if err := g.generate(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprint(&buf, "// Code generated by oas generate. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", cfg.Package)
+	fmt.Fprint(&buf, "import (\n")
+	for _, path := range sortedImports(g.imports) {
+		fmt.Fprintf(&buf, "\t%q\n", path)
+	}
+	fmt.Fprint(&buf, ")\n")
+	for _, decl := range g.decls {
+		fmt.Fprintf(&buf, "\n%s", decl)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		// This should never happen, unless the generator is broken.
+		return nil, fmt.Errorf("format generated code: %v", err)
+	}
+	return src, nil
+}
+
+type generator struct {
+	spec *spec.Swagger
+
+	// names maps declared type names to what they were declared for.
+	names   map[string]string
+	imports map[string]bool
+	decls   []string
+}
+
+// operation is an operation with parameters of the path item merged in.
+type operation struct {
+	*spec.Operation
+
+	method string
+	path   string
+	name   string
+	params []spec.Parameter
+}
+
+func (o operation) String() string {
+	return strings.ToUpper(o.method) + " " + o.path
+}
+
+func (g *generator) generate() error {
+	ops, err := g.operations()
+	if err != nil {
+		return err
+	}
+
+	defs := sortedSchemaKeys(g.spec.Definitions)
+
+	// Reserve the names of all top-level declarations first, so that names
+	// of nested types never take them.
+	for _, name := range defs {
+		if err := g.reserve(goName(name), fmt.Sprintf("definition %q", name)); err != nil {
+			return err
+		}
+	}
+	for _, op := range ops {
+		if err := g.reserve(op.name+"Params", fmt.Sprintf("parameters of operation %q", op.ID)); err != nil {
+			return err
+		}
+	}
+
+	g.declareHandler(ops)
+
+	for _, op := range ops {
+		if err := g.declareParams(op); err != nil {
+			return err
+		}
+	}
+	for _, op := range ops {
+		if err := g.declareBodies(op); err != nil {
+			return err
+		}
+	}
+	for _, name := range defs {
+		sch := g.spec.Definitions[name]
+		doc := fmt.Sprintf("%s is the %s definition.", goName(name), name)
+		if _, err := g.declareType(goName(name), &sch, doc); err != nil {
+			return fmt.Errorf("definition %q: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// operations returns operations ordered by path and method.
+func (g *generator) operations() ([]operation, error) {
+	if g.spec.Paths == nil {
+		return nil, nil
+	}
+
+	paths := make([]string, 0, len(g.spec.Paths.Paths))
+	for path := range g.spec.Paths.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var ops []operation
+	methodOps := make(map[string]string)
+	for _, path := range paths {
+		item := g.spec.Paths.Paths[path]
+		methods := []struct {
+			method string
+			op     *spec.Operation
+		}{
+			{"delete", item.Delete},
+			{"get", item.Get},
+			{"head", item.Head},
+			{"options", item.Options},
+			{"patch", item.Patch},
+			{"post", item.Post},
+			{"put", item.Put},
+		}
+
+		for _, m := range methods {
+			if m.op == nil {
+				continue
+			}
+
+			op := operation{Operation: m.op, method: m.method, path: path}
+			if op.ID == "" {
+				return nil, fmt.Errorf("operation %s has no operationId", op)
+			}
+			op.name = goName(op.ID)
+			if other, ok := methodOps[op.name]; ok {
+				return nil, fmt.Errorf("method name %s of operation %q collides with operation %q", op.name, op.ID, other)
+			}
+			methodOps[op.name] = op.ID
+
+			params, err := g.parameters(item.Parameters, m.op.Parameters)
+			if err != nil {
+				return nil, fmt.Errorf("operation %q: %v", op.ID, err)
+			}
+			op.params = params
+
+			ops = append(ops, op)
+		}
+	}
+
+	return ops, nil
+}
+
+// parameters returns the operation parameters with path item parameters
+// not overridden by the operation.
+func (g *generator) parameters(common, own []spec.Parameter) ([]spec.Parameter, error) {
+	var params []spec.Parameter
+	seen := make(map[string]bool)
+	for _, ps := range [][]spec.Parameter{own, common} {
+		for _, p := range ps {
+			p, err := g.resolveParameter(p)
+			if err != nil {
+				return nil, err
+			}
+			if seen[p.In+"/"+p.Name] {
+				continue
+			}
+			seen[p.In+"/"+p.Name] = true
+			params = append(params, p)
+		}
+	}
+	return params, nil
+}
+
+func (g *generator) resolveParameter(p spec.Parameter) (spec.Parameter, error) {
+	ref := p.Ref.String()
+	if ref == "" {
+		return p, nil
+	}
+
+	name, ok := localRef(ref, "parameters")
+	if !ok {
+		return p, fmt.Errorf("unsupported reference %q", ref)
+	}
+	resolved, ok := g.spec.Parameters[name]
+	if !ok {
+		return p, fmt.Errorf("unresolved reference %q", ref)
+	}
+	return resolved, nil
+}
+
+func (g *generator) resolveResponse(resp spec.Response) (spec.Response, error) {
+	ref := resp.Ref.String()
+	if ref == "" {
+		return resp, nil
+	}
+
+	name, ok := localRef(ref, "responses")
+	if !ok {
+		return resp, fmt.Errorf("unsupported reference %q", ref)
+	}
+	resolved, ok := g.spec.Responses[name]
+	if !ok {
+		return resp, fmt.Errorf("unresolved reference %q", ref)
+	}
+	return resolved, nil
+}
+
+// declareHandler declares the handler interface and the operation handlers
+// map builder.
+func (g *generator) declareHandler(ops []operation) {
+	var buf bytes.Buffer
+
+	fmt.Fprint(&buf, "// Handler handles operations of the API.\n")
+	fmt.Fprint(&buf, "type Handler interface {\n")
+	for i, op := range ops {
+		if i > 0 {
+			fmt.Fprint(&buf, "\n")
+		}
+		fmt.Fprintf(&buf, "\t// %s handles operation %s (%s).\n", op.name, op.ID, op)
+		if op.Summary != "" {
+			writeComment(&buf, "\t", op.Summary)
+		}
+		fmt.Fprintf(&buf, "\t%s(w http.ResponseWriter, req *http.Request)\n", op.name)
+	}
+	fmt.Fprint(&buf, "}\n\n")
+
+	fmt.Fprint(&buf, "// OperationHandlers returns handlers of the API operations mapped by\n")
+	fmt.Fprint(&buf, "// operation id, to use with oas.OperationRouter.\n")
+	fmt.Fprint(&buf, "func OperationHandlers(h Handler) map[string]http.Handler {\n")
+	fmt.Fprint(&buf, "\treturn map[string]http.Handler{\n")
+	for _, op := range ops {
+		fmt.Fprintf(&buf, "\t\t%q: http.HandlerFunc(h.%s),\n", op.ID, op.name)
+	}
+	fmt.Fprint(&buf, "\t}\n}\n")
+
+	g.decls = append(g.decls, buf.String())
+}
+
+// declareParams declares struct of the operation query parameters.
+func (g *generator) declareParams(op operation) error {
+	var query []spec.Parameter
+	for _, p := range op.params {
+		if p.In == "query" {
+			query = append(query, p)
+		}
+	}
+	if len(query) == 0 {
+		return nil
+	}
+	sort.Slice(query, func(i, j int) bool {
+		return query[i].Name < query[j].Name
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// %sParams represents query parameters of operation %s.\n", op.name, op.ID)
+	fmt.Fprintf(&buf, "// Use oas.DecodeQuery to decode them from the request.\n")
+	fmt.Fprintf(&buf, "type %sParams struct {\n", op.name)
+
+	fields := make(map[string]bool)
+	var omitted []string
+	for _, p := range query {
+		typ, err := parameterType(p)
+		if err != nil {
+			omitted = append(omitted, fmt.Sprintf("Parameter %q is omitted: %v.", p.Name, err))
+			continue
+		}
+
+		if p.Description != "" {
+			separateField(&buf, len(fields))
+			writeComment(&buf, "\t", p.Description)
+		}
+		fmt.Fprintf(&buf, "\t%s %s `oas:%q`\n", uniqueField(fields, goName(p.Name)), typ, p.Name)
+	}
+	for i, msg := range omitted {
+		if i == 0 {
+			separateField(&buf, len(fields))
+		}
+		fmt.Fprintf(&buf, "\t// %s\n", msg)
+	}
+	fmt.Fprint(&buf, "}\n")
+
+	g.decls = append(g.decls, buf.String())
+	return nil
+}
+
+// parameterType returns the Go type of the value that convert.Parameter
+// produces for the parameter. Values of optional parameters without
+// defaults are pointers, so that missing values can be told apart.
+func parameterType(p spec.Parameter) (string, error) {
+	if p.Type == "array" {
+		if p.Items == nil {
+			return "", fmt.Errorf("array has no items")
+		}
+		switch p.Items.Type {
+		case "string":
+			return "[]string", nil
+		case "integer":
+			if p.Items.Format == "int32" {
+				return "[]int32", nil
+			}
+			return "[]int64", nil
+		case "number":
+			if p.Items.Format == "float" {
+				return "[]float32", nil
+			}
+			return "[]float64", nil
+		default:
+			return "", fmt.Errorf("items of type %s are not supported", p.Items.Type)
+		}
+	}
+
+	var typ string
+	switch p.Type {
+	case "string":
+		switch p.Format {
+		case "", "partial-time", "uuid":
+			typ = "string"
+		default:
+			return "", fmt.Errorf("format %s of type string is not supported", p.Format)
+		}
+	case "integer":
+		switch p.Format {
+		case "int32":
+			typ = "int32"
+		case "int64", "":
+			typ = "int64"
+		default:
+			return "", fmt.Errorf("format %s of type integer is not supported", p.Format)
+		}
+	case "number":
+		switch p.Format {
+		case "float":
+			typ = "float32"
+		case "double", "":
+			typ = "float64"
+		default:
+			return "", fmt.Errorf("format %s of type number is not supported", p.Format)
+		}
+	case "boolean":
+		typ = "bool"
+	default:
+		return "", fmt.Errorf("type %s is not supported", p.Type)
+	}
+
+	if !p.Required && p.Default == nil {
+		typ = "*" + typ
+	}
+	return typ, nil
+}
+
+// declareBodies declares types of the operation request and response bodies
+// that are not references to definitions.
+func (g *generator) declareBodies(op operation) error {
+	for _, p := range op.params {
+		if p.In != "body" {
+			continue
+		}
+		if err := g.checkRefs(p.Schema); err != nil {
+			return fmt.Errorf("operation %q body: %v", op.ID, err)
+		}
+		if !hasInlineStruct(p.Schema) {
+			continue
+		}
+		name := g.unique(op.name + "Body")
+		doc := fmt.Sprintf("%s is the request body of operation %s.", name, op.ID)
+		if _, err := g.declareType(name, p.Schema, doc); err != nil {
+			return fmt.Errorf("operation %q body: %v", op.ID, err)
+		}
+	}
+
+	if op.Responses == nil {
+		return nil
+	}
+
+	resps := make(map[string]spec.Response)
+	for code, resp := range op.Responses.StatusCodeResponses {
+		resps[fmt.Sprint(code)] = resp
+	}
+	if op.Responses.Default != nil {
+		resps["default"] = *op.Responses.Default
+	}
+
+	codes := make([]string, 0, len(resps))
+	for code := range resps {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		resp, err := g.resolveResponse(resps[code])
+		if err != nil {
+			return fmt.Errorf("operation %q response %s: %v", op.ID, code, err)
+		}
+		if err := g.checkRefs(resp.Schema); err != nil {
+			return fmt.Errorf("operation %q response %s: %v", op.ID, code, err)
+		}
+		if !hasInlineStruct(resp.Schema) {
+			continue
+		}
+
+		suffix := code
+		if code == "default" {
+			suffix = "Default"
+		}
+		name := g.unique(op.name + suffix + "Response")
+		doc := fmt.Sprintf("%s is the body of response %s of operation %s.", name, code, op.ID)
+		if _, err := g.declareType(name, resp.Schema, doc); err != nil {
+			return fmt.Errorf("operation %q response %s: %v", op.ID, code, err)
+		}
+	}
+
+	return nil
+}
+
+// declareType declares named type for the schema.
+func (g *generator) declareType(name string, sch *spec.Schema, doc string) (string, error) {
+	if isStruct(sch) {
+		return name, g.declareStruct(name, sch, doc)
+	}
+
+	// Reserve the place, so that the type is declared before the types
+	// of its elements.
+	idx := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	typ, err := g.schemaType(sch, name, name)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	writeComment(&buf, "", doc)
+	if sch.Description != "" {
+		fmt.Fprint(&buf, "//\n")
+		writeComment(&buf, "", sch.Description)
+	}
+	fmt.Fprintf(&buf, "type %s %s\n", name, typ)
+	g.decls[idx] = buf.String()
+
+	return name, nil
+}
+
+// declareStruct declares struct type for the object schema. Properties of
+// allOf schemas are merged in, definitions referenced by allOf are embedded.
+func (g *generator) declareStruct(name string, sch *spec.Schema, doc string) error {
+	idx := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	var embedded []string
+	props := make(map[string]spec.Schema)
+	required := make(map[string]bool)
+	if err := g.collectProperties(sch, &embedded, props, required); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	writeComment(&buf, "", doc)
+	if sch.Description != "" {
+		fmt.Fprint(&buf, "//\n")
+		writeComment(&buf, "", sch.Description)
+	}
+	fmt.Fprintf(&buf, "type %s struct {\n", name)
+
+	fields := make(map[string]bool)
+	for _, typ := range embedded {
+		fields[typ] = true
+		fmt.Fprintf(&buf, "\t%s\n", typ)
+	}
+	if len(embedded) > 0 && len(props) > 0 {
+		fmt.Fprint(&buf, "\n")
+	}
+
+	for i, prop := range sortedSchemaKeys(props) {
+		ps := props[prop]
+		field := uniqueField(fields, goName(prop))
+		typ, err := g.schemaType(&ps, name+field, prop+" property of "+name)
+		if err != nil {
+			return fmt.Errorf("property %q: %v", prop, err)
+		}
+
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+			if isPointable(typ) {
+				typ = "*" + typ
+			}
+		}
+
+		if ps.Description != "" {
+			separateField(&buf, i)
+			writeComment(&buf, "\t", ps.Description)
+		}
+		fmt.Fprintf(&buf, "\t%s %s `json:%q`\n", field, typ, tag)
+	}
+	fmt.Fprint(&buf, "}\n")
+
+	g.decls[idx] = buf.String()
+	return nil
+}
+
+func (g *generator) collectProperties(sch *spec.Schema, embedded *[]string, props map[string]spec.Schema, required map[string]bool) error {
+	for _, sub := range sch.AllOf {
+		if ref := sub.Ref.String(); ref != "" {
+			typ, err := g.refType(ref)
+			if err != nil {
+				return err
+			}
+			*embedded = append(*embedded, typ)
+			continue
+		}
+		sub := sub
+		if err := g.collectProperties(&sub, embedded, props, required); err != nil {
+			return err
+		}
+	}
+
+	for name, prop := range sch.Properties {
+		props[name] = prop
+	}
+	for _, name := range sch.Required {
+		required[name] = true
+	}
+	return nil
+}
+
+// schemaType returns the Go type for the schema. Types of inline objects
+// are declared with the name and documented as what the schema describes.
+func (g *generator) schemaType(sch *spec.Schema, name, what string) (string, error) {
+	if sch == nil {
+		return "interface{}", nil
+	}
+	if ref := sch.Ref.String(); ref != "" {
+		return g.refType(ref)
+	}
+	if isStruct(sch) {
+		name = g.unique(name)
+		doc := fmt.Sprintf("%s is the %s.", name, what)
+		return name, g.declareStruct(name, sch, doc)
+	}
+
+	switch schemaTypeName(sch) {
+	case "array":
+		if sch.Items == nil || sch.Items.Schema == nil {
+			return "[]interface{}", nil
+		}
+		elem, err := g.schemaType(sch.Items.Schema, name+"Item", "item of "+what)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	case "object":
+		if sch.AdditionalProperties == nil || sch.AdditionalProperties.Schema == nil {
+			return "map[string]interface{}", nil
+		}
+		elem, err := g.schemaType(sch.AdditionalProperties.Schema, name+"Value", "value of "+what)
+		if err != nil {
+			return "", err
+		}
+		return "map[string]" + elem, nil
+	case "string":
+		if sch.Format == "date-time" {
+			g.imports["time"] = true
+			return "time.Time", nil
+		}
+		return "string", nil
+	case "integer":
+		if sch.Format == "int32" {
+			return "int32", nil
+		}
+		return "int64", nil
+	case "number":
+		if sch.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	default:
+		return "interface{}", nil
+	}
+}
+
+func (g *generator) refType(ref string) (string, error) {
+	name, ok := localRef(ref, "definitions")
+	if !ok {
+		return "", fmt.Errorf("unsupported reference %q, only references to definitions of the same file are supported", ref)
+	}
+	if _, ok := g.spec.Definitions[name]; !ok {
+		return "", fmt.Errorf("unresolved reference %q", ref)
+	}
+	return goName(name), nil
+}
+
+// checkRefs checks that all references in the schema are references to
+// definitions, even if the schema itself needs no type declaration.
+func (g *generator) checkRefs(sch *spec.Schema) error {
+	if sch == nil {
+		return nil
+	}
+	if ref := sch.Ref.String(); ref != "" {
+		_, err := g.refType(ref)
+		return err
+	}
+
+	if sch.Items != nil {
+		if err := g.checkRefs(sch.Items.Schema); err != nil {
+			return err
+		}
+	}
+	if sch.AdditionalProperties != nil {
+		if err := g.checkRefs(sch.AdditionalProperties.Schema); err != nil {
+			return err
+		}
+	}
+	for i := range sch.AllOf {
+		if err := g.checkRefs(&sch.AllOf[i]); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedSchemaKeys(sch.Properties) {
+		prop := sch.Properties[name]
+		if err := g.checkRefs(&prop); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *generator) reserve(name, what string) error {
+	if other, ok := g.names[name]; ok {
+		return fmt.Errorf("type name %s of %s collides with %s", name, what, other)
+	}
+	g.names[name] = what
+	return nil
+}
+
+// unique returns the name, or the name with a number appended if the name
+// is taken, and reserves it.
+func (g *generator) unique(name string) string {
+	candidate := name
+	for i := 2; ; i++ {
+		if _, ok := g.names[candidate]; !ok {
+			g.names[candidate] = "generated type"
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+}
+
+// localRef returns the name of the element of the section referenced
+// by the reference, e.g. "Pet" for "#/definitions/Pet".
+func localRef(ref, section string) (string, bool) {
+	prefix := "#/" + section + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(ref, prefix)
+	if strings.Contains(name, "/") {
+		return "", false
+	}
+	return jsonpointer.Unescape(name), true
+}
+
+func schemaTypeName(sch *spec.Schema) string {
+	for _, typ := range sch.Type {
+		if typ != "null" {
+			return typ
+		}
+	}
+	if len(sch.Properties) > 0 || sch.AdditionalProperties != nil {
+		return "object"
+	}
+	return ""
+}
+
+// isStruct returns true if the schema is an object with known properties.
+func isStruct(sch *spec.Schema) bool {
+	if sch == nil || sch.Ref.String() != "" {
+		return false
+	}
+	if len(sch.AllOf) > 0 {
+		return true
+	}
+	return schemaTypeName(sch) == "object" && len(sch.Properties) > 0
+}
+
+// hasInlineStruct returns true if the schema, or the schema of its items,
+// is an inline object that needs a type declaration.
+func hasInlineStruct(sch *spec.Schema) bool {
+	for sch != nil {
+		if isStruct(sch) {
+			return true
+		}
+		if sch.Items == nil {
+			return false
+		}
+		sch = sch.Items.Schema
+	}
+	return false
+}
+
+// isPointable returns true if values of the type need a pointer to tell
+// a missing value from the zero one.
+func isPointable(typ string) bool {
+	return !strings.HasPrefix(typ, "[]") &&
+		!strings.HasPrefix(typ, "map[") &&
+		typ != "interface{}"
+}
+
+func writeComment(buf *bytes.Buffer, indent, text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			fmt.Fprintf(buf, "%s//\n", indent)
+			continue
+		}
+		fmt.Fprintf(buf, "%s// %s\n", indent, line)
+	}
+}
+
+// separateField separates documented field from the previous one with
+// a blank line.
+func separateField(buf *bytes.Buffer, i int) {
+	if i > 0 {
+		fmt.Fprint(buf, "\n")
+	}
+}
+
+func uniqueField(fields map[string]bool, name string) string {
+	candidate := name
+	for i := 2; fields[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	fields[candidate] = true
+	return candidate
+}
+
+func sortedSchemaKeys(m map[string]spec.Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedImports(m map[string]bool) []string {
+	paths := make([]string, 0, len(m))
+	for path := range m {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
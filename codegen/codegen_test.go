@@ -0,0 +1,120 @@
+package codegen
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"testing"
+
+	"github.com/go-openapi/loads"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hypnoglow/oas2"
+)
+
+func TestGenerate(t *testing.T) {
+	doc, err := oas.LoadFile("testdata/petstore.yml")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	src, err := Generate(doc, Config{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected, err := ioutil.ReadFile("testdata/petstore.golden")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	assert.Equal(t, string(expected), string(src))
+
+	// Generated code must compile.
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "api.go", src, 0)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	if _, err := conf.Check("api", fset, []*ast.File{f}, nil); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	t.Run("package name", func(t *testing.T) {
+		src, err := Generate(doc, Config{Package: "petstore"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		assert.Contains(t, string(src), "\npackage petstore\n")
+	})
+}
+
+func TestGenerate_errors(t *testing.T) {
+	cases := map[string]struct {
+		spec     string
+		expected string
+	}{
+		"no operation id": {
+			spec:     `{"paths": {"/pets": {"get": {"responses": {"200": {"description": "OK"}}}}}}`,
+			expected: "operation GET /pets has no operationId",
+		},
+		"method name collision": {
+			spec: `{"paths": {"/pets": {
+				"get": {"operationId": "listPets", "responses": {"200": {"description": "OK"}}},
+				"post": {"operationId": "list-pets", "responses": {"200": {"description": "OK"}}}
+			}}}`,
+			expected: `method name ListPets of operation "list-pets" collides with operation "listPets"`,
+		},
+		"type name collision": {
+			spec: `{
+				"paths": {"/pets": {"get": {"operationId": "listPets", "responses": {"200": {"description": "OK"}}}}},
+				"definitions": {"ListPetsParams": {"type": "object"}}
+			}`,
+			expected: `type name ListPetsParams of parameters of operation "listPets" collides with definition "ListPetsParams"`,
+		},
+		"external reference": {
+			spec: `{
+				"paths": {},
+				"definitions": {"Pet": {"$ref": "pets.yml#/definitions/Pet"}}
+			}`,
+			expected: `definition "Pet": unsupported reference "pets.yml#/definitions/Pet", only references to definitions of the same file are supported`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			d, err := loads.Analyzed(json.RawMessage(c.spec), "")
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			_, err = Generate(&oas.Document{Document: d}, Config{})
+			if assert.Error(t, err) {
+				assert.Equal(t, c.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestGoName(t *testing.T) {
+	cases := map[string]string{
+		"pet":          "Pet",
+		"petId":        "PetID",
+		"pet_id":       "PetID",
+		"get-pet-url":  "GetPetURL",
+		"listPets":     "ListPets",
+		"HTTPServer":   "HTTPServer",
+		"X-Request-ID": "XRequestID",
+		"v2Pets":       "V2Pets",
+		"200":          "X200",
+		"":             "X",
+	}
+
+	for name, expected := range cases {
+		assert.Equal(t, expected, goName(name), name)
+	}
+}
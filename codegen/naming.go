@@ -0,0 +1,95 @@
+package codegen
+
+import (
+	"strings"
+	"unicode"
+)
+
+// commonInitialisms are words that are spelled in upper case in Go names.
+var commonInitialisms = map[string]bool{
+	"API":   true,
+	"ASCII": true,
+	"CPU":   true,
+	"CSS":   true,
+	"DNS":   true,
+	"EOF":   true,
+	"GUID":  true,
+	"HTML":  true,
+	"HTTP":  true,
+	"HTTPS": true,
+	"ID":    true,
+	"IP":    true,
+	"JSON":  true,
+	"LHS":   true,
+	"QPS":   true,
+	"RAM":   true,
+	"RHS":   true,
+	"RPC":   true,
+	"SLA":   true,
+	"SMTP":  true,
+	"SQL":   true,
+	"SSH":   true,
+	"TCP":   true,
+	"TLS":   true,
+	"TTL":   true,
+	"UDP":   true,
+	"UI":    true,
+	"UID":   true,
+	"URI":   true,
+	"URL":   true,
+	"UTF8":  true,
+	"UUID":  true,
+	"VM":    true,
+	"XML":   true,
+}
+
+// goName returns exported Go name for the spec name, e.g. "PetID"
+// for "pet_id" or "petId".
+func goName(name string) string {
+	var b []rune
+	for _, word := range splitWords(name) {
+		if upper := strings.ToUpper(word); commonInitialisms[upper] {
+			b = append(b, []rune(upper)...)
+			continue
+		}
+		rs := []rune(word)
+		rs[0] = unicode.ToUpper(rs[0])
+		b = append(b, rs...)
+	}
+
+	if len(b) == 0 {
+		return "X"
+	}
+	if !unicode.IsLetter(b[0]) {
+		// Names must start with a letter, e.g. for response code "200".
+		return "X" + string(b)
+	}
+	return string(b)
+}
+
+// splitWords splits the name into words by non-alphanumeric characters and
+// lower to upper case transitions.
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+	var prev rune
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+			}
+			word, prev = nil, 0
+			continue
+		}
+		if unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) && len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+		word = append(word, r)
+		prev = r
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
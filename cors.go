@@ -0,0 +1,316 @@
+package oas
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/spec"
+)
+
+// extCORS configures CORS middleware in the spec. See NewCORSMiddleware.
+const extCORS = "x-cors"
+
+// corsSafelistedHeaders are request headers that are always allowed.
+var corsSafelistedHeaders = []string{"Accept", "Accept-Language", "Content-Language", "Content-Type"}
+
+// CORSOptions represent options for CORS middleware.
+type CORSOptions struct {
+	origins     []string
+	credentials bool
+	maxAge      time.Duration
+}
+
+// CORSOption is option to use when creating CORS middleware.
+type CORSOption func(*CORSOptions)
+
+// CORSAllowOrigins returns option that sets origins allowed to make
+// cross-origin requests, e.g. "https://example.com", or "*" to allow any
+// origin. It overrides "allowOrigins" of "x-cors" vendor extension.
+func CORSAllowOrigins(origins ...string) CORSOption {
+	return func(o *CORSOptions) {
+		o.origins = origins
+	}
+}
+
+// CORSAllowCredentials returns option that allows cross-origin requests
+// with credentials, e.g. cookies. It overrides "allowCredentials" of
+// "x-cors" vendor extension.
+func CORSAllowCredentials(allow bool) CORSOption {
+	return func(o *CORSOptions) {
+		o.credentials = allow
+	}
+}
+
+// CORSMaxAge returns option that sets how long results of preflight requests
+// can be cached. It overrides "maxAge" of "x-cors" vendor extension.
+func CORSMaxAge(d time.Duration) CORSOption {
+	return func(o *CORSOptions) {
+		o.maxAge = d
+	}
+}
+
+// NewCORSMiddleware returns a middleware that handles Cross-Origin Resource
+// Sharing for the API described by the spec. It must wrap the router, as
+// preflight requests have no operation.
+//
+// Allowed methods are the methods of operations the request path matches,
+// the same way as the operation router of the basis matches them. Allowed
+// request headers are header parameters of the operation, headers of
+// its security schemes, and CORS-safelisted headers. Exposed headers are
+// headers of the operation responses.
+//
+// Allowed origins, credentials and preflight max age are configured with
+// options, or with "x-cors" vendor extension of the spec, e.g.:
+//
+//  x-cors:
+//    allowOrigins: ["https://example.com"]
+//    allowCredentials: true
+//    maxAge: 600 # seconds
+//
+// By default, no origin is allowed. Any origin, "*", cannot be allowed
+// together with credentials, in which case an error is returned. Preflight
+// requests from origins that are not allowed, or for methods or headers that
+// are not allowed, are responded with 403 Forbidden. Preflight requests to
+// paths not defined in the spec are passed to the next handler as is.
+func NewCORSMiddleware(doc *Document, opts ...CORSOption) (Middleware, error) {
+	options := corsOptionsFromSpec(doc.Spec())
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	anyOrigin := containsFold(options.origins, "*")
+	if anyOrigin && options.credentials {
+		return nil, fmt.Errorf("any origin cannot be allowed with credentials")
+	}
+
+	c := &cors{
+		options:   options,
+		anyOrigin: anyOrigin,
+		basePath:  doc.BasePath(),
+		headers:   make(map[string]corsHeaders),
+	}
+	for method, pathOps := range doc.Analyzer.Operations() {
+		for path, op := range pathOps {
+			c.routes = append(c.routes, templateRoute{
+				method:      strings.ToUpper(method),
+				segments:    pathSegments(path),
+				operationID: op.ID,
+			})
+			c.headers[op.ID] = newCORSHeaders(doc, method, path, op)
+		}
+	}
+	sortRoutes(c.routes)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			c.ServeHTTP(w, req, next)
+		})
+	}, nil
+}
+
+// corsOptionsFromSpec returns options set by "x-cors" vendor extension.
+// Values of unexpected types are ignored.
+func corsOptionsFromSpec(s *spec.Swagger) CORSOptions {
+	var options CORSOptions
+
+	ext, ok := s.Extensions[extCORS].(map[string]interface{})
+	if !ok {
+		return options
+	}
+
+	switch v := ext["allowOrigins"].(type) {
+	case string:
+		options.origins = []string{v}
+	case []interface{}:
+		for _, o := range v {
+			if s, ok := o.(string); ok {
+				options.origins = append(options.origins, s)
+			}
+		}
+	}
+	if v, ok := ext["allowCredentials"].(bool); ok {
+		options.credentials = v
+	}
+	if v, ok := ext["maxAge"].(float64); ok {
+		options.maxAge = time.Duration(v) * time.Second
+	}
+	return options
+}
+
+// corsHeaders are CORS headers of the operation.
+type corsHeaders struct {
+	allow  []string
+	expose []string
+}
+
+func newCORSHeaders(doc *Document, method, path string, op *spec.Operation) corsHeaders {
+	allow := make(map[string]bool)
+	for _, h := range corsSafelistedHeaders {
+		allow[h] = true
+	}
+	for _, p := range doc.Analyzer.ParamsFor(method, path) {
+		if p.In == "header" {
+			allow[http.CanonicalHeaderKey(p.Name)] = true
+		}
+	}
+	for _, scheme := range doc.Analyzer.SecurityDefinitionsFor(op) {
+		switch {
+		case scheme.Type == "apiKey" && scheme.In == "header":
+			allow[http.CanonicalHeaderKey(scheme.Name)] = true
+		case scheme.Type == "basic" || scheme.Type == "oauth2":
+			allow["Authorization"] = true
+		}
+	}
+
+	expose := make(map[string]bool)
+	if op.Responses != nil {
+		resps := make([]spec.Response, 0, len(op.Responses.StatusCodeResponses)+1)
+		for _, resp := range op.Responses.StatusCodeResponses {
+			resps = append(resps, resp)
+		}
+		if op.Responses.Default != nil {
+			resps = append(resps, *op.Responses.Default)
+		}
+		for _, resp := range resps {
+			for name := range resp.Headers {
+				expose[http.CanonicalHeaderKey(name)] = true
+			}
+		}
+	}
+
+	return corsHeaders{
+		allow:  sortedSet(allow),
+		expose: sortedSet(expose),
+	}
+}
+
+type cors struct {
+	options CORSOptions
+
+	// anyOrigin is true if any origin is allowed.
+	anyOrigin bool
+
+	basePath string
+	routes   []templateRoute
+
+	// headers are CORS headers by operation id.
+	headers map[string]corsHeaders
+}
+
+func (c *cors) ServeHTTP(w http.ResponseWriter, req *http.Request, next http.Handler) {
+	if !c.anyOrigin {
+		// Responses depend on the origin, even if the request has none,
+		// so that caches do not serve them to other origins.
+		w.Header().Add("Vary", "Origin")
+	}
+
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		next.ServeHTTP(w, req)
+		return
+	}
+
+	preflight := req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != ""
+	if preflight {
+		c.preflight(w, req, next)
+		return
+	}
+
+	if !c.allowOrigin(origin) {
+		next.ServeHTTP(w, req)
+		return
+	}
+
+	c.setOrigin(w, origin)
+	if route, _, _, ok := matchRoutes(c.routes, c.basePath, req.Method, req.URL.Path); ok {
+		if expose := c.headers[route.operationID].expose; len(expose) > 0 {
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(expose, ", "))
+		}
+	}
+	next.ServeHTTP(w, req)
+}
+
+func (c *cors) preflight(w http.ResponseWriter, req *http.Request, next http.Handler) {
+	// No route has empty method, so methods of all operations the path
+	// matches are returned as allowed.
+	_, _, methods, _ := matchRoutes(c.routes, c.basePath, "", req.URL.Path)
+	if len(methods) == 0 {
+		next.ServeHTTP(w, req)
+		return
+	}
+
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	origin := req.Header.Get("Origin")
+	if !c.allowOrigin(origin) {
+		http.Error(w, "origin is not allowed", http.StatusForbidden)
+		return
+	}
+
+	route, _, _, ok := matchRoutes(c.routes, c.basePath, req.Header.Get("Access-Control-Request-Method"), req.URL.Path)
+	if !ok {
+		http.Error(w, "method is not allowed", http.StatusForbidden)
+		return
+	}
+
+	allowHeaders := c.headers[route.operationID].allow
+	for _, h := range strings.Split(req.Header.Get("Access-Control-Request-Headers"), ",") {
+		h = strings.TrimSpace(h)
+		if h != "" && !containsFold(allowHeaders, h) {
+			http.Error(w, "header "+h+" is not allowed", http.StatusForbidden)
+			return
+		}
+	}
+
+	// Methods are sorted, but may repeat if the path matches several
+	// path templates.
+	allowMethods := methods[:1]
+	for _, m := range methods[1:] {
+		if m != allowMethods[len(allowMethods)-1] {
+			allowMethods = append(allowMethods, m)
+		}
+	}
+
+	c.setOrigin(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowMethods, ", "))
+	w.Header().Set("Access-Control-Allow-Headers", strings.Join(allowHeaders, ", "))
+	if c.options.maxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.options.maxAge.Seconds())))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *cors) allowOrigin(origin string) bool {
+	for _, o := range c.options.origins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// setOrigin sets headers that allow the origin.
+func (c *cors) setOrigin(w http.ResponseWriter, origin string) {
+	if c.anyOrigin {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.options.credentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func sortedSet(set map[string]bool) []string {
+	ss := make([]string, 0, len(set))
+	for s := range set {
+		ss = append(ss, s)
+	}
+	sort.Strings(ss)
+	return ss
+}
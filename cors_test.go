@@ -0,0 +1,207 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCORSMiddleware(t *testing.T) {
+	doc := loadDocFile(t, "testdata/cors.yml")
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	mw, err := NewCORSMiddleware(doc)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	h := mw(next)
+
+	testCases := map[string]struct {
+		method  string
+		path    string
+		headers map[string]string
+
+		expectedStatus  int
+		expectedHeaders map[string]string
+	}{
+		"same origin": {
+			method:          http.MethodGet,
+			path:            "/api/pets",
+			expectedStatus:  http.StatusTeapot,
+			expectedHeaders: map[string]string{"Access-Control-Allow-Origin": "", "Vary": "Origin"},
+		},
+		"actual request": {
+			method:         http.MethodGet,
+			path:           "/api/pets",
+			headers:        map[string]string{"Origin": "https://app.example.com"},
+			expectedStatus: http.StatusTeapot,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "X-Rate-Limit, X-Total-Count",
+				"Vary":                             "Origin",
+			},
+		},
+		"actual request exposes default response headers": {
+			method:         http.MethodGet,
+			path:           "/api/pets/12",
+			headers:        map[string]string{"Origin": "https://app.example.com"},
+			expectedStatus: http.StatusTeapot,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "https://app.example.com",
+				"Access-Control-Expose-Headers": "X-Error-Code",
+			},
+		},
+		"actual request from not allowed origin": {
+			method:         http.MethodGet,
+			path:           "/api/pets",
+			headers:        map[string]string{"Origin": "https://evil.example.com"},
+			expectedStatus: http.StatusTeapot,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "",
+				"Access-Control-Expose-Headers": "",
+				"Vary":                          "Origin",
+			},
+		},
+		"preflight": {
+			method: http.MethodOptions,
+			path:   "/api/pets",
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "content-type, x-api-key",
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET, POST",
+				"Access-Control-Allow-Headers":     "Accept, Accept-Language, Content-Language, Content-Type, X-Api-Key",
+				"Access-Control-Max-Age":           "600",
+			},
+		},
+		"preflight of path with parameter": {
+			method: http.MethodOptions,
+			path:   "/api/pets/12",
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Methods": "DELETE, GET",
+			},
+		},
+		"preflight of literal path": {
+			method: http.MethodOptions,
+			path:   "/api/pets/mine",
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			expectedStatus: http.StatusNoContent,
+			expectedHeaders: map[string]string{
+				// DELETE is routed to the operation of /pets/{id}.
+				"Access-Control-Allow-Methods": "DELETE, GET",
+			},
+		},
+		"preflight of not allowed method": {
+			method: http.MethodOptions,
+			path:   "/api/pets/mine",
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "PUT",
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "",
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+		"preflight of not allowed header": {
+			method: http.MethodOptions,
+			path:   "/api/pets",
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "GET",
+				"Access-Control-Request-Headers": "X-API-Key",
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		"preflight from not allowed origin": {
+			method: http.MethodOptions,
+			path:   "/api/pets",
+			headers: map[string]string{
+				"Origin":                        "https://evil.example.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedHeaders: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		"preflight of unknown path": {
+			method: http.MethodOptions,
+			path:   "/api/owners",
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			expectedStatus: http.StatusTeapot,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+
+			h.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			for k, v := range tc.expectedHeaders {
+				assert.Equal(t, v, rr.Header().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestNewCORSMiddleware_options(t *testing.T) {
+	doc := loadDocFile(t, "testdata/cors.yml")
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {})
+	mw, err := NewCORSMiddleware(doc,
+		CORSAllowOrigins("*"),
+		CORSAllowCredentials(false),
+		CORSMaxAge(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	h := mw(next)
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/pets/12", nil)
+	req.Header.Set("Origin", "https://other.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	rr := httptest.NewRecorder()
+
+	h.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "", rr.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "60", rr.Header().Get("Access-Control-Max-Age"))
+	assert.NotContains(t, rr.Header()["Vary"], "Origin")
+
+	// Any origin is allowed with credentials by the spec.
+	_, err = NewCORSMiddleware(doc, CORSAllowOrigins("*"))
+	assert.EqualError(t, err, "any origin cannot be allowed with credentials")
+}
@@ -0,0 +1,289 @@
+package oas
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CoverageRecorder records operations and responses exercised by requests,
+// e.g. in tests, to find out which parts of the spec are never exercised.
+// It is safe for concurrent use.
+type CoverageRecorder struct {
+	doc *Document
+
+	mu   sync.Mutex
+	hits map[coverageKey]int
+}
+
+// coverageKey identifies recorded responses.
+type coverageKey struct {
+	operationID string
+	status      int
+	contentType string
+}
+
+// NewCoverageRecorder returns a new coverage recorder for the spec.
+func NewCoverageRecorder(doc *Document) *CoverageRecorder {
+	return &CoverageRecorder{
+		doc:  doc,
+		hits: make(map[coverageKey]int),
+	}
+}
+
+// Record records the response of the status code and the content type to
+// the request of the operation.
+func (r *CoverageRecorder) Record(operationID string, status int, contentType string) {
+	if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mt
+	}
+
+	r.mu.Lock()
+	r.hits[coverageKey{operationID, status, contentType}]++
+	r.mu.Unlock()
+}
+
+// CoverageRecorder returns a middleware that records operations and
+// responses of requests to the recorder.
+func (b *ResolvingBasis) CoverageRecorder(rec *CoverageRecorder) Middleware {
+	return func(next http.Handler) http.Handler {
+		return &resolvingCoverageRecorder{
+			next:     next,
+			recorder: rec,
+			strict:   b.strict,
+		}
+	}
+}
+
+type resolvingCoverageRecorder struct {
+	next     http.Handler
+	recorder *CoverageRecorder
+
+	// strict enforces recording. If false, then requests without operation
+	// context are not recorded.
+	strict bool
+}
+
+func (mw *resolvingCoverageRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("coverage recorder middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	ww := newWrapResponseWriter(w, req.ProtoMajor)
+	mw.next.ServeHTTP(ww, req)
+
+	status := ww.Status()
+	if status == 0 {
+		// Nothing has been written, so net/http responds with 200.
+		status = http.StatusOK
+	}
+	mw.recorder.Record(oi.operation.ID, status, ww.Header().Get("Content-Type"))
+}
+
+// Report returns the coverage report of responses recorded so far.
+func (r *CoverageRecorder) Report() *CoverageReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &CoverageReport{}
+	for method, pathOps := range r.doc.Analyzer.Operations() {
+		for path, op := range pathOps {
+			oc := OperationCoverage{
+				OperationID: op.ID,
+				Method:      strings.ToUpper(method),
+				Path:        path,
+			}
+
+			responses := make(map[string]*ResponseCoverage)
+			if op.Responses != nil {
+				for code := range op.Responses.StatusCodeResponses {
+					status := strconv.Itoa(code)
+					responses[status] = &ResponseCoverage{Status: status, Documented: true}
+				}
+				if op.Responses.Default != nil {
+					responses["default"] = &ResponseCoverage{Status: "default", Documented: true}
+				}
+			}
+
+			for key, hits := range r.hits {
+				if key.operationID != op.ID {
+					continue
+				}
+				oc.Hits += hits
+
+				status := strconv.Itoa(key.status)
+				rc, ok := responses[status]
+				if !ok {
+					rc, ok = responses["default"]
+				}
+				if !ok {
+					rc = &ResponseCoverage{Status: status}
+					responses[status] = rc
+				}
+				rc.Hits += hits
+				if rc.ContentTypes == nil {
+					rc.ContentTypes = make(map[string]int)
+				}
+				rc.ContentTypes[key.contentType] += hits
+			}
+
+			for _, rc := range responses {
+				oc.Responses = append(oc.Responses, *rc)
+			}
+			sort.Slice(oc.Responses, func(i, j int) bool {
+				return oc.Responses[i].Status < oc.Responses[j].Status
+			})
+
+			report.Operations = append(report.Operations, oc)
+		}
+	}
+
+	sort.Slice(report.Operations, func(i, j int) bool {
+		a, b := report.Operations[i], report.Operations[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+
+	for _, oc := range report.Operations {
+		report.OperationsTotal++
+		if oc.Hits > 0 {
+			report.OperationsCovered++
+		}
+		for _, rc := range oc.Responses {
+			if !rc.Documented {
+				continue
+			}
+			report.ResponsesTotal++
+			if rc.Hits > 0 {
+				report.ResponsesCovered++
+			}
+		}
+	}
+
+	return report
+}
+
+// CoverageReport reports which operations and documented responses of the
+// spec were exercised. It is JSON-marshalable.
+type CoverageReport struct {
+	Operations []OperationCoverage `json:"operations"`
+
+	OperationsTotal   int `json:"operationsTotal"`
+	OperationsCovered int `json:"operationsCovered"`
+	ResponsesTotal    int `json:"responsesTotal"`
+	ResponsesCovered  int `json:"responsesCovered"`
+}
+
+// OperationCoverage reports coverage of the operation.
+type OperationCoverage struct {
+	OperationID string `json:"operationId"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+
+	// Hits is the number of recorded requests of the operation.
+	Hits int `json:"hits"`
+
+	// Responses are the documented responses, as well as responses of
+	// status codes that are not documented, ordered by status code.
+	Responses []ResponseCoverage `json:"responses"`
+}
+
+// ResponseCoverage reports coverage of the operation response.
+type ResponseCoverage struct {
+	// Status is the status code, or "default" for the default response.
+	Status string `json:"status"`
+
+	// Documented is false if the status code is not documented for the
+	// operation, and there is no default response.
+	Documented bool `json:"documented"`
+
+	// Hits is the number of recorded responses.
+	Hits int `json:"hits"`
+
+	// ContentTypes are the number of recorded responses by media type.
+	// Responses without Content-Type header are recorded with empty one.
+	ContentTypes map[string]int `json:"contentTypes,omitempty"`
+}
+
+// OperationCoverage returns the percentage of operations exercised.
+func (r *CoverageReport) OperationCoverage() float64 {
+	return percentage(r.OperationsCovered, r.OperationsTotal)
+}
+
+// ResponseCoverage returns the percentage of documented responses
+// exercised.
+func (r *CoverageReport) ResponseCoverage() float64 {
+	return percentage(r.ResponsesCovered, r.ResponsesTotal)
+}
+
+// CheckThreshold returns error if the percentage of documented responses
+// exercised is less than min, e.g. 80.
+func (r *CoverageReport) CheckThreshold(min float64) error {
+	if c := r.ResponseCoverage(); c < min {
+		return fmt.Errorf("response coverage %.1f%% is below the threshold %.1f%%", c, min)
+	}
+	return nil
+}
+
+// WriteText writes the report as text: the coverage percentages, and lists
+// of operations and documented responses never exercised, and of recorded
+// responses that are not documented.
+func (r *CoverageReport) WriteText(w io.Writer) error {
+	var lines []string
+	lines = append(lines,
+		fmt.Sprintf("Operation coverage: %d/%d (%.1f%%)", r.OperationsCovered, r.OperationsTotal, r.OperationCoverage()),
+		fmt.Sprintf("Response coverage: %d/%d (%.1f%%)", r.ResponsesCovered, r.ResponsesTotal, r.ResponseCoverage()),
+	)
+
+	var uncovered, undocumented []string
+	for _, oc := range r.Operations {
+		op := fmt.Sprintf("%s %s (%s)", oc.Method, oc.Path, oc.OperationID)
+		if oc.Hits == 0 {
+			uncovered = append(uncovered, op)
+			continue
+		}
+		for _, rc := range oc.Responses {
+			switch {
+			case !rc.Documented:
+				undocumented = append(undocumented, fmt.Sprintf("%s response %s: %d hits", op, rc.Status, rc.Hits))
+			case rc.Hits == 0:
+				uncovered = append(uncovered, fmt.Sprintf("%s response %s", op, rc.Status))
+			}
+		}
+	}
+
+	if len(uncovered) > 0 {
+		lines = append(lines, "", "Not covered:")
+		for _, s := range uncovered {
+			lines = append(lines, "    "+s)
+		}
+	}
+	if len(undocumented) > 0 {
+		lines = append(lines, "", "Not documented:")
+		for _, s := range undocumented {
+			lines = append(lines, "    "+s)
+		}
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+func percentage(n, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(n) * 100 / float64(total)
+}
@@ -0,0 +1,124 @@
+package oas
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverageRecorder(t *testing.T) {
+	doc := loadDocFile(t, "testdata/mock.yml")
+	rec := NewCoverageRecorder(doc)
+
+	b := &ResolvingBasis{adapter: templateAdapter{}, doc: doc, strict: true}
+	b.initCache()
+
+	router := &templateRouter{}
+	err := b.OperationRouter(router).
+		WithOperationHandlers(map[string]http.Handler{
+			"listPets": http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.Write([]byte(`[]`)) // nolint
+			}),
+			"addPet": http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusConflict)
+			}),
+			"getPet": http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/api/pets/1" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.WriteHeader(http.StatusInternalServerError)
+			}),
+		}).
+		WithMiddleware(b.CoverageRecorder(rec)).
+		Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, r := range []struct{ method, path string }{
+		{http.MethodGet, "/api/pets"},
+		{http.MethodGet, "/api/pets"},
+		{http.MethodPost, "/api/pets"},
+		{http.MethodGet, "/api/pets/1"},
+		{http.MethodGet, "/api/pets/2"},
+	} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(r.method, r.path, nil))
+	}
+
+	report := rec.Report()
+
+	t.Run("report", func(t *testing.T) {
+		assert.Equal(t, 5, report.OperationsTotal)
+		assert.Equal(t, 3, report.OperationsCovered)
+		assert.Equal(t, 7, report.ResponsesTotal)
+		assert.Equal(t, 3, report.ResponsesCovered)
+		assert.InDelta(t, 60.0, report.OperationCoverage(), 0.01)
+		assert.InDelta(t, 42.86, report.ResponseCoverage(), 0.01)
+
+		assert.Equal(t, OperationCoverage{
+			OperationID: "listPets",
+			Method:      "GET",
+			Path:        "/pets",
+			Hits:        2,
+			Responses: []ResponseCoverage{
+				{Status: "200", Documented: true, Hits: 2, ContentTypes: map[string]int{"application/json": 2}},
+			},
+		}, report.Operations[0])
+
+		assert.Equal(t, OperationCoverage{
+			OperationID: "getPet",
+			Method:      "GET",
+			Path:        "/pets/{id}",
+			Hits:        2,
+			Responses: []ResponseCoverage{
+				{Status: "200", Documented: true},
+				{Status: "404", Documented: true, Hits: 1, ContentTypes: map[string]int{"": 1}},
+				{Status: "500", Documented: false, Hits: 1, ContentTypes: map[string]int{"": 1}},
+			},
+		}, report.Operations[4])
+	})
+
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := report.WriteText(&buf); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		expected := `Operation coverage: 3/5 (60.0%)
+Response coverage: 3/7 (42.9%)
+
+Not covered:
+    POST /pets (addPet) response 201
+    GET /pets/mine (listMyPets)
+    DELETE /pets/{id} (deletePet)
+    GET /pets/{id} (getPet) response 200
+
+Not documented:
+    GET /pets/{id} (getPet) response 500: 1 hits
+`
+		assert.Equal(t, expected, buf.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		b, err := json.Marshal(report)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		assert.Contains(t, string(b), `"responsesTotal":7,"responsesCovered":3`)
+		assert.Contains(t, string(b), `{"status":"default","documented":true,"hits":1,"contentTypes":{"":1}}`)
+	})
+
+	t.Run("threshold", func(t *testing.T) {
+		assert.NoError(t, report.CheckThreshold(40))
+		err := report.CheckThreshold(80)
+		if assert.Error(t, err) {
+			assert.Equal(t, "response coverage 42.9% is below the threshold 80.0%", err.Error())
+		}
+	})
+}
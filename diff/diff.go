@@ -0,0 +1,564 @@
+// Package diff detects changes between two versions of OpenAPI specification
+// and classifies them as breaking or non-breaking for API clients.
+//
+// A change is breaking if a client that works with the old version of the API
+// may fail with the new one, e.g. an operation is removed, a new required
+// parameter is added, or a response property is removed.
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/jsonpointer"
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2"
+)
+
+// Change describes a difference between the old and the new spec.
+type Change struct {
+	// Breaking is true if the change breaks existing clients.
+	Breaking bool `json:"breaking"`
+
+	// Operation is the operation affected by the change, e.g. "GET /pets".
+	// It is empty for changes not related to a single operation.
+	Operation string `json:"operation,omitempty"`
+
+	// Pointer is a JSON Pointer to the changed location in the new spec,
+	// or in the old spec if the location was removed.
+	Pointer string `json:"pointer"`
+
+	Message string `json:"message"`
+}
+
+// Report is the result of spec comparison.
+type Report struct {
+	Changes []Change `json:"changes"`
+}
+
+// Breaking returns true if any of the changes is breaking.
+func (r *Report) Breaking() bool {
+	for _, c := range r.Changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare compares the old version of the spec, from, with the new one, to,
+// and reports changes ordered by location.
+//
+// Operations are matched by method and path template regardless of names of
+// path parameters, so renaming a path parameter is reported as a non-breaking
+// parameter change. Parameters, media types, response bodies and response
+// headers are compared. Other properties, e.g. security requirements, are not.
+func Compare(from, to *oas.Document) *Report {
+	c := &comparer{}
+	c.compare(from.Spec(), to.Spec())
+
+	sort.SliceStable(c.changes, func(i, j int) bool {
+		return c.changes[i].Pointer < c.changes[j].Pointer
+	})
+
+	return &Report{Changes: c.changes}
+}
+
+// direction defines how the schema is used: changes that are safe for
+// requests may break clients if made to responses and vice versa.
+type direction int
+
+const (
+	request direction = iota
+	response
+)
+
+type comparer struct {
+	changes []Change
+
+	// operation is the operation being compared.
+	operation string
+}
+
+func (c *comparer) report(breaking bool, tokens []string, format string, args ...interface{}) {
+	c.changes = append(c.changes, Change{
+		Breaking:  breaking,
+		Operation: c.operation,
+		Pointer:   pointer(tokens),
+		Message:   fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *comparer) compare(from, to *spec.Swagger) {
+	c.compareMediaTypes(from.Consumes, to.Consumes, []string{"consumes"})
+	c.compareMediaTypes(from.Produces, to.Produces, []string{"produces"})
+
+	oldOps, newOps := operations(from), operations(to)
+
+	for _, key := range sortedOperations(oldOps) {
+		o := oldOps[key]
+		c.operation = o.String()
+		n, ok := newOps[key]
+		if !ok {
+			c.report(true, o.tokens, "operation removed")
+			continue
+		}
+		c.operation = n.String()
+		c.compareOperation(o, n)
+	}
+
+	for _, key := range sortedOperations(newOps) {
+		if _, ok := oldOps[key]; !ok {
+			c.operation = newOps[key].String()
+			c.report(false, newOps[key].tokens, "operation added")
+		}
+	}
+	c.operation = ""
+}
+
+func (c *comparer) compareOperation(from, to operation) {
+	// Media types of the operation override global ones, so compare
+	// effective media types only if the operation overrides them.
+	if from.Consumes != nil || to.Consumes != nil {
+		c.compareMediaTypes(from.consumes, to.consumes, extend(to.tokens, "consumes"))
+	}
+	if from.Produces != nil || to.Produces != nil {
+		c.compareMediaTypes(from.produces, to.produces, extend(to.tokens, "produces"))
+	}
+
+	c.compareParameters(from, to)
+	c.compareResponses(from, to)
+}
+
+func (c *comparer) compareMediaTypes(from, to []string, tokens []string) {
+	for _, mt := range from {
+		if !containsString(to, mt) {
+			// Clients may send requests of the removed media type, or expect
+			// responses of it.
+			c.report(true, tokens, "media type %q removed", mt)
+		}
+	}
+	for _, mt := range to {
+		if !containsString(from, mt) {
+			c.report(false, tokens, "media type %q added", mt)
+		}
+	}
+}
+
+func (c *comparer) compareParameters(from, to operation) {
+	renamed := renamedPathParams(from.pathParams, to.pathParams)
+
+	for _, key := range sortedParamKeys(from.params) {
+		op := from.params[key]
+		toKey := key
+		if name, ok := renamed[key.name]; ok && key.in == "path" {
+			toKey.name = name
+		}
+		np, ok := to.params[toKey]
+		if !ok {
+			c.report(true, op.tokens, "%s parameter %q removed", key.in, key.name)
+			continue
+		}
+
+		tokens := np.tokens
+		if toKey != key {
+			c.report(false, tokens, "path parameter %q renamed to %q", key.name, toKey.name)
+		}
+		if !op.Required && np.Required {
+			c.report(true, tokens, "%s parameter %q became required", key.in, key.name)
+		}
+		if op.Required && !np.Required {
+			c.report(false, tokens, "%s parameter %q became optional", key.in, key.name)
+		}
+
+		if key.in == "body" {
+			c.compareSchema(op.Schema, np.Schema, request, extend(tokens, "schema"), "request body", 0)
+			continue
+		}
+
+		c.compareType(
+			simpleType{op.Type, op.Format},
+			simpleType{np.Type, np.Format},
+			tokens,
+			fmt.Sprintf("%s parameter %q", key.in, key.name),
+		)
+		c.compareEnum(op.Enum, np.Enum, request, tokens, fmt.Sprintf("%s parameter %q", key.in, key.name))
+		c.compareItems(op.Items, np.Items, request, extend(tokens, "items"), fmt.Sprintf("%s parameter %q items", key.in, key.name))
+	}
+
+	renamedTo := make(map[string]bool)
+	for _, name := range renamed {
+		renamedTo[name] = true
+	}
+
+	for _, key := range sortedParamKeys(to.params) {
+		if _, ok := from.params[key]; ok {
+			continue
+		}
+		if key.in == "path" && renamedTo[key.name] {
+			continue
+		}
+		np := to.params[key]
+		if np.Required {
+			c.report(true, np.tokens, "required %s parameter %q added", key.in, key.name)
+		} else {
+			c.report(false, np.tokens, "optional %s parameter %q added", key.in, key.name)
+		}
+	}
+}
+
+func (c *comparer) compareItems(from, to *spec.Items, dir direction, tokens []string, what string) {
+	if from == nil || to == nil {
+		return
+	}
+
+	c.compareType(
+		simpleType{from.Type, from.Format},
+		simpleType{to.Type, to.Format},
+		tokens,
+		what,
+	)
+	c.compareEnum(from.Enum, to.Enum, dir, tokens, what)
+	c.compareItems(from.Items, to.Items, dir, extend(tokens, "items"), what+" items")
+}
+
+func (c *comparer) compareResponses(from, to operation) {
+	oldResps, newResps := responses(from.Operation), responses(to.Operation)
+
+	for _, code := range sortedKeys(oldResps) {
+		or := oldResps[code]
+		nr, ok := newResps[code]
+		if !ok {
+			c.report(true, extend(from.tokens, "responses", code), "response %s removed", code)
+			continue
+		}
+
+		tokens := extend(to.tokens, "responses", code)
+		if or.Schema != nil && nr.Schema == nil {
+			c.report(true, tokens, "response %s body removed", code)
+		} else {
+			c.compareSchema(or.Schema, nr.Schema, response, extend(tokens, "schema"), "response "+code+" body", 0)
+		}
+		c.compareHeaders(or.Headers, nr.Headers, extend(tokens, "headers"), "response "+code)
+	}
+
+	for _, code := range sortedKeys(newResps) {
+		if _, ok := oldResps[code]; !ok {
+			c.report(false, extend(to.tokens, "responses", code), "response %s added", code)
+		}
+	}
+}
+
+// compareHeaders reports removed response headers, which clients may rely
+// on, and changes of their types and enums. Header names are
+// case-insensitive.
+func (c *comparer) compareHeaders(from, to map[string]spec.Header, tokens []string, what string) {
+	for _, name := range sortedHeaders(from) {
+		fh := from[name]
+		toName, ok := findHeader(to, name)
+		if !ok {
+			c.report(true, extend(tokens, name), "%s header %q removed", what, name)
+			continue
+		}
+
+		th := to[toName]
+		headerTokens := extend(tokens, toName)
+		headerWhat := fmt.Sprintf("%s header %q", what, name)
+		c.compareType(
+			simpleType{fh.Type, fh.Format},
+			simpleType{th.Type, th.Format},
+			headerTokens,
+			headerWhat,
+		)
+		c.compareEnum(fh.Enum, th.Enum, response, headerTokens, headerWhat)
+		c.compareItems(fh.Items, th.Items, response, extend(headerTokens, "items"), headerWhat+" items")
+	}
+
+	for _, name := range sortedHeaders(to) {
+		if _, ok := findHeader(from, name); !ok {
+			c.report(false, extend(tokens, name), "%s header %q added", what, name)
+		}
+	}
+}
+
+// simpleType is a type of non-body parameter or items.
+type simpleType struct {
+	typ    string
+	format string
+}
+
+func (t simpleType) String() string {
+	if t.format == "" {
+		return t.typ
+	}
+	return t.typ + "/" + t.format
+}
+
+func (c *comparer) compareType(from, to simpleType, tokens []string, what string) {
+	if from != to {
+		c.report(true, tokens, "%s type changed from %s to %s", what, from, to)
+	}
+}
+
+// compareEnum reports removed enum values of requests, which clients may
+// send, and added enum values of responses, which clients may not expect.
+func (c *comparer) compareEnum(from, to []interface{}, dir direction, tokens []string, what string) {
+	if len(from) == 0 && len(to) == 0 {
+		return
+	}
+
+	if len(to) > 0 && len(from) == 0 {
+		c.report(dir == request, tokens, "%s is restricted to enum", what)
+		return
+	}
+	if len(from) > 0 && len(to) == 0 {
+		c.report(dir == response, tokens, "%s is no longer restricted to enum", what)
+		return
+	}
+
+	for _, v := range from {
+		if !containsValue(to, v) {
+			c.report(dir == request, tokens, "%s enum value %v removed", what, v)
+		}
+	}
+	for _, v := range to {
+		if !containsValue(from, v) {
+			c.report(dir == response, tokens, "%s enum value %v added", what, v)
+		}
+	}
+}
+
+// operationKey identifies operation by method and path template with
+// parameter names omitted, e.g. "/pets/{}".
+type operationKey struct {
+	method string
+	path   string
+}
+
+// operation is an operation with effective media types and parameters.
+type operation struct {
+	*spec.Operation
+
+	method string
+	path   string
+
+	// pathParams are names of path template parameters in order.
+	pathParams []string
+
+	tokens   []string
+	consumes []string
+	produces []string
+	params   map[paramKey]param
+}
+
+func (o operation) String() string {
+	return strings.ToUpper(o.method) + " " + o.path
+}
+
+// paramKey identifies parameter by location and name.
+type paramKey struct {
+	in   string
+	name string
+}
+
+type param struct {
+	spec.Parameter
+
+	tokens []string
+}
+
+func operations(s *spec.Swagger) map[operationKey]operation {
+	ops := make(map[operationKey]operation)
+	if s.Paths == nil {
+		return ops
+	}
+
+	for path, item := range s.Paths.Paths {
+		methods := []struct {
+			method string
+			op     *spec.Operation
+		}{
+			{"get", item.Get},
+			{"put", item.Put},
+			{"post", item.Post},
+			{"delete", item.Delete},
+			{"options", item.Options},
+			{"head", item.Head},
+			{"patch", item.Patch},
+		}
+
+		for _, m := range methods {
+			if m.op == nil {
+				continue
+			}
+
+			template, pathParams := pathTemplate(path)
+			o := operation{
+				Operation:  m.op,
+				method:     m.method,
+				path:       path,
+				pathParams: pathParams,
+				tokens:     []string{"paths", path, m.method},
+				consumes:  s.Consumes,
+				produces:  s.Produces,
+				params:    make(map[paramKey]param),
+			}
+			if m.op.Consumes != nil {
+				o.consumes = m.op.Consumes
+			}
+			if m.op.Produces != nil {
+				o.produces = m.op.Produces
+			}
+
+			// Operation parameters override path item parameters.
+			for i, p := range item.Parameters {
+				o.params[paramKey{p.In, p.Name}] = param{p, []string{"paths", path, "parameters", fmt.Sprint(i)}}
+			}
+			for i, p := range m.op.Parameters {
+				o.params[paramKey{p.In, p.Name}] = param{p, []string{"paths", path, m.method, "parameters", fmt.Sprint(i)}}
+			}
+
+			ops[operationKey{m.method, template}] = o
+		}
+	}
+
+	return ops
+}
+
+// templateParam matches a parameter of path template, e.g. "{id}".
+var templateParam = regexp.MustCompile(`\{[^{}]*\}`)
+
+// pathTemplate returns the path template with parameter names omitted,
+// e.g. "/pets/{}" for "/pets/{id}", and the parameter names.
+func pathTemplate(path string) (string, []string) {
+	var names []string
+	for _, m := range templateParam.FindAllString(path, -1) {
+		names = append(names, m[1:len(m)-1])
+	}
+	return templateParam.ReplaceAllString(path, "{}"), names
+}
+
+// renamedPathParams returns new names of path parameters by old names.
+// Parameters are matched by position in the path template.
+func renamedPathParams(from, to []string) map[string]string {
+	renamed := make(map[string]string)
+	for i := range from {
+		if i < len(to) && from[i] != to[i] {
+			renamed[from[i]] = to[i]
+		}
+	}
+	return renamed
+}
+
+// responses returns operation responses by status code, or "default".
+func responses(op *spec.Operation) map[string]spec.Response {
+	resps := make(map[string]spec.Response)
+	if op.Responses == nil {
+		return resps
+	}
+
+	if op.Responses.Default != nil {
+		resps["default"] = *op.Responses.Default
+	}
+	for code, resp := range op.Responses.StatusCodeResponses {
+		resps[fmt.Sprint(code)] = resp
+	}
+	return resps
+}
+
+func sortedOperations(ops map[operationKey]operation) []operationKey {
+	keys := make([]operationKey, 0, len(ops))
+	for k := range ops {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].path != keys[j].path {
+			return keys[i].path < keys[j].path
+		}
+		return keys[i].method < keys[j].method
+	})
+	return keys
+}
+
+func sortedParamKeys(params map[paramKey]param) []paramKey {
+	keys := make([]paramKey, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].in != keys[j].in {
+			return keys[i].in < keys[j].in
+		}
+		return keys[i].name < keys[j].name
+	})
+	return keys
+}
+
+func sortedHeaders(m map[string]spec.Header) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// findHeader returns the name of the header in m that matches the name
+// case-insensitively.
+func findHeader(m map[string]spec.Header, name string) (string, bool) {
+	if _, ok := m[name]; ok {
+		return name, true
+	}
+	for k := range m {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+func sortedKeys(m map[string]spec.Response) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// extend returns a copy of the tokens with more tokens appended.
+func extend(tokens []string, more ...string) []string {
+	result := make([]string, 0, len(tokens)+len(more))
+	result = append(result, tokens...)
+	return append(result, more...)
+}
+
+// pointer returns JSON Pointer composed of the tokens.
+func pointer(tokens []string) string {
+	var p string
+	for _, token := range tokens {
+		p += "/" + jsonpointer.Escape(token)
+	}
+	return p
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsValue(vs []interface{}, v interface{}) bool {
+	for _, x := range vs {
+		if fmt.Sprint(x) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hypnoglow/oas2"
+)
+
+func TestCompare(t *testing.T) {
+	from := loadDocFile(t, "testdata/old.yml")
+	to := loadDocFile(t, "testdata/new.yml")
+
+	r := Compare(from, to)
+
+	expected := []Change{
+		{false, "GET /owners/{ownerId}", "/paths/~1owners~1{ownerId}/get/parameters/0", `path parameter "id" renamed to "ownerId"`},
+		{true, "GET /owners/{ownerId}", "/paths/~1owners~1{ownerId}/get/parameters/0", `path parameter "id" type changed from integer to string`},
+		{true, "GET /owners/{ownerId}", "/paths/~1owners~1{ownerId}/get/responses/200/headers/X-Request-Id", `response 200 header "X-Request-Id" removed`},
+		{false, "GET /owners/{ownerId}", "/paths/~1owners~1{ownerId}/get/responses/200/headers/X-Trace-Id", `response 200 header "X-Trace-Id" added`},
+		{true, "GET /pets", "/paths/~1pets/get/parameters/1", `query parameter "status" enum value sold removed`},
+		{true, "GET /pets", "/paths/~1pets/get/parameters/2", `required query parameter "owner" added`},
+		{false, "GET /pets", "/paths/~1pets/get/parameters/3", `optional query parameter "sort" added`},
+		{true, "GET /pets", "/paths/~1pets/get/responses/200/schema/items/properties", `response 200 body items property "tag" removed`},
+		{true, "GET /pets", "/paths/~1pets/get/responses/200/schema/items/properties/age", `response 200 body items property "age" type changed from integer to string`},
+		{false, "GET /pets", "/paths/~1pets/get/responses/200/schema/items/properties/color", `response 200 body items property "color" added`},
+		{false, "POST /pets", "/paths/~1pets/post/parameters/0/schema/properties", `request body property "tag" removed`},
+		{true, "POST /pets", "/paths/~1pets/post/parameters/0/schema/properties/age", `request body property "age" type changed from integer to string`},
+		{false, "POST /pets", "/paths/~1pets/post/parameters/0/schema/properties/color", `request body property "color" added`},
+		{true, "DELETE /pets/{id}", "/paths/~1pets~1{id}/delete", "operation removed"},
+		{false, "GET /pets/{id}", "/paths/~1pets~1{id}/get", "operation added"},
+		{true, "", "/produces", `media type "application/xml" removed`},
+	}
+	assert.Equal(t, expected, r.Changes)
+	assert.True(t, r.Breaking())
+
+	assert.Empty(t, Compare(from, from).Changes)
+	assert.False(t, Compare(from, from).Breaking())
+}
+
+func TestCompare_direction(t *testing.T) {
+	from := loadDocFile(t, "testdata/old.yml")
+	to := loadDocFile(t, "testdata/old.yml")
+
+	// Body schemas are expanded, so patch them in place.
+	post := to.Spec().Paths.Paths["/pets"].Post
+	post.Parameters[0].Schema.Required = []string{"name", "tag"}
+	get := to.Spec().Paths.Paths["/pets"].Get
+	get.Responses.StatusCodeResponses[200].Schema.Items.Schema.Required = []string{}
+
+	r := Compare(from, to)
+
+	expected := []Change{
+		{true, "GET /pets", "/paths/~1pets/get/responses/200/schema/items/properties/name", `response 200 body items property "name" became optional`},
+		{true, "POST /pets", "/paths/~1pets/post/parameters/0/schema/properties/tag", `request body property "tag" became required`},
+	}
+	assert.Equal(t, expected, r.Changes)
+}
+
+func loadDocFile(t *testing.T, fpath string) *oas.Document {
+	doc, err := oas.LoadFile(fpath)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return doc
+}
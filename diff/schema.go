@@ -0,0 +1,158 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// maxSchemaDepth limits schema comparison depth, as expanded specs may still
+// contain circular references.
+const maxSchemaDepth = 32
+
+func (c *comparer) compareSchema(from, to *spec.Schema, dir direction, tokens []string, what string, depth int) {
+	if from == nil || to == nil || depth > maxSchemaDepth {
+		return
+	}
+
+	// Circular references are left unexpanded.
+	if from.Ref.String() != "" || to.Ref.String() != "" {
+		if from.Ref.String() != to.Ref.String() {
+			c.report(true, tokens, "%s schema changed from %q to %q", what, from.Ref.String(), to.Ref.String())
+		}
+		return
+	}
+
+	c.compareSchemaType(from, to, dir, tokens, what)
+	c.compareEnum(from.Enum, to.Enum, dir, tokens, what)
+
+	c.compareProperties(from, to, dir, tokens, what, depth)
+
+	if from.Items != nil && to.Items != nil {
+		c.compareSchema(from.Items.Schema, to.Items.Schema, dir, extend(tokens, "items"), what+" items", depth+1)
+	}
+}
+
+func (c *comparer) compareSchemaType(from, to *spec.Schema, dir direction, tokens []string, what string) {
+	oldType, newType := []string(from.Type), []string(to.Type)
+
+	if !sameStrings(oldType, newType) {
+		// Widening the type is safe for requests, narrowing it is safe
+		// for responses.
+		breaking := !subset(oldType, newType)
+		if dir == response {
+			breaking = !subset(newType, oldType)
+		}
+		c.report(breaking, tokens, "%s type changed from %s to %s", what, typeString(oldType), typeString(newType))
+	}
+
+	if from.Format != to.Format {
+		c.report(true, tokens, "%s format changed from %q to %q", what, from.Format, to.Format)
+	}
+}
+
+func (c *comparer) compareProperties(from, to *spec.Schema, dir direction, tokens []string, what string, depth int) {
+	oldProps, oldRequired := properties(from)
+	newProps, newRequired := properties(to)
+
+	closed := to.AdditionalProperties != nil && !to.AdditionalProperties.Allows
+
+	for _, name := range sortedNames(oldProps) {
+		propTokens := extend(tokens, "properties", name)
+		propWhat := fmt.Sprintf("%s property %q", what, name)
+
+		np, ok := newProps[name]
+		if !ok {
+			// Clients may send the property, or expect it in responses.
+			c.report(dir == response || closed, extend(tokens, "properties"), "%s removed", propWhat)
+			continue
+		}
+
+		switch {
+		case !oldRequired[name] && newRequired[name]:
+			c.report(dir == request, propTokens, "%s became required", propWhat)
+		case oldRequired[name] && !newRequired[name]:
+			c.report(dir == response, propTokens, "%s became optional", propWhat)
+		}
+
+		op := oldProps[name]
+		c.compareSchema(&op, &np, dir, propTokens, propWhat, depth+1)
+	}
+
+	for _, name := range sortedNames(newProps) {
+		if _, ok := oldProps[name]; ok {
+			continue
+		}
+		propTokens := extend(tokens, "properties", name)
+		if newRequired[name] {
+			c.report(dir == request, propTokens, "%s required property %q added", what, name)
+		} else {
+			c.report(false, propTokens, "%s property %q added", what, name)
+		}
+	}
+}
+
+// properties returns properties of the schema and its allOf schemas, and
+// a set of the required properties.
+func properties(sch *spec.Schema) (map[string]spec.Schema, map[string]bool) {
+	props := make(map[string]spec.Schema)
+	required := make(map[string]bool)
+
+	var collect func(s *spec.Schema, depth int)
+	collect = func(s *spec.Schema, depth int) {
+		if depth > maxSchemaDepth {
+			return
+		}
+		for name, p := range s.Properties {
+			props[name] = p
+		}
+		for _, name := range s.Required {
+			required[name] = true
+		}
+		for i := range s.AllOf {
+			collect(&s.AllOf[i], depth+1)
+		}
+	}
+	collect(sch, 0)
+
+	return props, required
+}
+
+func sortedNames(m map[string]spec.Schema) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// subset checks if the types of a are allowed by b. Empty list of types
+// allows any type.
+func subset(a, b []string) bool {
+	if len(b) == 0 {
+		return true
+	}
+	if len(a) == 0 {
+		return false
+	}
+	for _, t := range a {
+		if !containsString(b, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameStrings(a, b []string) bool {
+	return subset(a, b) && subset(b, a) && len(a) == len(b)
+}
+
+func typeString(types []string) string {
+	if len(types) == 0 {
+		return "any"
+	}
+	return strings.Join(types, "|")
+}
@@ -0,0 +1,242 @@
+package oas
+
+import (
+	"bytes"
+	"compress/gzip"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hypnoglow/oas2/internal/swaggerui"
+)
+
+// DocsOptions represent options for the documentation handler.
+type DocsOptions struct {
+	mountPath string
+	title     string
+	specURL   string
+}
+
+// DocsOption is option to use when creating the documentation handler.
+type DocsOption func(*DocsOptions)
+
+// DocsMountPath returns option that sets the path the documentation handler
+// is mounted on. Default is "/docs/".
+func DocsMountPath(p string) DocsOption {
+	return func(o *DocsOptions) {
+		o.mountPath = p
+	}
+}
+
+// DocsTitle returns option that sets the title of the documentation page.
+// Default is the title of the spec.
+func DocsTitle(title string) DocsOption {
+	return func(o *DocsOptions) {
+		o.title = title
+	}
+}
+
+// DocsSpecURL returns option that sets URL of the spec to document, e.g.
+// the URL of the handler returned by NewDynamicSpecHandler. By default,
+// the documentation handler serves the spec itself at "openapi.json" under
+// the mount path.
+func DocsSpecURL(url string) DocsOption {
+	return func(o *DocsOptions) {
+		o.specURL = url
+	}
+}
+
+// NewDocsHandler returns HTTP handler that serves interactive documentation
+// for the spec using Swagger UI.
+//
+// Swagger UI is embedded, so the handler makes no network requests and works
+// in environments without internet access. The handler must be mounted on
+// the path set with DocsMountPath option, and serve all requests with that
+// path prefix, e.g. with gorilla/mux:
+//
+//  router.PathPrefix("/docs/").Handler(oas.NewDocsHandler(doc))
+func NewDocsHandler(doc *Document, opts ...DocsOption) http.Handler {
+	options := DocsOptions{
+		mountPath: "/docs/",
+	}
+	if doc.Spec().Info != nil {
+		options.title = doc.Spec().Info.Title
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if !strings.HasSuffix(options.mountPath, "/") {
+		options.mountPath += "/"
+	}
+
+	h := &docsHandler{
+		mountPath: options.mountPath,
+		modTime:   time.Now().Truncate(time.Second),
+	}
+
+	if options.specURL == "" {
+		options.specURL = docsSpecFile
+		h.spec = NewStaticSpecHandler(doc)
+	}
+
+	buf := &bytes.Buffer{}
+	data := map[string]string{
+		"title":   options.title,
+		"specURL": options.specURL,
+	}
+	if err := docsIndexTemplate.Execute(buf, data); err != nil {
+		// The template is static and data are strings, so this should
+		// never happen.
+		panic(err)
+	}
+	h.index = buf.Bytes()
+
+	return h
+}
+
+// docsSpecFile is the name of the spec served by the documentation handler.
+const docsSpecFile = "openapi.json"
+
+type docsHandler struct {
+	mountPath string
+	modTime   time.Time
+
+	// index is the rendered documentation page.
+	index []byte
+
+	// spec is the handler for the spec. It is nil if the spec is served
+	// elsewhere.
+	spec http.Handler
+}
+
+func (h *docsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path+"/" == h.mountPath {
+		http.Redirect(w, req, h.mountPath, http.StatusMovedPermanently)
+		return
+	}
+
+	if !strings.HasPrefix(req.URL.Path, h.mountPath) {
+		http.NotFound(w, req)
+		return
+	}
+
+	name := strings.TrimPrefix(req.URL.Path, h.mountPath)
+	switch name {
+	case "", "index.html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		http.ServeContent(w, req, "", h.modTime, bytes.NewReader(h.index))
+	case docsSpecFile:
+		if h.spec == nil {
+			http.NotFound(w, req)
+			return
+		}
+		h.spec.ServeHTTP(w, req)
+	default:
+		h.serveAsset(w, req, name)
+	}
+}
+
+// serveAsset serves Swagger UI distribution file.
+func (h *docsHandler) serveAsset(w http.ResponseWriter, req *http.Request, name string) {
+	gz, ok := swaggerui.Asset(name)
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+	w.Header().Set("Content-Type", docsContentType(name))
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+
+	body := gz
+	if acceptsGzip(req) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("ETag", `"`+swaggerui.Version+"-"+name+`-gzip"`)
+	} else {
+		b, err := gunzipAsset(name, gz)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body = b
+		w.Header().Set("ETag", `"`+swaggerui.Version+"-"+name+`"`)
+	}
+
+	http.ServeContent(w, req, "", h.modTime, bytes.NewReader(body))
+}
+
+func docsContentType(name string) string {
+	switch path.Ext(name) {
+	case ".js":
+		return "application/javascript"
+	case ".css":
+		return "text/css; charset=utf-8"
+	case ".png":
+		return "image/png"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+var (
+	gunzippedMu     sync.Mutex
+	gunzippedAssets = make(map[string][]byte)
+)
+
+// gunzipAsset returns decompressed asset. Decompressed assets are kept
+// for clients that do not accept gzip.
+func gunzipAsset(name string, gz []byte) ([]byte, error) {
+	gunzippedMu.Lock()
+	defer gunzippedMu.Unlock()
+
+	if b, ok := gunzippedAssets[name]; ok {
+		return b, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(gz))
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	gunzippedAssets[name] = b
+	return b, nil
+}
+
+// docsIndexTemplate is the documentation page. Assets are referenced with
+// relative URLs, so the page works behind any prefix. The validator badge
+// is disabled, because it is fetched from swagger.io.
+var docsIndexTemplate = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <title>{{ .title }}</title>
+  <link rel="stylesheet" type="text/css" href="swagger-ui.css">
+  <link rel="icon" type="image/png" href="favicon-32x32.png" sizes="32x32">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="swagger-ui-bundle.js" charset="UTF-8"></script>
+  <script>
+    window.onload = function() {
+      window.ui = SwaggerUIBundle({
+        url: {{ .specURL }},
+        dom_id: "#swagger-ui",
+        deepLinking: true,
+        validatorUrl: null,
+        presets: [SwaggerUIBundle.presets.apis],
+        layout: "BaseLayout"
+      });
+    };
+  </script>
+</body>
+</html>
+`))
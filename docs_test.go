@@ -0,0 +1,103 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocsHandler(t *testing.T) {
+	testCases := map[string]struct {
+		path                string
+		acceptEncoding      string
+		expectedStatus      int
+		expectedContentType string
+		expectedEncoding    string
+		expectedBodyPart    string
+	}{
+		"index": {
+			path:                "/api/docs/",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBodyPart:    `url: "openapi.json"`,
+		},
+		"mount path without trailing slash": {
+			path:           "/api/docs",
+			expectedStatus: http.StatusMovedPermanently,
+		},
+		"spec": {
+			path:                "/api/docs/openapi.json",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBodyPart:    `"swagger":"2.0"`,
+		},
+		"asset": {
+			path:                "/api/docs/swagger-ui-bundle.js",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/javascript",
+			expectedBodyPart:    "SwaggerUIBundle",
+		},
+		"gzipped asset": {
+			path:                "/api/docs/swagger-ui.css",
+			acceptEncoding:      "gzip",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/css; charset=utf-8",
+			expectedEncoding:    "gzip",
+		},
+		"unknown asset": {
+			path:           "/api/docs/unknown.js",
+			expectedStatus: http.StatusNotFound,
+		},
+		"outside of mount path": {
+			path:           "/api/pets",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	h := NewDocsHandler(doc, DocsMountPath("/api/docs"))
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tc.expectedContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedEncoding, w.Header().Get("Content-Encoding"))
+			assert.Contains(t, w.Body.String(), tc.expectedBodyPart)
+		})
+	}
+}
+
+func TestDocsHandler_options(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	h := NewDocsHandler(doc, DocsTitle("Pets & Co"), DocsSpecURL("/openapi/v1"))
+
+	req := httptest.NewRequest(http.MethodGet, "/docs/", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<title>Pets &amp; Co</title>")
+	// Older Go versions escape slashes in JS strings.
+	assert.Contains(t, strings.Replace(w.Body.String(), `\/`, "/", -1), `url: "/openapi/v1"`)
+
+	// The page must not load anything from the network.
+	assert.False(t, strings.Contains(w.Body.String(), "http://") || strings.Contains(w.Body.String(), "https://"))
+
+	// The spec is served elsewhere.
+	req = httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
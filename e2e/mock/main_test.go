@@ -0,0 +1,61 @@
+// +build e2e
+
+package mock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hypnoglow/oas2"
+	_ "github.com/hypnoglow/oas2/adapter/chi/init"
+	_ "github.com/hypnoglow/oas2/adapter/gorilla/init"
+	"github.com/hypnoglow/oas2/e2e/testdata"
+)
+
+// TestMockHandler tests that mock handler serves mock responses using router
+// adapters.
+func TestMockHandler(t *testing.T) {
+	routers := map[string]http.Handler{
+		"chi":     chi.NewRouter(),
+		"gorilla": mux.NewRouter(),
+	}
+
+	for adapter, router := range routers {
+		t.Run(adapter, func(t *testing.T) {
+			doc := testdata.GreeterSpec(t)
+
+			srv := httptest.NewServer(oas.NewMockHandler(doc, oas.MockRouter(adapter, router)))
+			defer srv.Close()
+
+			resp, err := srv.Client().Get(srv.URL + "/api/greeting?name=John")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			b, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+			assert.JSONEq(t, `{"greeting":"string"}`, string(b))
+
+			// Requests are validated.
+			resp, err = srv.Client().Get(srv.URL + "/api/greeting")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
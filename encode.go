@@ -0,0 +1,417 @@
+package oas
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/internal/mediatype"
+	"github.com/hypnoglow/oas2/internal/operations"
+)
+
+// EncodeRequest builds a request of the operation from params, which is a
+// struct, or a pointer to struct, with fields tagged with parameter names,
+// the same way as for DecodeQueryParams. The body parameter is tagged with
+// its name too.
+//
+// Path parameters are filled in the path template. Query, header and
+// formData parameters are encoded according to their collectionFormat. Nil
+// pointers and slices are omitted, and missing required parameters are
+// reported as errors. The body is serialized as JSON, unless the operation
+// consumes no JSON media types, in which case the body field must be []byte,
+// string or io.Reader. Content-Type is chosen from the media types the
+// operation consumes, preferring JSON, and Accept is chosen from the media
+// types it produces, preferring JSON. Otherwise, the first media type in the
+// spec order is chosen.
+//
+// The request URL includes the host and the scheme of the spec, if defined.
+// Otherwise, the URL has only the path, and the host must be set before the
+// request is sent.
+func EncodeRequest(doc *Document, operationID string, params interface{}) (*http.Request, error) {
+	method, path, op, ok := doc.Analyzer.OperationForName(operationID)
+	if !ok {
+		return nil, fmt.Errorf("operation %q is not found", operationID)
+	}
+
+	var fields map[string]reflect.StructField
+	pv := reflect.ValueOf(params)
+	for pv.Kind() == reflect.Ptr && !pv.IsNil() {
+		pv = pv.Elem()
+	}
+	switch {
+	case pv.Kind() == reflect.Struct:
+		fields = fieldMap(pv)
+	case params != nil && !(pv.Kind() == reflect.Ptr && pv.IsNil()):
+		return nil, fmt.Errorf("params is not a struct or a pointer to struct")
+	}
+
+	e := &requestEncoder{
+		query:  make(url.Values),
+		header: make(http.Header),
+		form:   make(url.Values),
+		files:  make(map[string]interface{}),
+	}
+
+	for _, p := range operations.Params(doc.Spec(), path, op) {
+		var v reflect.Value
+		if f, ok := fields[p.Name]; ok && f.PkgPath == "" {
+			// Unexported fields are ignored.
+			v = pv.FieldByIndex(f.Index)
+		}
+		if !v.IsValid() || isNil(v) {
+			if p.Required {
+				return nil, fmt.Errorf("parameter %s is required", p.Name)
+			}
+			continue
+		}
+
+		if err := e.encode(p, v); err != nil {
+			return nil, fmt.Errorf("parameter %s: %s", p.Name, err)
+		}
+	}
+
+	path = strings.TrimSuffix(doc.BasePath(), "/") + path
+	rawPath := path
+	for name, value := range e.path {
+		path = strings.Replace(path, "{"+name+"}", value, -1)
+		rawPath = strings.Replace(rawPath, "{"+name+"}", url.PathEscape(value), -1)
+	}
+	u := &url.URL{
+		Path:     path,
+		RawPath:  rawPath,
+		RawQuery: e.query.Encode(),
+	}
+	if host := doc.Host(); host != "" {
+		u.Host = host
+		u.Scheme = "http"
+		if schemes := doc.Spec().Schemes; len(schemes) > 0 {
+			u.Scheme = schemes[0]
+		}
+	}
+
+	consumes := operations.Consumes(doc.Spec(), op)
+	body, contentType, err := e.body(consumes)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(strings.ToUpper(method), u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range e.header {
+		req.Header[name] = values
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if accept := mediatype.PreferJSON(operations.Produces(doc.Spec(), op)); accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	return req, nil
+}
+
+// requestEncoder collects encoded parameters of the request.
+type requestEncoder struct {
+	path   map[string]string
+	query  url.Values
+	header http.Header
+	form   url.Values
+
+	// files are formData parameters of type file: []byte, string or
+	// io.Reader.
+	files map[string]interface{}
+
+	// bodyValue is the body parameter value, if any.
+	bodyValue interface{}
+}
+
+func (e *requestEncoder) encode(p spec.Parameter, v reflect.Value) error {
+	if p.In == "body" {
+		e.bodyValue = v.Interface()
+		return nil
+	}
+	if p.Type == "file" {
+		if !isRaw(v.Interface()) {
+			return fmt.Errorf("value of type %s cannot be sent as file", v.Type())
+		}
+		e.files[p.Name] = v.Interface()
+		return nil
+	}
+
+	values, err := encodeParam(p, v)
+	if err != nil {
+		return err
+	}
+
+	switch p.In {
+	case "path":
+		if e.path == nil {
+			e.path = make(map[string]string)
+		}
+		e.path[p.Name] = values[0]
+	case "query":
+		e.query[p.Name] = values
+	case "header":
+		e.header[http.CanonicalHeaderKey(p.Name)] = values
+	case "formData":
+		e.form[p.Name] = values
+	}
+	return nil
+}
+
+// body returns the request body and its content type.
+func (e *requestEncoder) body(consumes []string) (io.Reader, string, error) {
+	if len(e.form) > 0 || len(e.files) > 0 {
+		return e.formBody(consumes)
+	}
+	if e.bodyValue == nil {
+		return nil, "", nil
+	}
+
+	mediaType := mediatype.PreferJSON(consumes)
+	if mediaType == "" {
+		mediaType = "application/json"
+	}
+	if mediatype.IsJSON(mediaType) {
+		b, err := json.Marshal(e.bodyValue)
+		if err != nil {
+			return nil, "", fmt.Errorf("cannot encode body: %s", err)
+		}
+		return bytes.NewReader(b), mediaType, nil
+	}
+
+	if !isRaw(e.bodyValue) {
+		return nil, "", fmt.Errorf("body of type %T cannot be sent as %s", e.bodyValue, mediaType)
+	}
+	return rawReader(e.bodyValue), mediaType, nil
+}
+
+// formBody returns the formData parameters encoded as URL-encoded form, or
+// as multipart form, if there are files or the operation consumes only
+// multipart forms.
+func (e *requestEncoder) formBody(consumes []string) (io.Reader, string, error) {
+	multi := len(e.files) > 0
+	if !multi {
+		multi = containsFold(consumes, "multipart/form-data") && !containsFold(consumes, "application/x-www-form-urlencoded")
+	}
+	if !multi {
+		return strings.NewReader(e.form.Encode()), "application/x-www-form-urlencoded", nil
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for _, name := range sortedNames(e.form) {
+		for _, v := range e.form[name] {
+			if err := w.WriteField(name, v); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	for _, name := range sortedFileNames(e.files) {
+		fw, err := w.CreateFormFile(name, name)
+		if err != nil {
+			return nil, "", err
+		}
+		if _, err := io.Copy(fw, rawReader(e.files[name])); err != nil {
+			return nil, "", fmt.Errorf("parameter %s: %s", name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return &buf, w.FormDataContentType(), nil
+}
+
+// encodeParam returns string values of the non-body parameter.
+func encodeParam(p spec.Parameter, v reflect.Value) ([]string, error) {
+	v = indirect(v)
+	if p.Type != "array" || isEncodable(v) {
+		s, err := formatValue(v, p.Format)
+		if err != nil {
+			return nil, err
+		}
+		return []string{s}, nil
+	}
+
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("value of type %s is not a slice", v.Type())
+	}
+
+	var format string
+	if p.Items != nil {
+		format = p.Items.Format
+	}
+	values := make([]string, v.Len())
+	for i := range values {
+		s, err := formatValue(indirect(v.Index(i)), format)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = s
+	}
+
+	switch p.CollectionFormat {
+	case "multi":
+		if p.In == "query" || p.In == "formData" {
+			return values, nil
+		}
+		return []string{strings.Join(values, ",")}, nil
+	case "ssv":
+		return []string{strings.Join(values, " ")}, nil
+	case "tsv":
+		return []string{strings.Join(values, "\t")}, nil
+	case "pipes":
+		return []string{strings.Join(values, "|")}, nil
+	default: // "csv"
+		return []string{strings.Join(values, ",")}, nil
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// formatValue returns string representation of the primitive value.
+func formatValue(v reflect.Value, format string) (string, error) {
+	if !v.IsValid() {
+		return "", fmt.Errorf("nil value")
+	}
+
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if format == "date" {
+			return t.Format("2006-01-02"), nil
+		}
+		return t.Format(time.RFC3339Nano), nil
+	}
+	if tm, ok := textMarshaler(v); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("value of type %s cannot be encoded", v.Type())
+	}
+}
+
+// indirect returns the value pointers point to, or invalid value if any
+// pointer is nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isEncodable returns true if the value encodes itself, e.g. a slice type
+// implementing encoding.TextMarshaler.
+func isEncodable(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	if v.Type() == timeType {
+		return true
+	}
+	_, ok := textMarshaler(v)
+	return ok
+}
+
+func textMarshaler(v reflect.Value) (encoding.TextMarshaler, bool) {
+	if tm, ok := v.Interface().(encoding.TextMarshaler); ok {
+		return tm, true
+	}
+	if v.CanAddr() {
+		tm, ok := v.Addr().Interface().(encoding.TextMarshaler)
+		return tm, ok
+	}
+	return nil, false
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// isRaw returns true if the value can be sent as is.
+func isRaw(v interface{}) bool {
+	switch v.(type) {
+	case []byte, string, io.Reader:
+		return true
+	default:
+		return false
+	}
+}
+
+func rawReader(v interface{}) io.Reader {
+	switch v := v.(type) {
+	case []byte:
+		return bytes.NewReader(v)
+	case string:
+		return strings.NewReader(v)
+	case io.Reader:
+		return v
+	default:
+		return bytes.NewReader(nil)
+	}
+}
+
+// sortedNames returns sorted names of the form values.
+func sortedNames(form url.Values) []string {
+	names := make([]string, 0, len(form))
+	for name := range form {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortedFileNames returns sorted names of the form files.
+func sortedFileNames(files map[string]interface{}) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// containsFold reports whether ss contains s, ignoring case.
+func containsFold(ss []string, s string) bool {
+	for _, v := range ss {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
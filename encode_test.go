@@ -0,0 +1,133 @@
+package oas
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeRequest(t *testing.T) {
+	doc := loadDocFile(t, "testdata/encode.yml")
+
+	type pet struct {
+		Name string `json:"name"`
+	}
+
+	t.Run("body, query, path and header", func(t *testing.T) {
+		since := time.Date(2018, 8, 8, 0, 0, 0, 0, time.UTC)
+		params := struct {
+			ID        int64      `oas:"id"`
+			Tags      []string   `oas:"tags"`
+			Sizes     []int32    `oas:"sizes"`
+			Since     *time.Time `oas:"since"`
+			RequestID string     `oas:"X-Request-ID"`
+			Pet       pet        `oas:"pet"`
+			Ignored   string
+		}{
+			ID:        12,
+			Tags:      []string{"cute", "small"},
+			Sizes:     []int32{1, 2},
+			Since:     &since,
+			RequestID: "abc",
+			Pet:       pet{Name: "Rex"},
+		}
+
+		req, err := EncodeRequest(doc, "updatePet", &params)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, http.MethodPut, req.Method)
+		assert.Equal(t, "https://api.example.com/api/pets/12?since=2018-08-08&sizes=1&sizes=2&tags=cute%2Csmall", req.URL.String())
+		assert.Equal(t, "abc", req.Header.Get("X-Request-ID"))
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, "application/json", req.Header.Get("Accept"))
+
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.Equal(t, `{"name":"Rex"}`, string(body))
+	})
+
+	t.Run("optional parameters omitted", func(t *testing.T) {
+		params := struct {
+			ID        int64    `oas:"id"`
+			Tags      []string `oas:"tags"`
+			RequestID string   `oas:"X-Request-ID"`
+			Pet       *pet     `oas:"pet"`
+		}{
+			ID:        12,
+			RequestID: "abc",
+			Pet:       &pet{Name: "Rex"},
+		}
+
+		req, err := EncodeRequest(doc, "updatePet", params)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "https://api.example.com/api/pets/12", req.URL.String())
+	})
+
+	t.Run("multipart form", func(t *testing.T) {
+		params := struct {
+			ID      string `oas:"id"`
+			Caption string `oas:"caption"`
+			Photo   []byte `oas:"photo"`
+		}{
+			ID:      "a/b",
+			Caption: "Rex",
+			Photo:   []byte("PNG"),
+		}
+
+		req, err := EncodeRequest(doc, "uploadPhoto", params)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "/api/pets/a%2Fb/photo", req.URL.EscapedPath())
+		if !assert.NoError(t, req.ParseMultipartForm(1024)) {
+			return
+		}
+		assert.Equal(t, "Rex", req.FormValue("caption"))
+		f, _, err := req.FormFile("photo")
+		if !assert.NoError(t, err) {
+			return
+		}
+		b, _ := ioutil.ReadAll(f)
+		assert.Equal(t, "PNG", string(b))
+	})
+
+	t.Run("raw body", func(t *testing.T) {
+		params := struct {
+			ID   string `oas:"id"`
+			Note string `oas:"note"`
+		}{ID: "1", Note: "Good boy"}
+
+		req, err := EncodeRequest(doc, "addNote", params)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "text/plain", req.Header.Get("Content-Type"))
+		assert.Equal(t, "text/plain", req.Header.Get("Accept"))
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.Equal(t, "Good boy", string(body))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := EncodeRequest(doc, "unknown", nil)
+		assert.EqualError(t, err, `operation "unknown" is not found`)
+
+		_, err = EncodeRequest(doc, "updatePet", struct {
+			ID int64 `oas:"id"`
+		}{ID: 1})
+		assert.EqualError(t, err, "parameter X-Request-ID is required")
+
+		_, err = EncodeRequest(doc, "uploadPhoto", struct {
+			ID    string `oas:"id"`
+			Photo int    `oas:"photo"`
+		}{ID: "1", Photo: 1})
+		assert.EqualError(t, err, "parameter photo: value of type int cannot be sent as file")
+
+		_, err = EncodeRequest(doc, "updatePet", 1)
+		assert.EqualError(t, err, "params is not a struct or a pointer to struct")
+	})
+}
@@ -1,6 +1,7 @@
 package oas
 
 import (
+	"encoding/json"
 	"strings"
 )
 
@@ -44,3 +45,26 @@ func (me multiError) Message() string {
 func (me multiError) Errors() []error {
 	return me.errs
 }
+
+// MarshalJSON implements json.Marshaler. Errors that do not implement
+// json.Marshaler themselves are represented by their messages.
+func (me multiError) MarshalJSON() ([]byte, error) {
+	errs := make([]interface{}, len(me.errs))
+	for i, err := range me.errs {
+		if _, ok := err.(json.Marshaler); ok {
+			errs[i] = err
+			continue
+		}
+		errs[i] = struct {
+			Message string `json:"message"`
+		}{err.Error()}
+	}
+
+	return json.Marshal(struct {
+		Message string        `json:"message,omitempty"`
+		Errors  []interface{} `json:"errors"`
+	}{
+		Message: me.msg,
+		Errors:  errs,
+	})
+}
@@ -0,0 +1,46 @@
+package oas
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hypnoglow/oas2/validate"
+)
+
+func TestMultiError_MarshalJSON(t *testing.T) {
+	ps := []spec.Parameter{
+		{
+			ParamProps: spec.ParamProps{
+				Name:     "name",
+				In:       "query",
+				Required: true,
+			},
+			SimpleSchema: spec.SimpleSchema{
+				Type: "string",
+			},
+		},
+	}
+	errs := validate.Query(ps, url.Values{})
+	errs = append(errs, errors.New("something went wrong"))
+
+	me := newMultiError("query params do not match the schema", errs...)
+
+	b, err := json.Marshal(me)
+	assert.NoError(t, err)
+	assert.JSONEq(
+		t,
+		`{
+			"message": "query params do not match the schema",
+			"errors": [
+				{"message": "param name is required", "field": "name", "in": "query", "keyword": "required", "expected": true},
+				{"message": "something went wrong"}
+			]
+		}`,
+		string(b),
+	)
+}
@@ -0,0 +1,207 @@
+package oas
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/internal/mediatype"
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// ExampleError reports an example of the spec that does not match its
+// schema.
+type ExampleError struct {
+	// Pointer is JSON Pointer to the offending value in the spec, e.g.
+	// "/definitions/Pet/example/name".
+	Pointer string
+
+	// Err is the validation error.
+	Err error
+}
+
+func (e ExampleError) Error() string {
+	// Errors of primitive examples have no field name.
+	return fmt.Sprintf("%s: %s", e.Pointer, strings.TrimSpace(e.Err.Error()))
+}
+
+// ValidateExamples validates examples of the spec against their schemas,
+// the same way request and response bodies are validated, and returns
+// errors ordered by pointer.
+//
+// Examples are the "example" values of schemas, wherever schemas are
+// defined, and the "examples" of responses for JSON media types. Each schema
+// is validated once where it is defined, not where it is referenced.
+func ValidateExamples(doc *Document) []ExampleError {
+	ev := &exampleValidator{
+		subtypes: validate.NewSubtypes(doc.Spec().Definitions, doc.OrigSpec().Definitions),
+	}
+
+	orig, exp := doc.OrigSpec(), doc.Spec()
+
+	for name, sch := range orig.Definitions {
+		expSch := exp.Definitions[name]
+		ev.schema(&sch, &expSch, []string{"definitions", name})
+	}
+
+	for name, p := range orig.Parameters {
+		expP := exp.Parameters[name]
+		ev.parameter(&p, &expP, []string{"parameters", name})
+	}
+
+	for name, resp := range orig.Responses {
+		expResp := exp.Responses[name]
+		ev.response(&resp, &expResp, []string{"responses", name})
+	}
+
+	if orig.Paths != nil && exp.Paths != nil {
+		for path, item := range orig.Paths.Paths {
+			expItem := exp.Paths.Paths[path]
+			ev.pathItem(&item, &expItem, []string{"paths", path})
+		}
+	}
+
+	sort.SliceStable(ev.errs, func(i, j int) bool {
+		return ev.errs[i].Pointer < ev.errs[j].Pointer
+	})
+	return ev.errs
+}
+
+// exampleValidator walks the original spec and validates examples against
+// the same schemas of the expanded spec. References of the original spec are
+// not followed, as their targets are validated where they are defined.
+type exampleValidator struct {
+	subtypes *validate.Subtypes
+	errs     []ExampleError
+}
+
+func (ev *exampleValidator) validate(sch *spec.Schema, example interface{}, tokens []string) {
+	v := validate.NewSchemaValidator(sch, validate.WithSubtypes(ev.subtypes))
+	for _, err := range v.Validate(example) {
+		pointer := jsonPointer(tokens)
+		if de, ok := err.(validate.DetailedValidationError); ok {
+			pointer += de.Pointer()
+		}
+		ev.errs = append(ev.errs, ExampleError{Pointer: pointer, Err: err})
+	}
+}
+
+func (ev *exampleValidator) pathItem(item, exp *spec.PathItem, tokens []string) {
+	for i := range item.Parameters {
+		if i < len(exp.Parameters) {
+			ev.parameter(&item.Parameters[i], &exp.Parameters[i], append(tokens, "parameters", strconv.Itoa(i)))
+		}
+	}
+
+	ops := map[string][2]*spec.Operation{
+		"get":     {item.Get, exp.Get},
+		"put":     {item.Put, exp.Put},
+		"post":    {item.Post, exp.Post},
+		"delete":  {item.Delete, exp.Delete},
+		"options": {item.Options, exp.Options},
+		"head":    {item.Head, exp.Head},
+		"patch":   {item.Patch, exp.Patch},
+	}
+	for method, op := range ops {
+		if op[0] != nil && op[1] != nil {
+			ev.operation(op[0], op[1], append(tokens, method))
+		}
+	}
+}
+
+func (ev *exampleValidator) operation(op, exp *spec.Operation, tokens []string) {
+	for i := range op.Parameters {
+		if i < len(exp.Parameters) {
+			ev.parameter(&op.Parameters[i], &exp.Parameters[i], append(tokens, "parameters", strconv.Itoa(i)))
+		}
+	}
+
+	if op.Responses == nil || exp.Responses == nil {
+		return
+	}
+	if op.Responses.Default != nil && exp.Responses.Default != nil {
+		ev.response(op.Responses.Default, exp.Responses.Default, append(tokens, "responses", "default"))
+	}
+	for code, resp := range op.Responses.StatusCodeResponses {
+		if expResp, ok := exp.Responses.StatusCodeResponses[code]; ok {
+			ev.response(&resp, &expResp, append(tokens, "responses", strconv.Itoa(code)))
+		}
+	}
+}
+
+func (ev *exampleValidator) parameter(p, exp *spec.Parameter, tokens []string) {
+	if p.Ref.String() != "" || p.Schema == nil || exp.Schema == nil {
+		return
+	}
+	ev.schema(p.Schema, exp.Schema, append(tokens, "schema"))
+}
+
+func (ev *exampleValidator) response(resp, exp *spec.Response, tokens []string) {
+	if resp.Ref.String() != "" || exp.Schema == nil {
+		return
+	}
+
+	// Response examples are validated against the expanded schema, even if
+	// the schema is a reference.
+	for mediaType, example := range resp.Examples {
+		if mediatype.IsJSON(mediaType) {
+			ev.validate(exp.Schema, example, append(tokens, "examples", mediaType))
+		}
+	}
+
+	if resp.Schema != nil {
+		ev.schema(resp.Schema, exp.Schema, append(tokens, "schema"))
+	}
+}
+
+func (ev *exampleValidator) schema(sch, exp *spec.Schema, tokens []string) {
+	if sch.Ref.String() != "" {
+		return
+	}
+
+	if sch.Example != nil {
+		ev.validate(exp, sch.Example, append(tokens, "example"))
+	}
+
+	for name, prop := range sch.Properties {
+		if expProp, ok := exp.Properties[name]; ok {
+			ev.schema(&prop, &expProp, append(tokens, "properties", name))
+		}
+	}
+
+	for i := range sch.AllOf {
+		if i < len(exp.AllOf) {
+			ev.schema(&sch.AllOf[i], &exp.AllOf[i], append(tokens, "allOf", strconv.Itoa(i)))
+		}
+	}
+
+	if sch.Items != nil && exp.Items != nil {
+		if sch.Items.Schema != nil && exp.Items.Schema != nil {
+			ev.schema(sch.Items.Schema, exp.Items.Schema, append(tokens, "items"))
+		}
+		for i := range sch.Items.Schemas {
+			if i < len(exp.Items.Schemas) {
+				ev.schema(&sch.Items.Schemas[i], &exp.Items.Schemas[i], append(tokens, "items", strconv.Itoa(i)))
+			}
+		}
+	}
+
+	if sch.AdditionalProperties != nil && sch.AdditionalProperties.Schema != nil &&
+		exp.AdditionalProperties != nil && exp.AdditionalProperties.Schema != nil {
+		ev.schema(sch.AdditionalProperties.Schema, exp.AdditionalProperties.Schema, append(tokens, "additionalProperties"))
+	}
+}
+
+// jsonPointer returns JSON Pointer of the tokens.
+func jsonPointer(tokens []string) string {
+	var p string
+	for _, t := range tokens {
+		t = strings.Replace(t, "~", "~0", -1)
+		t = strings.Replace(t, "/", "~1", -1)
+		p += "/" + t
+	}
+	return p
+}
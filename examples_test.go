@@ -0,0 +1,28 @@
+package oas
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateExamples(t *testing.T) {
+	doc, err := LoadFile("testdata/examples.yml")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	errs := ValidateExamples(doc)
+
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	assert.Equal(t, []string{
+		"/definitions/Pet/example/name: name in body is required",
+		`/definitions/Pet/properties/birthday/example: in body must be of type date: "yesterday"`,
+		"/definitions/Pets/example/1/id: 1.id in body must be of type integer: \"string\"",
+		"/paths/~1pets/post/responses/default/examples/application~1json/message: message in body must be of type string: \"array\"",
+		"/paths/~1pets/post/responses/default/schema/properties/message/example: in body must be of type string: \"number\"",
+	}, messages)
+}
@@ -0,0 +1,260 @@
+package oas
+
+import (
+	"net"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// forwarded describes the original request made by the client, as reported
+// by proxies.
+type forwarded struct {
+	host   string
+	scheme string
+
+	// prefix is the path prefix the service is mounted under by the proxy.
+	prefix string
+}
+
+// forwardedRequest returns the original request details. Forwarding headers
+// are considered only if the request comes from a trusted proxy. The standard
+// Forwarded header (RFC 7239) takes precedence over X-Forwarded-* headers.
+//
+// Every proxy appends its own element to these headers, so the headers are
+// read from the right, i.e. from the proxy the request comes from, to the
+// left, as long as elements are added by trusted proxies. Elements added by
+// clients or untrusted proxies, which may be spoofed, are never used. If no
+// proxies are trusted, forwarding headers are ignored.
+func forwardedRequest(req *http.Request, trusted []*net.IPNet) forwarded {
+	f := forwarded{
+		host:   req.Host,
+		scheme: "http",
+	}
+	if req.TLS != nil {
+		f.scheme = "https"
+	}
+
+	if !trustedAddr(req.RemoteAddr, trusted) {
+		return f
+	}
+
+	forwardedFor := headerList(req, "X-Forwarded-For")
+
+	if host := trustedListValue(headerList(req, "X-Forwarded-Host"), forwardedFor, trusted); validHost(host) {
+		f.host = host
+	}
+
+	if scheme, ok := forwardedScheme(trustedListValue(headerList(req, "X-Forwarded-Proto"), forwardedFor, trusted)); ok {
+		f.scheme = scheme
+	} else if scheme, ok := forwardedScheme(trustedListValue(headerList(req, "X-Scheme"), forwardedFor, trusted)); ok {
+		f.scheme = scheme
+	}
+
+	if fwd := req.Header[http.CanonicalHeaderKey("Forwarded")]; len(fwd) > 0 {
+		elems := parseForwarded(strings.Join(fwd, ","))
+		addrs := make([]string, len(elems))
+		for i, params := range elems {
+			addrs[i] = params["for"]
+		}
+
+		params := elems[firstTrusted(len(elems), addrs, trusted)]
+		if host := params["host"]; validHost(host) {
+			f.host = host
+		}
+		if scheme, ok := forwardedScheme(params["proto"]); ok {
+			f.scheme = scheme
+		}
+	}
+
+	prefix := trustedListValue(headerList(req, "X-Forwarded-Prefix"), forwardedFor, trusted)
+	if prefix = strings.Trim(prefix, "/"); prefix != "" && validPrefix(prefix) {
+		f.prefix = path.Clean("/" + prefix)
+	}
+
+	return f
+}
+
+// maxForwardedLength is the maximum length of forwarded host and prefix.
+const maxForwardedLength = 255
+
+// validHost checks if the forwarded host is a host name or an IP address
+// with optional port. Other values are ignored, so they never get into
+// the published spec.
+func validHost(host string) bool {
+	if host == "" || len(host) > maxForwardedLength {
+		return false
+	}
+	for _, c := range host {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '.' || c == '-' || c == ':' || c == '[' || c == ']':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// validPrefix checks if the forwarded prefix consists of path segments of
+// unreserved characters only.
+func validPrefix(prefix string) bool {
+	if len(prefix) > maxForwardedLength {
+		return false
+	}
+	for _, c := range prefix {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '/' || c == '.' || c == '-' || c == '_' || c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// forwardedScheme returns the scheme in lower case and true if it is one of
+// the schemes allowed by OpenAPI.
+func forwardedScheme(scheme string) (string, bool) {
+	scheme = strings.ToLower(scheme)
+	switch scheme {
+	case "http", "https", "ws", "wss":
+		return scheme, true
+	}
+	return "", false
+}
+
+// trustedAddr checks if the address, optionally with port, belongs to
+// a trusted proxy.
+func trustedAddr(addr string, trusted []*net.IPNet) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// headerList returns elements of a comma-separated header from all header
+// lines.
+func headerList(req *http.Request, name string) []string {
+	var list []string
+	for _, line := range req.Header[http.CanonicalHeaderKey(name)] {
+		for _, v := range strings.Split(line, ",") {
+			list = append(list, strings.TrimSpace(v))
+		}
+	}
+	return list
+}
+
+// trustedListValue returns the leftmost element of the list added by
+// a trusted proxy. Proxy addresses are taken from X-Forwarded-For header.
+func trustedListValue(list, forwardedFor []string, trusted []*net.IPNet) string {
+	if len(list) == 0 {
+		return ""
+	}
+	return list[firstTrusted(len(list), forwardedFor, trusted)]
+}
+
+// firstTrusted returns the index of the leftmost of n list elements that is
+// added by a trusted proxy.
+//
+// The rightmost element is added by the proxy the request comes from, which
+// is trusted. The element to the left of element i is added by the proxy at
+// addrs[i], i.e. the address the request has been received from by the proxy
+// that added element i. If addresses do not match elements, only the rightmost
+// element is trusted.
+func firstTrusted(n int, addrs []string, trusted []*net.IPNet) int {
+	i := n - 1
+	if len(addrs) != n {
+		return i
+	}
+	for i > 0 && trustedAddr(addrs[i], trusted) {
+		i--
+	}
+	return i
+}
+
+// parseForwarded parses elements of the Forwarded header, in order they are
+// added by proxies, i.e. the first element is added by the proxy closest to
+// the client. Parameter names are case-insensitive and are returned in lower
+// case.
+//
+// See https://tools.ietf.org/html/rfc7239#section-4
+func parseForwarded(v string) []map[string]string {
+	var elems []map[string]string
+	params := make(map[string]string)
+
+	for {
+		v = strings.TrimLeft(v, " \t")
+
+		i := strings.IndexAny(v, "=;,")
+		if i < 0 {
+			return append(elems, params)
+		}
+		switch v[i] {
+		case ',':
+			elems = append(elems, params)
+			params = make(map[string]string)
+			v = v[i+1:]
+			continue
+		case ';':
+			// Malformed pair without value.
+			v = v[i+1:]
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(v[:i]))
+		v = v[i+1:]
+
+		var value string
+		if strings.HasPrefix(v, `"`) {
+			value, v = parseQuotedString(v)
+		} else {
+			j := strings.IndexAny(v, ";,")
+			if j < 0 {
+				j = len(v)
+			}
+			value, v = strings.TrimSpace(v[:j]), v[j:]
+		}
+
+		if _, ok := params[key]; !ok {
+			params[key] = value
+		}
+
+		v = strings.TrimLeft(v, " \t")
+		if strings.HasPrefix(v, ";") {
+			v = v[1:]
+		}
+	}
+}
+
+// parseQuotedString parses quoted string at the beginning of v and returns
+// its unquoted value and the rest of v.
+func parseQuotedString(v string) (value, rest string) {
+	b := make([]byte, 0, len(v))
+	for i := 1; i < len(v); i++ {
+		switch c := v[i]; {
+		case c == '\\' && i+1 < len(v):
+			i++
+			b = append(b, v[i])
+		case c == '"':
+			return string(b), v[i+1:]
+		default:
+			b = append(b, c)
+		}
+	}
+
+	// Unterminated quoted string.
+	return string(b), ""
+}
@@ -0,0 +1,374 @@
+// Package fuzz tests HTTP handlers with requests generated from OpenAPI
+// specification.
+//
+// For every case, a random operation is picked, and a valid request or a
+// deliberately invalid one is generated from the parameter and body schemas.
+// The request is served by the handler, and the following invariants are
+// checked:
+//
+//  - valid requests are never rejected with 400, 406 or 415, the status
+//    codes of the request validators;
+//  - invalid requests are always rejected with 4xx status code;
+//  - responses with a JSON body match the response schema.
+//
+// Every case is generated from a seed, so failures are reproducible with
+// Fuzzer.Check. On Go 1.18 and later, Fuzzer.Fuzz integrates with native
+// fuzzing.
+package fuzz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2"
+	"github.com/hypnoglow/oas2/convert"
+	"github.com/hypnoglow/oas2/internal/mediatype"
+	"github.com/hypnoglow/oas2/internal/operations"
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// attempts is the number of attempts to generate a request that is valid,
+// or invalid, as expected.
+const attempts = 10
+
+// Fuzzer sends requests generated from the spec to the handler and checks
+// invariants.
+type Fuzzer struct {
+	handler  http.Handler
+	basePath string
+	ops      []operations.Operation
+	subtypes *validate.Subtypes
+}
+
+// New returns a new fuzzer of the handler, which serves the API described
+// by the spec, including the base path.
+func New(doc *oas.Document, handler http.Handler) *Fuzzer {
+	// Operations are ordered, so that seeds reproduce the same cases.
+	return &Fuzzer{
+		handler:  handler,
+		basePath: strings.TrimSuffix(doc.BasePath(), "/"),
+		ops:      operations.All(doc.Spec(), doc.Analyzer),
+		subtypes: validate.NewSubtypes(doc.Spec().Definitions, doc.OrigSpec().Definitions),
+	}
+}
+
+// Failure describes a violated invariant.
+type Failure struct {
+	// Seed is the seed of the case, to reproduce it with Fuzzer.Check.
+	Seed int64
+
+	OperationID string
+
+	// Valid is true if the request is valid.
+	Valid bool
+
+	// Mutation describes how the invalid request was made invalid.
+	Mutation string
+
+	Method string
+	URL    string
+	Header http.Header
+	Body   string
+
+	// Status is the status code of the response.
+	Status int
+
+	// Message describes the violated invariant.
+	Message string
+}
+
+func (f Failure) Error() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "seed %d: operation %s: %s\n", f.Seed, f.OperationID, f.Message)
+	if f.Mutation != "" {
+		fmt.Fprintf(&buf, "invalid request: %s\n", f.Mutation)
+	}
+	fmt.Fprintf(&buf, "%s %s", f.Method, f.URL)
+	if f.Body != "" {
+		fmt.Fprintf(&buf, "\nContent-Type: %s\n\n%s", f.Header.Get("Content-Type"), f.Body)
+	}
+	return buf.String()
+}
+
+// Run checks n cases generated from seeds starting from the seed, and
+// returns failures.
+func (f *Fuzzer) Run(seed int64, n int) []Failure {
+	var failures []Failure
+	for i := 0; i < n; i++ {
+		if fail := f.Check(seed + int64(i)); fail != nil {
+			failures = append(failures, *fail)
+		}
+	}
+	return failures
+}
+
+// Check checks the case generated from the seed, and returns failure if
+// any invariant is violated. Cases that cannot be generated, e.g. because
+// parameters have patterns, are skipped.
+func (f *Fuzzer) Check(seed int64) *Failure {
+	if len(f.ops) == 0 {
+		return nil
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	op := f.ops[rng.Intn(len(f.ops))]
+	valid := rng.Intn(2) == 0
+	g := &generator{rng: rng}
+
+	var r *request
+	var mutation string
+	for i := 0; i < attempts && r == nil; i++ {
+		candidate := f.generate(g, op)
+		if len(f.check(op, candidate)) > 0 {
+			continue
+		}
+		if valid {
+			r = candidate
+			break
+		}
+
+		mutation = f.mutate(g, op, candidate)
+		if mutation != "" && len(f.check(op, candidate)) > 0 {
+			r = candidate
+		}
+	}
+	if r == nil {
+		return nil
+	}
+
+	req := r.httpRequest(op.Method, f.basePath+op.Path)
+	rec := httptest.NewRecorder()
+	f.handler.ServeHTTP(rec, req)
+
+	fail := &Failure{
+		Seed:        seed,
+		OperationID: op.ID,
+		Valid:       valid,
+		Mutation:    mutation,
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		Header:      req.Header,
+		Body:        string(r.body),
+		Status:      rec.Code,
+	}
+
+	switch {
+	case valid && (rec.Code == http.StatusBadRequest || rec.Code == http.StatusNotAcceptable || rec.Code == http.StatusUnsupportedMediaType):
+		fail.Message = fmt.Sprintf("valid request is rejected with status %d: %s", rec.Code, strings.TrimSpace(rec.Body.String()))
+		return fail
+	case !valid && (rec.Code < 400 || rec.Code > 499):
+		fail.Message = fmt.Sprintf("invalid request is not rejected: status %d", rec.Code)
+		return fail
+	}
+
+	if msg := f.checkResponse(op, rec); msg != "" {
+		fail.Message = msg
+		return fail
+	}
+	return nil
+}
+
+// request is a generated request.
+type request struct {
+	pathParams  map[string]string
+	query       url.Values
+	header      http.Header
+	body        []byte
+	contentType string
+}
+
+func (r *request) httpRequest(method, path string) *http.Request {
+	rawPath := path
+	for name, value := range r.pathParams {
+		path = strings.Replace(path, "{"+name+"}", value, -1)
+		rawPath = strings.Replace(rawPath, "{"+name+"}", url.PathEscape(value), -1)
+	}
+
+	u := &url.URL{Path: path, RawPath: rawPath, RawQuery: r.query.Encode()}
+	req := httptest.NewRequest(method, u.String(), bytes.NewReader(r.body))
+	if r.body == nil {
+		req.Body = http.NoBody
+		req.ContentLength = 0
+	}
+	for name, values := range r.header {
+		req.Header[name] = values
+	}
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	return req
+}
+
+// generate generates a request for the operation. The request is expected,
+// but not guaranteed to be valid.
+func (f *Fuzzer) generate(g *generator, op operations.Operation) *request {
+	r := &request{
+		pathParams: make(map[string]string),
+		query:      make(url.Values),
+		header:     make(http.Header),
+	}
+
+	for _, p := range op.Params {
+		if p.In == "body" {
+			if !p.Required && g.rng.Intn(4) == 0 {
+				continue
+			}
+			// Value of a valid schema is always marshalable.
+			r.body, _ = json.Marshal(g.value(p.Schema, 0))
+			r.contentType = mediatype.JSON(op.Consumes)
+			continue
+		}
+
+		if !p.Required && g.rng.Intn(2) == 0 {
+			continue
+		}
+		values := paramValues(g, p)
+		switch p.In {
+		case "path":
+			r.pathParams[p.Name] = values[0]
+		case "query":
+			r.query[p.Name] = values
+		case "header":
+			r.header.Set(p.Name, values[0])
+		}
+	}
+
+	return r
+}
+
+// paramValues returns string values of the non-body parameter.
+func paramValues(g *generator, p spec.Parameter) []string {
+	v := g.value(simpleSchema(p.Type, p.Format, p.Items, p.CommonValidations), 0)
+
+	arr, ok := v.([]interface{})
+	if !ok {
+		return []string{fmt.Sprint(v)}
+	}
+
+	values := make([]string, len(arr))
+	for i, item := range arr {
+		values[i] = fmt.Sprint(item)
+	}
+	switch p.CollectionFormat {
+	case "multi":
+		return values
+	case "ssv":
+		return []string{strings.Join(values, " ")}
+	case "tsv":
+		return []string{strings.Join(values, "\t")}
+	case "pipes":
+		return []string{strings.Join(values, "|")}
+	default:
+		return []string{strings.Join(values, ",")}
+	}
+}
+
+// check returns problems of the request, as request validators see them.
+func (f *Fuzzer) check(op operations.Operation, r *request) []string {
+	var problems []string
+
+	for _, p := range op.Params {
+		switch p.In {
+		case "path":
+			if _, err := convert.Primitive(r.pathParams[p.Name], p.Type, p.Format); err != nil {
+				problems = append(problems, err.Error())
+			}
+		case "header":
+			if p.Required && r.header.Get(p.Name) == "" {
+				problems = append(problems, fmt.Sprintf("header %s is required", p.Name))
+			}
+		case "body":
+			problems = append(problems, f.checkBody(op, p, r)...)
+		}
+	}
+
+	// Query validation removes known parameters from the values.
+	q := make(url.Values)
+	for k, v := range r.query {
+		q[k] = v
+	}
+	for _, err := range validate.Query(op.Params, q) {
+		problems = append(problems, err.Error())
+	}
+
+	return problems
+}
+
+func (f *Fuzzer) checkBody(op operations.Operation, p spec.Parameter, r *request) []string {
+	if r.body == nil {
+		if p.Required {
+			return []string{"body is required"}
+		}
+		return nil
+	}
+
+	if !mediatype.Match(r.contentType, op.Consumes) {
+		return []string{fmt.Sprintf("content type %s is not consumed", r.contentType)}
+	}
+	if !mediatype.IsJSON(r.contentType) {
+		return nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(r.body, &data); err != nil {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	v := validate.NewBodyValidator(op.Params, validate.WithSubtypes(f.subtypes))
+	for _, err := range v.Validate(data) {
+		problems = append(problems, err.Error())
+	}
+	return problems
+}
+
+// checkResponse returns message if the response does not match the spec.
+func (f *Fuzzer) checkResponse(op operations.Operation, rec *httptest.ResponseRecorder) string {
+	if op.Responses == nil {
+		return ""
+	}
+	resp, ok := op.Responses.StatusCodeResponses[rec.Code]
+	if !ok {
+		if op.Responses.Default == nil {
+			// Status codes may be not documented, see oas.ResponseBodyValidator.
+			return ""
+		}
+		resp = *op.Responses.Default
+	}
+	if resp.Schema == nil || rec.Body.Len() == 0 || !mediatype.IsJSON(rec.Header().Get("Content-Type")) {
+		return ""
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &data); err != nil {
+		return fmt.Sprintf("response %d body is not valid JSON: %s", rec.Code, err)
+	}
+
+	v := validate.NewResponseValidator(resp.Schema, validate.WithSubtypes(f.subtypes))
+	if errs := v.Validate(data); len(errs) > 0 {
+		msgs := make([]string, len(errs))
+		for i, err := range errs {
+			msgs[i] = err.Error()
+		}
+		return fmt.Sprintf("response %d body does not match the schema: %s", rec.Code, strings.Join(msgs, "; "))
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]spec.Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,23 @@
+//go:build go1.18
+// +build go1.18
+
+package fuzz
+
+import "testing"
+
+// Fuzz runs native fuzzing with the seeds added to the corpus. The fuzzing
+// engine mutates seeds, and each seed is checked with Check.
+//
+//  func FuzzAPI(f *testing.F) {
+//      fuzz.New(doc, handler).Fuzz(f, 1, 2, 3)
+//  }
+func (f *Fuzzer) Fuzz(tf *testing.F, seeds ...int64) {
+	for _, seed := range seeds {
+		tf.Add(seed)
+	}
+	tf.Fuzz(func(t *testing.T, seed int64) {
+		if fail := f.Check(seed); fail != nil {
+			t.Fatal(fail)
+		}
+	})
+}
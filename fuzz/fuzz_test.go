@@ -0,0 +1,87 @@
+package fuzz
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hypnoglow/oas2"
+)
+
+func TestFuzzer(t *testing.T) {
+	doc, err := oas.LoadFile("testdata/petstore.yml")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	t.Run("mock handler", func(t *testing.T) {
+		f := New(doc, oas.NewMockHandler(doc))
+		for _, fail := range f.Run(1, 500) {
+			t.Errorf("Unexpected failure: %s", fail)
+		}
+	})
+
+	t.Run("handler without validation", func(t *testing.T) {
+		h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		failures := New(doc, h).Run(1, 100)
+		if !assert.NotEmpty(t, failures) {
+			return
+		}
+		for _, fail := range failures {
+			assert.False(t, fail.Valid)
+			assert.NotEmpty(t, fail.Mutation)
+			assert.Equal(t, "invalid request is not rejected: status 204", fail.Message)
+		}
+	})
+
+	t.Run("response mismatch", func(t *testing.T) {
+		h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `{"name": 42}`)
+		})
+
+		var found bool
+		for _, fail := range New(doc, h).Run(1, 100) {
+			if fail.OperationID == "getPet" && fail.Valid {
+				found = true
+				assert.True(t, strings.HasPrefix(fail.Message, "response 200 body does not match the schema: "), fail.Message)
+			}
+		}
+		assert.True(t, found, "Expected failure of getPet")
+	})
+
+	t.Run("reproducible", func(t *testing.T) {
+		h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		f := New(doc, h)
+
+		failures := f.Run(1, 100)
+		if !assert.NotEmpty(t, failures) {
+			return
+		}
+		for _, fail := range failures {
+			assert.Equal(t, &fail, f.Check(fail.Seed))
+		}
+	})
+}
+
+func TestRequest_httpRequest(t *testing.T) {
+	r := &request{
+		pathParams: map[string]string{"name": "a b/c%d"},
+		query:      url.Values{"q": {"x y"}},
+	}
+
+	req := r.httpRequest(http.MethodGet, "/api/pets/{name}")
+	assert.Equal(t, "/api/pets/a b/c%d", req.URL.Path)
+	assert.Equal(t, "/api/pets/a%20b%2Fc%25d", req.URL.EscapedPath())
+	assert.Equal(t, "/api/pets/a%20b%2Fc%25d?q=x+y", req.URL.String())
+}
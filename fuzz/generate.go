@@ -0,0 +1,284 @@
+package fuzz
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/go-openapi/spec"
+)
+
+// maxDepth limits nesting of generated objects and arrays. Beyond it, only
+// required properties and minimal arrays are generated.
+const maxDepth = 5
+
+// generator generates random values that match schemas. Values are not
+// guaranteed to match schemas with patterns or unique items, so generated
+// requests are checked before they are sent.
+type generator struct {
+	rng *rand.Rand
+}
+
+func (g *generator) value(sch *spec.Schema, depth int) interface{} {
+	if sch == nil {
+		return g.str(0, 10)
+	}
+	if len(sch.Enum) > 0 {
+		return sch.Enum[g.rng.Intn(len(sch.Enum))]
+	}
+	if len(sch.AllOf) > 0 {
+		return g.allOf(sch, depth)
+	}
+
+	switch schemaType(sch) {
+	case "object":
+		return g.object(sch, depth)
+	case "array":
+		return g.array(sch, depth)
+	case "string":
+		return g.format(sch.Format, sch.MinLength, sch.MaxLength)
+	case "integer":
+		return g.integer(sch.Minimum, sch.ExclusiveMinimum, sch.Maximum, sch.ExclusiveMaximum, sch.MultipleOf)
+	case "number":
+		return g.number(sch.Minimum, sch.ExclusiveMinimum, sch.Maximum, sch.ExclusiveMaximum, sch.MultipleOf)
+	case "boolean":
+		return g.rng.Intn(2) == 0
+	default:
+		return g.str(1, 10)
+	}
+}
+
+func (g *generator) allOf(sch *spec.Schema, depth int) interface{} {
+	merged := make(map[string]interface{})
+	for i := range sch.AllOf {
+		if obj, ok := g.value(&sch.AllOf[i], depth).(map[string]interface{}); ok {
+			for k, v := range obj {
+				merged[k] = v
+			}
+		}
+	}
+	if obj, ok := g.object(sch, depth).(map[string]interface{}); ok {
+		for k, v := range obj {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+func (g *generator) object(sch *spec.Schema, depth int) interface{} {
+	required := make(map[string]bool)
+	for _, name := range sch.Required {
+		required[name] = true
+	}
+
+	obj := make(map[string]interface{})
+	for _, name := range sortedKeys(sch.Properties) {
+		prop := sch.Properties[name]
+		if prop.ReadOnly {
+			// Properties marked as readOnly are rejected in requests.
+			continue
+		}
+		if !required[name] && (depth >= maxDepth || g.rng.Intn(2) == 0) {
+			continue
+		}
+		obj[name] = g.value(&prop, depth+1)
+	}
+	return obj
+}
+
+func (g *generator) array(sch *spec.Schema, depth int) interface{} {
+	var items *spec.Schema
+	if sch.Items != nil {
+		items = sch.Items.Schema
+	}
+
+	n := g.length(sch.MinItems, sch.MaxItems, 3)
+	if depth >= maxDepth && sch.MinItems != nil {
+		n = int(*sch.MinItems)
+	}
+
+	arr := make([]interface{}, n)
+	for i := range arr {
+		arr[i] = g.value(items, depth+1)
+	}
+	return arr
+}
+
+// length returns random length between the limits, or up to spread
+// above the minimum if there is no maximum.
+func (g *generator) length(min, max *int64, spread int) int {
+	lo := 0
+	if min != nil {
+		lo = int(*min)
+	}
+	hi := lo + spread
+	if max != nil && int(*max) < hi {
+		hi = int(*max)
+	}
+	if hi < lo {
+		return lo
+	}
+	return lo + g.rng.Intn(hi-lo+1)
+}
+
+const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func (g *generator) str(min, max int) string {
+	n := min
+	if max > min {
+		n += g.rng.Intn(max - min + 1)
+	}
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = letters[g.rng.Intn(len(letters))]
+	}
+	return string(b)
+}
+
+func (g *generator) format(format string, minLength, maxLength *int64) string {
+	t := time.Date(2000+g.rng.Intn(30), time.Month(1+g.rng.Intn(12)), 1+g.rng.Intn(28),
+		g.rng.Intn(24), g.rng.Intn(60), g.rng.Intn(60), 0, time.UTC)
+
+	switch format {
+	case "date-time":
+		return t.Format(time.RFC3339)
+	case "date":
+		return t.Format("2006-01-02")
+	case "partial-time":
+		return t.Format("15:04:05")
+	case "uuid":
+		b := make([]byte, 16)
+		g.rng.Read(b) // nolint
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	case "email":
+		return g.str(1, 8) + "@example.com"
+	case "hostname":
+		return g.str(1, 8) + ".example.com"
+	case "uri", "url":
+		return "https://example.com/" + g.str(1, 8)
+	case "ipv4":
+		return fmt.Sprintf("%d.%d.%d.%d", g.rng.Intn(256), g.rng.Intn(256), g.rng.Intn(256), g.rng.Intn(256))
+	case "ipv6":
+		return fmt.Sprintf("2001:db8::%x", g.rng.Intn(0xffff))
+	case "byte":
+		return base64.StdEncoding.EncodeToString([]byte(g.str(1, 8)))
+	}
+
+	n := g.length(minLength, maxLength, 10)
+	return g.str(n, n)
+}
+
+// integer returns random integer within the limits. If limits allow no
+// integer, the result does not match them.
+func (g *generator) integer(min *float64, exclMin bool, max *float64, exclMax bool, multipleOf *float64) int64 {
+	lo, hi := int64(-1000), int64(1000)
+	if min != nil {
+		lo = int64(math.Ceil(*min))
+		if exclMin && float64(lo) == *min {
+			lo++
+		}
+		if max == nil {
+			hi = lo + 1000
+		}
+	}
+	if max != nil {
+		hi = int64(math.Floor(*max))
+		if exclMax && float64(hi) == *max {
+			hi--
+		}
+		if min == nil {
+			lo = hi - 1000
+		}
+	}
+	if hi < lo {
+		return lo
+	}
+
+	if multipleOf != nil && *multipleOf >= 1 && *multipleOf == math.Trunc(*multipleOf) {
+		m := int64(*multipleOf)
+		first := int64(math.Ceil(float64(lo)/float64(m))) * m
+		if first > hi {
+			return first
+		}
+		return first + g.rng.Int63n((hi-first)/m+1)*m
+	}
+
+	return lo + g.rng.Int63n(hi-lo+1)
+}
+
+// number returns random number within the limits.
+func (g *generator) number(min *float64, exclMin bool, max *float64, exclMax bool, multipleOf *float64) float64 {
+	if multipleOf != nil && *multipleOf > 0 {
+		m := *multipleOf
+		var loM, hiM *float64
+		if min != nil {
+			v := *min / m
+			loM = &v
+		}
+		if max != nil {
+			v := *max / m
+			hiM = &v
+		}
+		return float64(g.integer(loM, exclMin, hiM, exclMax, nil)) * m
+	}
+
+	lo, hi := -1000.0, 1000.0
+	if min != nil {
+		lo = *min
+		if max == nil {
+			hi = lo + 1000
+		}
+	}
+	if max != nil {
+		hi = *max
+		if min == nil {
+			lo = hi - 1000
+		}
+	}
+
+	v := lo + g.rng.Float64()*(hi-lo)
+	if (exclMin && v == lo) || (exclMax && v == hi) {
+		v = (lo + hi) / 2
+	}
+	return v
+}
+
+// simpleSchema returns schema of a non-body parameter or of its items.
+func simpleSchema(typ, format string, items *spec.Items, cv spec.CommonValidations) *spec.Schema {
+	sch := &spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Type:             spec.StringOrArray{typ},
+			Format:           format,
+			Enum:             cv.Enum,
+			Minimum:          cv.Minimum,
+			ExclusiveMinimum: cv.ExclusiveMinimum,
+			Maximum:          cv.Maximum,
+			ExclusiveMaximum: cv.ExclusiveMaximum,
+			MinLength:        cv.MinLength,
+			MaxLength:        cv.MaxLength,
+			MinItems:         cv.MinItems,
+			MaxItems:         cv.MaxItems,
+			MultipleOf:       cv.MultipleOf,
+		},
+	}
+	if items != nil {
+		sch.Items = &spec.SchemaOrArray{
+			Schema: simpleSchema(items.Type, items.Format, items.Items, items.CommonValidations),
+		}
+	}
+	return sch
+}
+
+func schemaType(sch *spec.Schema) string {
+	for _, typ := range sch.Type {
+		if typ != "null" {
+			return typ
+		}
+	}
+	if len(sch.Properties) > 0 {
+		return "object"
+	}
+	return ""
+}
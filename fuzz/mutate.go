@@ -0,0 +1,211 @@
+package fuzz
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/internal/operations"
+)
+
+// mutation makes a valid request invalid, and returns its description, or
+// empty string if it is not applicable to the operation.
+type mutation func(g *generator, op operations.Operation, r *request) string
+
+// mutations are the ways to make requests invalid. Only query parameters
+// and bodies are mutated, as these are checked by the request validators.
+var mutations = []mutation{
+	mutateUnknownQuery,
+	mutateDropQuery,
+	mutateQueryType,
+	mutateQueryRange,
+	mutateEmptyBody,
+	mutateMalformedBody,
+	mutateDropProperty,
+	mutatePropertyType,
+	mutateContentType,
+}
+
+// mutate makes the request invalid with a random applicable mutation.
+func (f *Fuzzer) mutate(g *generator, op operations.Operation, r *request) string {
+	for _, i := range g.rng.Perm(len(mutations)) {
+		if desc := mutations[i](g, op, r); desc != "" {
+			return desc
+		}
+	}
+	return ""
+}
+
+func mutateUnknownQuery(g *generator, op operations.Operation, r *request) string {
+	name := "fuzz_" + g.str(4, 8)
+	for _, p := range op.Params {
+		if p.In == "query" && p.Name == name {
+			return ""
+		}
+	}
+	r.query.Set(name, g.str(1, 8))
+	return fmt.Sprintf("unknown query parameter %s", name)
+}
+
+func mutateDropQuery(g *generator, op operations.Operation, r *request) string {
+	p, ok := pickParam(g, op.Params, func(p spec.Parameter) bool {
+		return p.In == "query" && p.Required
+	})
+	if !ok {
+		return ""
+	}
+	r.query.Del(p.Name)
+	return fmt.Sprintf("required query parameter %s is missing", p.Name)
+}
+
+func mutateQueryType(g *generator, op operations.Operation, r *request) string {
+	p, ok := pickParam(g, op.Params, func(p spec.Parameter) bool {
+		return p.In == "query" && p.Type != "array" && (p.Type != "string" || len(p.Enum) > 0)
+	})
+	if !ok {
+		return ""
+	}
+	// Strings of letters are neither numbers nor booleans, and are unlikely
+	// enum values.
+	v := "fuzz" + g.str(4, 8)
+	r.query.Set(p.Name, v)
+	return fmt.Sprintf("query parameter %s has invalid value %q", p.Name, v)
+}
+
+func mutateQueryRange(g *generator, op operations.Operation, r *request) string {
+	p, ok := pickParam(g, op.Params, func(p spec.Parameter) bool {
+		return p.In == "query" && (p.Type == "integer" || p.Type == "number") &&
+			(p.Maximum != nil || p.Minimum != nil)
+	})
+	if !ok {
+		return ""
+	}
+
+	var v float64
+	if p.Maximum != nil {
+		v = *p.Maximum + 1
+	} else {
+		v = *p.Minimum - 1
+	}
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	r.query.Set(p.Name, s)
+	return fmt.Sprintf("query parameter %s is out of range: %s", p.Name, s)
+}
+
+func mutateEmptyBody(g *generator, op operations.Operation, r *request) string {
+	p, ok := pickParam(g, op.Params, func(p spec.Parameter) bool {
+		return p.In == "body" && p.Required
+	})
+	if !ok {
+		return ""
+	}
+	r.body = nil
+	r.contentType = ""
+	return fmt.Sprintf("required body %s is missing", p.Name)
+}
+
+func mutateMalformedBody(g *generator, op operations.Operation, r *request) string {
+	if r.body == nil {
+		return ""
+	}
+	r.body = []byte(`{"`)
+	return "body is malformed JSON"
+}
+
+func mutateDropProperty(g *generator, op operations.Operation, r *request) string {
+	obj, sch, ok := bodyObject(op, r)
+	if !ok {
+		return ""
+	}
+
+	var names []string
+	for _, name := range sch.Required {
+		if _, ok := obj[name]; ok && !sch.Properties[name].ReadOnly {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	name := names[g.rng.Intn(len(names))]
+	delete(obj, name)
+	r.body, _ = json.Marshal(obj)
+	return fmt.Sprintf("required body property %s is missing", name)
+}
+
+func mutatePropertyType(g *generator, op operations.Operation, r *request) string {
+	obj, sch, ok := bodyObject(op, r)
+	if !ok {
+		return ""
+	}
+
+	var names []string
+	for _, name := range sortedKeys(sch.Properties) {
+		if _, ok := obj[name]; ok {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	name := names[g.rng.Intn(len(names))]
+	prop := sch.Properties[name]
+	var v interface{} = "fuzz" + g.str(4, 8)
+	if schemaType(&prop) == "string" {
+		v = g.rng.Intn(1000)
+	}
+	obj[name] = v
+	r.body, _ = json.Marshal(obj)
+	return fmt.Sprintf("body property %s has value of wrong type", name)
+}
+
+func mutateContentType(g *generator, op operations.Operation, r *request) string {
+	if r.body == nil || len(op.Consumes) == 0 {
+		return ""
+	}
+	r.contentType = "text/plain"
+	return "content type is not consumed by the operation"
+}
+
+// bodyObject returns the body object of the request, and its schema, if
+// the schema has properties.
+func bodyObject(op operations.Operation, r *request) (map[string]interface{}, *spec.Schema, bool) {
+	if r.body == nil {
+		return nil, nil, false
+	}
+
+	p, ok := pickParam(nil, op.Params, func(p spec.Parameter) bool {
+		return p.In == "body"
+	})
+	if !ok || p.Schema == nil || len(p.Schema.Properties) == 0 {
+		return nil, nil, false
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(r.body, &obj); err != nil {
+		return nil, nil, false
+	}
+	return obj, p.Schema, true
+}
+
+// pickParam returns a random parameter that matches, or the first one if
+// the generator is nil.
+func pickParam(g *generator, params []spec.Parameter, match func(spec.Parameter) bool) (spec.Parameter, bool) {
+	var matched []spec.Parameter
+	for _, p := range params {
+		if match(p) {
+			matched = append(matched, p)
+		}
+	}
+	if len(matched) == 0 {
+		return spec.Parameter{}, false
+	}
+	if g == nil {
+		return matched[0], true
+	}
+	return matched[g.rng.Intn(len(matched))], true
+}
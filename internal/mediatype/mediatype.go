@@ -0,0 +1,103 @@
+// Package mediatype provides helpers shared by the client transport, the mock
+// and the testing packages to match media types against the media types of
+// operations.
+package mediatype
+
+import (
+	"mime"
+	"strings"
+)
+
+// Wildcard is the media range that matches any media type.
+const Wildcard = "*/*"
+
+// Base returns the media type of the Content-Type header value in lower case
+// without parameters, e.g. without charset.
+func Base(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		if i := strings.Index(contentType, ";"); i >= 0 {
+			contentType = contentType[:i]
+		}
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mt
+}
+
+// Match checks if media type matches any allowed media type. Parameters are
+// ignored, and allowed media types may be media ranges, e.g. "*/*" or
+// "image/*". If no media types are allowed, or no media type is given, it is
+// considered a match. The latter is useful for HTTP 204 responses, as RFC 7231
+// does not strictly require Content-Type to be defined:
+// https://tools.ietf.org/html/rfc7231#section-3.1.1.5
+func Match(mediaType string, allowed []string) bool {
+	if len(allowed) == 0 || mediaType == "" {
+		return true
+	}
+
+	mt := Base(mediaType)
+	for _, a := range allowed {
+		if matchRange(mt, Base(a)) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAny checks if any of the media types matches any allowed media type,
+// e.g. the values of Accept header. If no media types are given, it is
+// considered a match.
+func MatchAny(mediaTypes []string, allowed []string) bool {
+	if len(allowed) == 0 || len(mediaTypes) == 0 {
+		return true
+	}
+
+	for _, mediaType := range mediaTypes {
+		if Base(mediaType) == Wildcard || Match(mediaType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsJSON checks if media type is JSON, i.e. application/json or a media type
+// with +json suffix, e.g. application/vnd.api+json.
+func IsJSON(mediaType string) bool {
+	mt := Base(mediaType)
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// JSON returns the first JSON media type, or application/json if there is
+// none, e.g. to send data encoded as JSON.
+func JSON(mediaTypes []string) string {
+	for _, mt := range mediaTypes {
+		if IsJSON(mt) {
+			return mt
+		}
+	}
+	return "application/json"
+}
+
+// PreferJSON returns the first JSON media type, or the first media type.
+func PreferJSON(mediaTypes []string) string {
+	for _, mt := range mediaTypes {
+		if IsJSON(mt) {
+			return mt
+		}
+	}
+	if len(mediaTypes) > 0 {
+		return mediaTypes[0]
+	}
+	return ""
+}
+
+func matchRange(mediaType, mediaRange string) bool {
+	switch {
+	case mediaRange == Wildcard:
+		return true
+	case strings.HasSuffix(mediaRange, "/*"):
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*"))
+	default:
+		return mediaType == mediaRange
+	}
+}
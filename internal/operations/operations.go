@@ -0,0 +1,103 @@
+// Package operations provides operations of the spec with their effective
+// parameters and media types, shared by the mock and the testing packages.
+package operations
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/analysis"
+	"github.com/go-openapi/spec"
+)
+
+// Operation is an operation with effective parameters and media types.
+type Operation struct {
+	*spec.Operation
+
+	// Method is the method of the operation in upper case.
+	Method string
+	// Path is the path template of the operation, without the base path.
+	Path string
+
+	// Params are the parameters of the path item and of the operation in the
+	// spec order. See Params.
+	Params []spec.Parameter
+
+	// Consumes and Produces are media types of the operation, or of the
+	// spec if the operation does not define them, in the spec order.
+	Consumes []string
+	Produces []string
+}
+
+// New returns the operation of the method and the path with effective
+// parameters and media types.
+func New(sw *spec.Swagger, method, path string, op *spec.Operation) Operation {
+	return Operation{
+		Operation: op,
+		Method:    strings.ToUpper(method),
+		Path:      path,
+		Params:    Params(sw, path, op),
+		Consumes:  Consumes(sw, op),
+		Produces:  Produces(sw, op),
+	}
+}
+
+// All returns all operations of the spec ordered by path and method.
+func All(sw *spec.Swagger, an *analysis.Spec) []Operation {
+	var ops []Operation
+	for method, pathOps := range an.Operations() {
+		for path, op := range pathOps {
+			ops = append(ops, New(sw, method, path, op))
+		}
+	}
+
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Path != ops[j].Path {
+			return ops[i].Path < ops[j].Path
+		}
+		return ops[i].Method < ops[j].Method
+	})
+	return ops
+}
+
+// Consumes returns media types the operation consumes in the spec order.
+// Unlike analysis.Spec.ConsumesFor, the order is stable, so the first media
+// type is the preferred one.
+func Consumes(sw *spec.Swagger, op *spec.Operation) []string {
+	if len(op.Consumes) > 0 {
+		return op.Consumes
+	}
+	return sw.Consumes
+}
+
+// Produces returns media types the operation produces in the spec order.
+func Produces(sw *spec.Swagger, op *spec.Operation) []string {
+	if len(op.Produces) > 0 {
+		return op.Produces
+	}
+	return sw.Produces
+}
+
+// Params returns parameters of the path item and of the operation in the
+// spec order: parameters of the path item, unless the operation overrides
+// them, followed by parameters of the operation.
+func Params(sw *spec.Swagger, path string, op *spec.Operation) []spec.Parameter {
+	var params []spec.Parameter
+	if sw.Paths != nil {
+		for _, p := range sw.Paths.Paths[path].Parameters {
+			if !hasParam(op.Parameters, p) {
+				params = append(params, p)
+			}
+		}
+	}
+	return append(params, op.Parameters...)
+}
+
+func hasParam(params []spec.Parameter, param spec.Parameter) bool {
+	for _, p := range params {
+		if p.Name == param.Name && p.In == param.In {
+			return true
+		}
+	}
+	return false
+}
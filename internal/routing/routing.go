@@ -0,0 +1,91 @@
+// Package routing provides helpers shared by operation routers of adapters
+// to answer requests with methods not defined for the path.
+package routing
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Allow returns methods to report in Allow header for the path which has
+// operations of the methods. OPTIONS is always allowed, as it is answered
+// automatically if the spec does not define it, and HEAD is allowed if head
+// is true and there is GET operation.
+func Allow(methods []string, head bool) []string {
+	set := map[string]bool{http.MethodOptions: true}
+	for _, m := range methods {
+		m = strings.ToUpper(m)
+		set[m] = true
+		if head && m == http.MethodGet {
+			set[http.MethodHead] = true
+		}
+	}
+
+	allow := make([]string, 0, len(set))
+	for m := range set {
+		allow = append(allow, m)
+	}
+	sort.Strings(allow)
+	return allow
+}
+
+// MethodNotAllowed returns a handler that responds with 405 Method Not
+// Allowed and Allow header.
+func MethodNotAllowed(allow []string) http.Handler {
+	value := strings.Join(allow, ", ")
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Allow", value)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	})
+}
+
+// Options returns a handler that responds to OPTIONS request with 204 No
+// Content and Allow header.
+func Options(allow []string) http.Handler {
+	value := strings.Join(allow, ", ")
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Allow", value)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// Head is a middleware that serves HEAD requests with GET handler, which is
+// next, discarding the response body.
+func Head(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(headResponseWriter{w}, req)
+	})
+}
+
+type headResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w headResponseWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// SortPaths sorts path templates so that literal segments take precedence
+// over parameters, e.g. "/pets/mine" comes before "/pets/{id}", for routers
+// that match routes in order.
+func SortPaths(paths []string) {
+	sort.SliceStable(paths, func(i, j int) bool {
+		a := strings.Split(strings.Trim(paths[i], "/"), "/")
+		b := strings.Split(strings.Trim(paths[j], "/"), "/")
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		for k := range a {
+			pa, pb := isParam(a[k]), isParam(b[k])
+			if pa != pb {
+				return pb
+			}
+		}
+		return paths[i] < paths[j]
+	})
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
@@ -0,0 +1,92 @@
+// +build ignore
+
+// This program generates assets.go from Swagger UI distribution files.
+// Run it from the package directory:
+//
+//  go run gen.go -dist path/to/swagger-ui/dist -version 4.15.5
+//
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+)
+
+// files are Swagger UI distribution files required to render the docs.
+var files = []string{
+	"swagger-ui-bundle.js",
+	"swagger-ui.css",
+	"favicon-32x32.png",
+}
+
+func main() {
+	dist := flag.String("dist", "dist", "path to Swagger UI distribution directory")
+	version := flag.String("version", "", "Swagger UI version")
+	flag.Parse()
+
+	if *version == "" {
+		log.Fatal("version is required")
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by gen.go; DO NOT EDIT.\n\n")
+	fmt.Fprintf(buf, "package swaggerui\n\n")
+	fmt.Fprintf(buf, "// Version is the version of embedded Swagger UI.\n")
+	fmt.Fprintf(buf, "const Version = %q\n\n", *version)
+	fmt.Fprintf(buf, "// assets are gzip-compressed base64-encoded files by name.\n")
+	fmt.Fprintf(buf, "var assets = map[string]string{\n")
+
+	for _, name := range files {
+		b, err := ioutil.ReadFile(filepath.Join(*dist, name))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Fprintf(buf, "%q: `\n%s`,\n", name, encode(b))
+	}
+
+	fmt.Fprintf(buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile("assets.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// encode compresses b and returns it encoded in base64 split by lines.
+func encode(b []byte) []byte {
+	gz := &bytes.Buffer{}
+	zw, err := gzip.NewWriterLevel(gz, gzip.BestCompression)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := zw.Write(b); err != nil {
+		log.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	enc := base64.StdEncoding.EncodeToString(gz.Bytes())
+
+	out := &bytes.Buffer{}
+	for len(enc) > 76 {
+		out.WriteString(enc[:76])
+		out.WriteByte('\n')
+		enc = enc[76:]
+	}
+	out.WriteString(enc)
+	out.WriteByte('\n')
+	return out.Bytes()
+}
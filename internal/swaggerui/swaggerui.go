@@ -0,0 +1,37 @@
+// Package swaggerui provides embedded Swagger UI distribution files, so the
+// API documentation can be served without any network access.
+//
+// Swagger UI is licensed under the Apache License 2.0, see LICENSE file.
+// To update Swagger UI, run gen.go against the new distribution.
+package swaggerui
+
+//go:generate go run gen.go -dist dist -version 4.15.5
+
+import (
+	"encoding/base64"
+	"sync"
+)
+
+var (
+	decodeOnce sync.Once
+	decoded    map[string][]byte
+)
+
+// Asset returns gzip-compressed content of the Swagger UI distribution file
+// by its name, e.g. "swagger-ui-bundle.js".
+func Asset(name string) ([]byte, bool) {
+	decodeOnce.Do(func() {
+		decoded = make(map[string][]byte, len(assets))
+		for name, enc := range assets {
+			b, err := base64.StdEncoding.DecodeString(enc)
+			if err != nil {
+				// Assets are generated, so this should never happen.
+				panic("swaggerui: invalid asset " + name + ": " + err.Error())
+			}
+			decoded[name] = b
+		}
+	})
+
+	b, ok := decoded[name]
+	return b, ok
+}
@@ -0,0 +1,80 @@
+package lint
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/go-openapi/spec"
+)
+
+// IgnoreExtension is the vendor extension that suppresses issues of the
+// object it is set on, and of all objects within it. Its value is a rule
+// name, a list of rule names, or true to suppress issues of all rules:
+//
+//     /pets:
+//       x-lint-ignore: [path-case]
+const IgnoreExtension = "x-lint-ignore"
+
+// ignores tells whether issues are suppressed with IgnoreExtension.
+type ignores struct {
+	root interface{}
+}
+
+func newIgnores(s *spec.Swagger) (*ignores, error) {
+	// Walk the generic representation of the spec, so that the extension
+	// is found on any object regardless of its type.
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var root interface{}
+	if err := json.Unmarshal(b, &root); err != nil {
+		return nil, err
+	}
+	return &ignores{root: root}, nil
+}
+
+// ignored returns true if issues of the rule at the location are suppressed
+// by the location itself or any of its parents.
+func (ig *ignores) ignored(rule string, tokens []string) bool {
+	node := ig.root
+	for i := 0; ; i++ {
+		if m, ok := node.(map[string]interface{}); ok {
+			if ignoresRule(m[IgnoreExtension], rule) {
+				return true
+			}
+		}
+		if i == len(tokens) {
+			return false
+		}
+
+		switch n := node.(type) {
+		case map[string]interface{}:
+			node = n[tokens[i]]
+		case []interface{}:
+			idx, err := strconv.Atoi(tokens[i])
+			if err != nil || idx < 0 || idx >= len(n) {
+				return false
+			}
+			node = n[idx]
+		default:
+			return false
+		}
+	}
+}
+
+func ignoresRule(v interface{}, rule string) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return v == rule
+	case []interface{}:
+		for _, r := range v {
+			if r == rule {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/convert"
+)
+
+func checkOperationIDMissing(s *spec.Swagger, report ReportFunc) {
+	walkOperations(s, func(path, method string, op *spec.Operation) {
+		if op.ID == "" {
+			report("operation has no operationId", "paths", path, method)
+		}
+	})
+}
+
+func checkOperationIDUnique(s *spec.Swagger, report ReportFunc) {
+	seen := make(map[string]string)
+	walkOperations(s, func(path, method string, op *spec.Operation) {
+		if op.ID == "" {
+			return
+		}
+		where := strings.ToUpper(method) + " " + path
+		if other, ok := seen[op.ID]; ok {
+			report(fmt.Sprintf("operationId %q is already used by %s", op.ID, other), "paths", path, method, "operationId")
+			return
+		}
+		seen[op.ID] = where
+	})
+}
+
+func checkParameterFile(s *spec.Swagger, report ReportFunc) {
+	walkParameters(s, func(p spec.Parameter, tokens []string) {
+		if p.Type == "file" {
+			report(fmt.Sprintf("%s parameter %q is of type file", p.In, p.Name), tokens...)
+		}
+	})
+}
+
+// probes are values of simple types used to check that convert package
+// supports the type and format.
+var probes = map[string]string{
+	"string":  "x",
+	"integer": "1",
+	"number":  "1",
+	"boolean": "true",
+}
+
+// checkParameterFormat reports query and path parameters that convert
+// package rejects, so requests with them fail validation whatever the
+// value is, and they are never decoded.
+func checkParameterFormat(s *spec.Swagger, report ReportFunc) {
+	walkParameters(s, func(p spec.Parameter, tokens []string) {
+		if p.Type == "file" {
+			// Reported by parameter-file rule.
+			return
+		}
+
+		var err error
+		switch p.In {
+		case "query":
+			if p.Type == "array" && p.Items != nil {
+				_, err = convert.Array([]string{probes[p.Items.Type]}, p.Items.Type, p.Items.Format)
+			} else {
+				_, err = convert.Parameter([]string{probes[p.Type]}, &p)
+			}
+		case "path":
+			_, err = convert.Primitive(probes[p.Type], p.Type, p.Format)
+		default:
+			return
+		}
+
+		if err != nil {
+			report(fmt.Sprintf("%s parameter %q is not supported: %s", p.In, p.Name, err), tokens...)
+		}
+	})
+}
+
+// walkParameters calls fn for every parameter defined in the spec, with
+// JSON Pointer tokens of its location. Parameter references are skipped,
+// as referenced parameters are walked where they are defined.
+func walkParameters(s *spec.Swagger, fn func(p spec.Parameter, tokens []string)) {
+	names := make([]string, 0, len(s.Parameters))
+	for name := range s.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fn(s.Parameters[name], []string{"parameters", name})
+	}
+
+	walkList := func(ps []spec.Parameter, tokens ...string) {
+		for i, p := range ps {
+			if p.Ref.String() != "" {
+				continue
+			}
+			fn(p, append(tokens, "parameters", strconv.Itoa(i)))
+		}
+	}
+
+	for _, path := range sortedPaths(s) {
+		walkList(s.Paths.Paths[path].Parameters, "paths", path)
+	}
+	walkOperations(s, func(path, method string, op *spec.Operation) {
+		walkList(op.Parameters, "paths", path, method)
+	})
+}
@@ -0,0 +1,117 @@
+// Package lint checks OpenAPI specification against style rules, and for
+// things that this library does not handle, such as operations without
+// operationId or parameter formats that cannot be converted.
+//
+// Linting is performed on the original specification, i.e. not expanded,
+// so issues point to the place where the problem is defined.
+package lint
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// Severity defines how serious the issue is.
+type Severity string
+
+// Severities of issues.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+
+	// SeverityOff disables the rule.
+	SeverityOff Severity = "off"
+)
+
+// Issue describes a problem found in the specification.
+type Issue struct {
+	// Rule is the name of the rule that reported the issue.
+	Rule string `json:"rule"`
+
+	Severity Severity `json:"severity"`
+
+	// Pointer is a JSON Pointer to the problem location in the specification.
+	Pointer string `json:"pointer"`
+
+	Message string `json:"message"`
+}
+
+// Rule is a linter rule.
+type Rule struct {
+	// Name is a unique name of the rule, e.g. "operation-tags".
+	Name string
+
+	// Description describes what the rule checks.
+	Description string
+
+	// Severity is the default severity of the rule issues.
+	Severity Severity
+
+	// Check checks the specification and reports issues.
+	Check func(s *spec.Swagger, report ReportFunc)
+}
+
+// ReportFunc reports an issue located by the JSON Pointer tokens.
+type ReportFunc func(message string, tokens ...string)
+
+// Config configures the linter.
+type Config struct {
+	// Rules override default severities of the rules by rule name.
+	Rules map[string]Severity `json:"rules"`
+}
+
+// Lint checks the specification against the built-in rules and returns
+// found issues ordered by location. Issues suppressed with IgnoreExtension
+// are not returned.
+func Lint(s *spec.Swagger, cfg Config) []Issue {
+	var issues []Issue
+
+	ig, err := newIgnores(s)
+	if err != nil {
+		// Specification that was loaded is always marshalable, so this
+		// should never happen. Report everything then.
+		ig = &ignores{}
+	}
+
+	for _, rule := range Rules() {
+		severity := rule.Severity
+		if sev, ok := cfg.Rules[rule.Name]; ok {
+			severity = sev
+		}
+		if severity == SeverityOff {
+			continue
+		}
+
+		name := rule.Name
+		rule.Check(s, func(message string, tokens ...string) {
+			if ig.ignored(name, tokens) {
+				return
+			}
+			issues = append(issues, Issue{
+				Rule:     name,
+				Severity: severity,
+				Pointer:  Pointer(tokens...),
+				Message:  message,
+			})
+		})
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Pointer < issues[j].Pointer
+	})
+
+	return issues
+}
+
+// Pointer returns JSON Pointer composed of the tokens.
+func Pointer(tokens ...string) string {
+	var p string
+	for _, token := range tokens {
+		token = strings.Replace(token, "~", "~0", -1)
+		token = strings.Replace(token, "/", "~1", -1)
+		p += "/" + token
+	}
+	return p
+}
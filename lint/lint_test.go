@@ -0,0 +1,132 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/go-openapi/loads"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	doc, err := loads.Spec("testdata/style.yml")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	t.Run("default severities", func(t *testing.T) {
+		issues := Lint(doc.Spec(), Config{})
+
+		expected := []Issue{
+			{
+				Rule:     "definition-case",
+				Severity: SeverityWarning,
+				Pointer:  "/definitions/owner",
+				Message:  `definition name "owner" is not PascalCase`,
+			},
+			{
+				Rule:     "path-case",
+				Severity: SeverityWarning,
+				Pointer:  "/paths/~1petOwners",
+				Message:  `path segment "petOwners" is not kebab-case`,
+			},
+			{
+				Rule:     "operation-tags",
+				Severity: SeverityWarning,
+				Pointer:  "/paths/~1petOwners/get",
+				Message:  "operation has no tags",
+			},
+			{
+				Rule:     "operation-id-case",
+				Severity: SeverityWarning,
+				Pointer:  "/paths/~1petOwners/get/operationId",
+				Message:  `operationId "ListOwners" is not camelCase`,
+			},
+		}
+		assert.Equal(t, expected, issues)
+	})
+
+	t.Run("overridden severities", func(t *testing.T) {
+		issues := Lint(doc.Spec(), Config{Rules: map[string]Severity{
+			"definition-case":   SeverityOff,
+			"path-case":         SeverityOff,
+			"operation-id-case": SeverityOff,
+			"operation-tags":    SeverityError,
+			"property-case":     SeverityWarning,
+		}})
+
+		expected := []Issue{
+			{
+				Rule:     "property-case",
+				Severity: SeverityWarning,
+				Pointer:  "/definitions/owner/properties/first_name",
+				Message:  `property name "first_name" is not camelCase`,
+			},
+			{
+				Rule:     "operation-tags",
+				Severity: SeverityError,
+				Pointer:  "/paths/~1petOwners/get",
+				Message:  "operation has no tags",
+			},
+		}
+		assert.Equal(t, expected, issues)
+	})
+}
+
+func TestPointer(t *testing.T) {
+	assert.Equal(t, "", Pointer())
+	assert.Equal(t, "/paths/~1pets~1{id}/get", Pointer("paths", "/pets/{id}", "get"))
+	assert.Equal(t, "/definitions/a~0b", Pointer("definitions", "a~b"))
+}
+
+func TestLint_library(t *testing.T) {
+	doc, err := loads.Spec("testdata/library.yml")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	issues := Lint(doc.Spec(), Config{Rules: map[string]Severity{
+		"property-case": SeverityWarning,
+	}})
+
+	// Issues of "/petOwners" path and "owner" definition are suppressed
+	// with x-lint-ignore.
+	expected := []Issue{
+		{
+			Rule:     "parameter-format",
+			Severity: SeverityError,
+			Pointer:  "/parameters/since",
+			Message:  `query parameter "since" is not supported: unknown format date-time for type string`,
+		},
+		{
+			Rule:     "parameter-format",
+			Severity: SeverityError,
+			Pointer:  "/paths/~1pets/get/parameters/1",
+			Message:  `query parameter "flags" is not supported: unsupported (not implemented yet?) items type boolean for type array`,
+		},
+		{
+			Rule:     "operation-id-missing",
+			Severity: SeverityError,
+			Pointer:  "/paths/~1pets/post",
+			Message:  "operation has no operationId",
+		},
+		{
+			Rule:     "parameter-file",
+			Severity: SeverityWarning,
+			Pointer:  "/paths/~1pets/post/parameters/0",
+			Message:  `formData parameter "photo" is of type file`,
+		},
+		{
+			Rule:     "operation-id-unique",
+			Severity: SeverityError,
+			Pointer:  "/paths/~1pets~1{id}/get/operationId",
+			Message:  `operationId "listPets" is already used by GET /pets`,
+		},
+		{
+			Rule:     "parameter-format",
+			Severity: SeverityError,
+			Pointer:  "/paths/~1pets~1{id}/parameters/0",
+			Message:  `path parameter "id" is not supported: unknown format date for type string`,
+		},
+	}
+	assert.Equal(t, expected, issues)
+}
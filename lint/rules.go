@@ -0,0 +1,189 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+var (
+	camelCase  = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+	pascalCase = regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`)
+	kebabCase  = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+// Rules returns built-in rules.
+func Rules() []Rule {
+	return []Rule{
+		// Rules for things this library does not handle.
+		{
+			Name:        "operation-id-missing",
+			Description: "Operations must have operationId, as operations are routed and validated by it.",
+			Severity:    SeverityError,
+			Check:       checkOperationIDMissing,
+		},
+		{
+			Name:        "operation-id-unique",
+			Description: "Operation IDs must be unique.",
+			Severity:    SeverityError,
+			Check:       checkOperationIDUnique,
+		},
+		{
+			Name:        "parameter-file",
+			Description: "Parameters of type file are not supported by parameter conversion.",
+			Severity:    SeverityWarning,
+			Check:       checkParameterFile,
+		},
+		{
+			Name:        "parameter-format",
+			Description: "Query and path parameters must have types and formats supported by parameter conversion.",
+			Severity:    SeverityError,
+			Check:       checkParameterFormat,
+		},
+
+		// Style rules.
+		{
+			Name:        "operation-description",
+			Description: "Operations should have summary or description.",
+			Severity:    SeverityWarning,
+			Check:       checkOperationDescription,
+		},
+		{
+			Name:        "operation-tags",
+			Description: "Operations should have at least one tag.",
+			Severity:    SeverityWarning,
+			Check:       checkOperationTags,
+		},
+		{
+			Name:        "operation-id-case",
+			Description: "Operation IDs should be camelCase.",
+			Severity:    SeverityWarning,
+			Check:       checkOperationIDCase,
+		},
+		{
+			Name:        "path-case",
+			Description: "Path segments should be kebab-case.",
+			Severity:    SeverityWarning,
+			Check:       checkPathCase,
+		},
+		{
+			Name:        "definition-case",
+			Description: "Definition names should be PascalCase.",
+			Severity:    SeverityWarning,
+			Check:       checkDefinitionCase,
+		},
+		{
+			Name:        "property-case",
+			Description: "Definition property names should be camelCase.",
+			Severity:    SeverityOff,
+			Check:       checkPropertyCase,
+		},
+	}
+}
+
+func checkOperationDescription(s *spec.Swagger, report ReportFunc) {
+	walkOperations(s, func(path, method string, op *spec.Operation) {
+		if op.Summary == "" && op.Description == "" {
+			report("operation has neither summary nor description", "paths", path, method)
+		}
+	})
+}
+
+func checkOperationTags(s *spec.Swagger, report ReportFunc) {
+	walkOperations(s, func(path, method string, op *spec.Operation) {
+		if len(op.Tags) == 0 {
+			report("operation has no tags", "paths", path, method)
+		}
+	})
+}
+
+func checkOperationIDCase(s *spec.Swagger, report ReportFunc) {
+	walkOperations(s, func(path, method string, op *spec.Operation) {
+		if op.ID != "" && !camelCase.MatchString(op.ID) {
+			report(fmt.Sprintf("operationId %q is not camelCase", op.ID), "paths", path, method, "operationId")
+		}
+	})
+}
+
+func checkPathCase(s *spec.Swagger, report ReportFunc) {
+	for _, path := range sortedPaths(s) {
+		for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+			if segment == "" || strings.HasPrefix(segment, "{") {
+				continue
+			}
+			if !kebabCase.MatchString(segment) {
+				report(fmt.Sprintf("path segment %q is not kebab-case", segment), "paths", path)
+				break
+			}
+		}
+	}
+}
+
+func checkDefinitionCase(s *spec.Swagger, report ReportFunc) {
+	for _, name := range sortedKeys(s.Definitions) {
+		if !pascalCase.MatchString(name) {
+			report(fmt.Sprintf("definition name %q is not PascalCase", name), "definitions", name)
+		}
+	}
+}
+
+func checkPropertyCase(s *spec.Swagger, report ReportFunc) {
+	for _, name := range sortedKeys(s.Definitions) {
+		def := s.Definitions[name]
+		for _, prop := range sortedKeys(def.Properties) {
+			if !camelCase.MatchString(prop) {
+				report(fmt.Sprintf("property name %q is not camelCase", prop), "definitions", name, "properties", prop)
+			}
+		}
+	}
+}
+
+// walkOperations calls fn for every operation in the spec ordered by path
+// and method.
+func walkOperations(s *spec.Swagger, fn func(path, method string, op *spec.Operation)) {
+	for _, path := range sortedPaths(s) {
+		item := s.Paths.Paths[path]
+		ops := []struct {
+			method string
+			op     *spec.Operation
+		}{
+			{"get", item.Get},
+			{"put", item.Put},
+			{"post", item.Post},
+			{"delete", item.Delete},
+			{"options", item.Options},
+			{"head", item.Head},
+			{"patch", item.Patch},
+		}
+		for _, o := range ops {
+			if o.op != nil {
+				fn(path, o.method, o.op)
+			}
+		}
+	}
+}
+
+func sortedPaths(s *spec.Swagger) []string {
+	if s.Paths == nil {
+		return nil
+	}
+
+	paths := make([]string, 0, len(s.Paths.Paths))
+	for path := range s.Paths.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func sortedKeys(m map[string]spec.Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
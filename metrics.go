@@ -0,0 +1,184 @@
+package oas
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLatencyBuckets are upper bounds of the latency histogram buckets in
+// seconds used by metrics sinks of this package.
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// MetricsSink receives metrics of requests. Metrics are labelled by
+// operation id and tags, not by raw paths, so the number of label values
+// is limited by the spec. Implementations must be safe for concurrent use.
+type MetricsSink interface {
+	// ObserveRequest records metrics of the served request.
+	ObserveRequest(m RequestMetrics)
+
+	// ObserveProblem records a problem, e.g. a validation error, occurred
+	// while processing a request of the operation.
+	ObserveProblem(operationID string, tags []string)
+}
+
+// RequestMetrics are metrics of the served request.
+type RequestMetrics struct {
+	OperationID string
+	Tags        []string
+	Method      string
+
+	// Status is the status code of the response.
+	Status int
+
+	// Duration is the time spent serving the request.
+	Duration time.Duration
+
+	// RequestBytes is the size of the request body.
+	RequestBytes int64
+
+	// ResponseBytes is the size of the response body.
+	ResponseBytes int64
+}
+
+// Metrics returns a middleware that records metrics of requests to the sink.
+// To record validation problems, wrap problem handlers of validators with
+// NewMetricsProblemHandler.
+func (b *ResolvingBasis) Metrics(sink MetricsSink) Middleware {
+	return func(next http.Handler) http.Handler {
+		return &resolvingMetrics{
+			next:   next,
+			sink:   sink,
+			strict: b.strict,
+		}
+	}
+}
+
+type resolvingMetrics struct {
+	next http.Handler
+	sink MetricsSink
+
+	// strict enforces recording. If false, then requests without operation
+	// context are not recorded.
+	strict bool
+}
+
+func (mw *resolvingMetrics) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("metrics middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	var body *countingReader
+	if req.Body != nil && req.Body != http.NoBody {
+		body = &countingReader{ReadCloser: req.Body}
+		req.Body = body
+	}
+
+	start := time.Now()
+	ww := newWrapResponseWriter(w, req.ProtoMajor)
+	mw.next.ServeHTTP(ww, req)
+
+	m := RequestMetrics{
+		OperationID:   oi.operation.ID,
+		Tags:          oi.operation.Tags,
+		Method:        req.Method,
+		Status:        ww.Status(),
+		Duration:      time.Since(start),
+		ResponseBytes: int64(ww.BytesWritten()),
+	}
+	if m.Status == 0 {
+		// Nothing has been written, so net/http responds with 200.
+		m.Status = http.StatusOK
+	}
+	if body != nil {
+		m.RequestBytes = atomic.LoadInt64(&body.n)
+	}
+	if m.RequestBytes < req.ContentLength {
+		// The handler has not read the whole body.
+		m.RequestBytes = req.ContentLength
+	}
+	mw.sink.ObserveRequest(m)
+}
+
+// countingReader counts bytes read.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	atomic.AddInt64(&r.n, int64(n))
+	return n, err
+}
+
+// NewMetricsProblemHandler returns a problem handler that records problems
+// of requests with operation context to the sink, and passes them to next.
+// If next is nil, problems are only recorded.
+func NewMetricsProblemHandler(sink MetricsSink, next ProblemHandler) ProblemHandler {
+	return ProblemHandlerFunc(func(p Problem) {
+		if oi, ok := getOperationInfo(p.Request()); ok {
+			sink.ObserveProblem(oi.operation.ID, oi.operation.Tags)
+		}
+		if next != nil {
+			next.HandleProblem(p)
+		}
+	})
+}
+
+// histogram is a latency histogram with cumulative bucket counts.
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, le := range h.buckets {
+		if v <= le {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// metricsLabels are labels of operation metrics.
+type metricsLabels struct {
+	operationID string
+	tags        string
+}
+
+func newMetricsLabels(operationID string, tags []string) metricsLabels {
+	return metricsLabels{operationID: operationID, tags: strings.Join(tags, ",")}
+}
+
+func sortMetricsLabels(labels []metricsLabels) {
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].operationID != labels[j].operationID {
+			return labels[i].operationID < labels[j].operationID
+		}
+		return labels[i].tags < labels[j].tags
+	})
+}
+
+func formatBucket(le float64) string {
+	return strconv.FormatFloat(le, 'g', -1, 64)
+}
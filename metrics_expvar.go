@@ -0,0 +1,132 @@
+package oas
+
+import (
+	"expvar"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// ExpvarMetrics is a metrics sink that publishes metrics with expvar, so
+// they are served by expvar handler at "/debug/vars". Metrics are published
+// as a map of operations by operation id, e.g.:
+//
+//  "oas": {
+//      "getPet": {
+//          "duration_seconds": {"0.005": 10, "0.01": 12, ..., "+Inf": 12, "sum": 0.04},
+//          "problems": 1,
+//          "request_bytes": 0,
+//          "requests": 12,
+//          "response_bytes": 1024,
+//          "status": {"200": 11, "404": 1},
+//          "tags": "pets"
+//      }
+//  }
+type ExpvarMetrics struct {
+	buckets []float64
+	vars    *expvar.Map
+
+	mu  sync.Mutex
+	ops map[string]*expvarOperation
+}
+
+type expvarOperation struct {
+	requests      *expvar.Int
+	status        *expvar.Map
+	duration      *expvar.Map
+	durationSum   *expvar.Float
+	requestBytes  *expvar.Int
+	responseBytes *expvar.Int
+	problems      *expvar.Int
+}
+
+// NewExpvarMetrics returns a new expvar metrics sink that publishes metrics
+// with the name. Expvar variables cannot be unpublished, so the name must be
+// unique for the process: unlike expvar.Publish, which panics, it returns an
+// error if the name is already published.
+func NewExpvarMetrics(name string) (*ExpvarMetrics, error) {
+	em := &ExpvarMetrics{
+		buckets: DefaultLatencyBuckets,
+		vars:    new(expvar.Map).Init(),
+		ops:     make(map[string]*expvarOperation),
+	}
+
+	expvarPublishMu.Lock()
+	defer expvarPublishMu.Unlock()
+
+	if expvar.Get(name) != nil {
+		return nil, fmt.Errorf("expvar %q is already published", name)
+	}
+	expvar.Publish(name, em.vars)
+	return em, nil
+}
+
+// expvarPublishMu guards checking and publishing of expvar names.
+var expvarPublishMu sync.Mutex
+
+// ObserveRequest implements MetricsSink.
+func (em *ExpvarMetrics) ObserveRequest(m RequestMetrics) {
+	op := em.operation(m.OperationID, m.Tags)
+
+	op.requests.Add(1)
+	op.status.Add(strconv.Itoa(m.Status), 1)
+
+	seconds := m.Duration.Seconds()
+	for _, le := range em.buckets {
+		if seconds <= le {
+			op.duration.Add(formatBucket(le), 1)
+		}
+	}
+	op.duration.Add("+Inf", 1)
+	op.durationSum.Add(seconds)
+
+	op.requestBytes.Add(m.RequestBytes)
+	op.responseBytes.Add(m.ResponseBytes)
+}
+
+// ObserveProblem implements MetricsSink.
+func (em *ExpvarMetrics) ObserveProblem(operationID string, tags []string) {
+	em.operation(operationID, tags).problems.Add(1)
+}
+
+// operation returns vars of the operation, creating them on the first
+// call.
+func (em *ExpvarMetrics) operation(id string, tags []string) *expvarOperation {
+	em.mu.Lock()
+	defer em.mu.Unlock()
+
+	if op, ok := em.ops[id]; ok {
+		return op
+	}
+
+	op := &expvarOperation{
+		requests:      new(expvar.Int),
+		status:        new(expvar.Map).Init(),
+		duration:      new(expvar.Map).Init(),
+		durationSum:   new(expvar.Float),
+		requestBytes:  new(expvar.Int),
+		responseBytes: new(expvar.Int),
+		problems:      new(expvar.Int),
+	}
+	for _, le := range em.buckets {
+		op.duration.Add(formatBucket(le), 0)
+	}
+	op.duration.Add("+Inf", 0)
+	op.duration.Set("sum", op.durationSum)
+
+	tagsVar := new(expvar.String)
+	tagsVar.Set(newMetricsLabels(id, tags).tags)
+
+	vars := new(expvar.Map).Init()
+	vars.Set("requests", op.requests)
+	vars.Set("status", op.status)
+	vars.Set("duration_seconds", op.duration)
+	vars.Set("request_bytes", op.requestBytes)
+	vars.Set("response_bytes", op.responseBytes)
+	vars.Set("problems", op.problems)
+	vars.Set("tags", tagsVar)
+	em.vars.Set(id, vars)
+
+	em.ops[id] = op
+	return op
+}
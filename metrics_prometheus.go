@@ -0,0 +1,159 @@
+package oas
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PrometheusMetrics is a metrics sink that serves metrics in Prometheus text
+// exposition format. It is an http.Handler, e.g. for "/metrics" route.
+//
+// Metrics are:
+//
+//  - oas_requests_total: counter of requests by operation, tags and status;
+//  - oas_request_duration_seconds: histogram of request latencies;
+//  - oas_request_bytes_total: counter of request body bytes;
+//  - oas_response_bytes_total: counter of response body bytes;
+//  - oas_problems_total: counter of problems, e.g. validation errors.
+type PrometheusMetrics struct {
+	mu       sync.Mutex
+	buckets  []float64
+	ops      map[metricsLabels]*prometheusOperation
+	problems map[metricsLabels]uint64
+}
+
+type prometheusOperation struct {
+	requests      map[int]uint64
+	duration      *histogram
+	requestBytes  int64
+	responseBytes int64
+}
+
+// NewPrometheusMetrics returns a new Prometheus metrics sink with latency
+// buckets in seconds. If no buckets are given, DefaultLatencyBuckets are
+// used.
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	return &PrometheusMetrics{
+		buckets:  buckets,
+		ops:      make(map[metricsLabels]*prometheusOperation),
+		problems: make(map[metricsLabels]uint64),
+	}
+}
+
+// ObserveRequest implements MetricsSink.
+func (pm *PrometheusMetrics) ObserveRequest(m RequestMetrics) {
+	labels := newMetricsLabels(m.OperationID, m.Tags)
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	op, ok := pm.ops[labels]
+	if !ok {
+		op = &prometheusOperation{
+			requests: make(map[int]uint64),
+			duration: newHistogram(pm.buckets),
+		}
+		pm.ops[labels] = op
+	}
+	op.requests[m.Status]++
+	op.duration.observe(m.Duration.Seconds())
+	op.requestBytes += m.RequestBytes
+	op.responseBytes += m.ResponseBytes
+}
+
+// ObserveProblem implements MetricsSink.
+func (pm *PrometheusMetrics) ObserveProblem(operationID string, tags []string) {
+	pm.mu.Lock()
+	pm.problems[newMetricsLabels(operationID, tags)]++
+	pm.mu.Unlock()
+}
+
+// ServeHTTP writes the metrics in Prometheus text exposition format.
+func (pm *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	bw := bufio.NewWriter(w)
+	pm.write(bw)
+	bw.Flush() // nolint
+}
+
+func (pm *PrometheusMetrics) write(w *bufio.Writer) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	labels := make([]metricsLabels, 0, len(pm.ops))
+	for l := range pm.ops {
+		labels = append(labels, l)
+	}
+	sortMetricsLabels(labels)
+
+	writeMetricHeader(w, "oas_requests_total", "counter", "Number of requests by operation and status code.")
+	for _, l := range labels {
+		op := pm.ops[l]
+		codes := make([]int, 0, len(op.requests))
+		for code := range op.requests {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(w, "oas_requests_total{%s,status=\"%d\"} %d\n", l.prometheus(), code, op.requests[code])
+		}
+	}
+
+	writeMetricHeader(w, "oas_request_duration_seconds", "histogram", "Request latencies in seconds by operation.")
+	for _, l := range labels {
+		h := pm.ops[l].duration
+		for i, le := range h.buckets {
+			fmt.Fprintf(w, "oas_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", l.prometheus(), formatBucket(le), h.counts[i])
+		}
+		fmt.Fprintf(w, "oas_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", l.prometheus(), h.count)
+		fmt.Fprintf(w, "oas_request_duration_seconds_sum{%s} %g\n", l.prometheus(), h.sum)
+		fmt.Fprintf(w, "oas_request_duration_seconds_count{%s} %d\n", l.prometheus(), h.count)
+	}
+
+	writeMetricHeader(w, "oas_request_bytes_total", "counter", "Size of request bodies in bytes by operation.")
+	for _, l := range labels {
+		fmt.Fprintf(w, "oas_request_bytes_total{%s} %d\n", l.prometheus(), pm.ops[l].requestBytes)
+	}
+
+	writeMetricHeader(w, "oas_response_bytes_total", "counter", "Size of response bodies in bytes by operation.")
+	for _, l := range labels {
+		fmt.Fprintf(w, "oas_response_bytes_total{%s} %d\n", l.prometheus(), pm.ops[l].responseBytes)
+	}
+
+	problems := make([]metricsLabels, 0, len(pm.problems))
+	for l := range pm.problems {
+		problems = append(problems, l)
+	}
+	sortMetricsLabels(problems)
+
+	writeMetricHeader(w, "oas_problems_total", "counter", "Number of problems, e.g. validation errors, by operation.")
+	for _, l := range problems {
+		fmt.Fprintf(w, "oas_problems_total{%s} %d\n", l.prometheus(), pm.problems[l])
+	}
+}
+
+func writeMetricHeader(w *bufio.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// prometheus returns the labels in Prometheus format.
+func (l metricsLabels) prometheus() string {
+	return fmt.Sprintf(`operation_id="%s",tags="%s"`, escapeLabelValue(l.operationID), escapeLabelValue(l.tags))
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
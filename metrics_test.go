@@ -0,0 +1,179 @@
+package oas
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMetricsSink struct {
+	mu       sync.Mutex
+	requests []RequestMetrics
+	problems []string
+}
+
+func (s *fakeMetricsSink) ObserveRequest(m RequestMetrics) {
+	s.mu.Lock()
+	m.Duration = 0
+	s.requests = append(s.requests, m)
+	s.mu.Unlock()
+}
+
+func (s *fakeMetricsSink) ObserveProblem(operationID string, tags []string) {
+	s.mu.Lock()
+	s.problems = append(s.problems, operationID+" "+strings.Join(tags, ","))
+	s.mu.Unlock()
+}
+
+func TestResolvingBasis_Metrics(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	sink := &fakeMetricsSink{}
+
+	b := &ResolvingBasis{adapter: templateAdapter{}, doc: doc}
+	b.initCache()
+
+	problemHandler := NewMetricsProblemHandler(sink, newProblemHandlerErrorResponder())
+
+	router := &templateRouter{}
+	err := b.OperationRouter(router).
+		WithOperationHandlers(map[string]http.Handler{
+			"addPet": http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+			}),
+			"getPetById": http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Write([]byte(`{"name":"Rex"}`)) // nolint
+			}),
+		}).
+		WithMiddleware(
+			b.Metrics(sink),
+			b.QueryValidator(WithProblemHandler(problemHandler)),
+		).
+		Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v2/pet/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v2/pet/1?debug=maybe", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v2/pet", strings.NewReader(`{"name":"Rex"}`)))
+
+	assert.Equal(t, []RequestMetrics{
+		{OperationID: "getPetById", Tags: []string{"pet"}, Method: http.MethodGet, Status: 200, ResponseBytes: 14},
+		{OperationID: "getPetById", Tags: []string{"pet"}, Method: http.MethodGet, Status: 400, ResponseBytes: 88},
+		{OperationID: "addPet", Tags: []string{"pet"}, Method: http.MethodPost, Status: 201, RequestBytes: 14},
+	}, sink.requests)
+	assert.Equal(t, []string{"getPetById pet"}, sink.problems)
+}
+
+func TestNewMetricsProblemHandler_nilNext(t *testing.T) {
+	sink := &fakeMetricsSink{}
+	op := &spec.Operation{}
+	op.ID = "getPetById"
+	op.Tags = []string{"pet"}
+
+	req := withOperationInfo(httptest.NewRequest(http.MethodGet, "/v2/pet/1", nil), operationInfo{operation: op})
+	rec := httptest.NewRecorder()
+
+	NewMetricsProblemHandler(sink, nil).HandleProblem(NewProblem(rec, req, fmt.Errorf("problem")))
+
+	assert.Equal(t, []string{"getPetById pet"}, sink.problems)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	pm := NewPrometheusMetrics(0.1, 1)
+	pm.ObserveRequest(RequestMetrics{OperationID: "getPet", Tags: []string{"pets"}, Status: 200, Duration: 50 * time.Millisecond, ResponseBytes: 10})
+	pm.ObserveRequest(RequestMetrics{OperationID: "getPet", Tags: []string{"pets"}, Status: 404, Duration: 2 * time.Second})
+	pm.ObserveRequest(RequestMetrics{OperationID: "addPet", Tags: []string{"pets", "admin"}, Status: 201, Duration: 500 * time.Millisecond, RequestBytes: 20})
+	pm.ObserveProblem("addPet", []string{"pets", "admin"})
+
+	w := httptest.NewRecorder()
+	pm.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	expected := `# HELP oas_requests_total Number of requests by operation and status code.
+# TYPE oas_requests_total counter
+oas_requests_total{operation_id="addPet",tags="pets,admin",status="201"} 1
+oas_requests_total{operation_id="getPet",tags="pets",status="200"} 1
+oas_requests_total{operation_id="getPet",tags="pets",status="404"} 1
+# HELP oas_request_duration_seconds Request latencies in seconds by operation.
+# TYPE oas_request_duration_seconds histogram
+oas_request_duration_seconds_bucket{operation_id="addPet",tags="pets,admin",le="0.1"} 0
+oas_request_duration_seconds_bucket{operation_id="addPet",tags="pets,admin",le="1"} 1
+oas_request_duration_seconds_bucket{operation_id="addPet",tags="pets,admin",le="+Inf"} 1
+oas_request_duration_seconds_sum{operation_id="addPet",tags="pets,admin"} 0.5
+oas_request_duration_seconds_count{operation_id="addPet",tags="pets,admin"} 1
+oas_request_duration_seconds_bucket{operation_id="getPet",tags="pets",le="0.1"} 1
+oas_request_duration_seconds_bucket{operation_id="getPet",tags="pets",le="1"} 1
+oas_request_duration_seconds_bucket{operation_id="getPet",tags="pets",le="+Inf"} 2
+oas_request_duration_seconds_sum{operation_id="getPet",tags="pets"} 2.05
+oas_request_duration_seconds_count{operation_id="getPet",tags="pets"} 2
+# HELP oas_request_bytes_total Size of request bodies in bytes by operation.
+# TYPE oas_request_bytes_total counter
+oas_request_bytes_total{operation_id="addPet",tags="pets,admin"} 20
+oas_request_bytes_total{operation_id="getPet",tags="pets"} 0
+# HELP oas_response_bytes_total Size of response bodies in bytes by operation.
+# TYPE oas_response_bytes_total counter
+oas_response_bytes_total{operation_id="addPet",tags="pets,admin"} 0
+oas_response_bytes_total{operation_id="getPet",tags="pets"} 10
+# HELP oas_problems_total Number of problems, e.g. validation errors, by operation.
+# TYPE oas_problems_total counter
+oas_problems_total{operation_id="addPet",tags="pets,admin"} 1
+`
+	assert.Equal(t, expected, w.Body.String())
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+var expvarTestRun int
+
+func TestExpvarMetrics(t *testing.T) {
+	// Expvar names cannot be unpublished, so every run of the test uses
+	// a new name.
+	expvarTestRun++
+	name := fmt.Sprintf("oas_test_metrics_%d", expvarTestRun)
+
+	em, err := NewExpvarMetrics(name)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	_, err = NewExpvarMetrics(name)
+	assert.EqualError(t, err, fmt.Sprintf("expvar %q is already published", name))
+
+	em.ObserveRequest(RequestMetrics{OperationID: "getPet", Tags: []string{"pets"}, Status: 200, Duration: 50 * time.Millisecond, ResponseBytes: 10})
+	em.ObserveRequest(RequestMetrics{OperationID: "getPet", Tags: []string{"pets"}, Status: 404, Duration: 20 * time.Second})
+	em.ObserveProblem("getPet", []string{"pets"})
+
+	var vars map[string]struct {
+		Requests      int                `json:"requests"`
+		Status        map[string]int     `json:"status"`
+		Duration      map[string]float64 `json:"duration_seconds"`
+		RequestBytes  int                `json:"request_bytes"`
+		ResponseBytes int                `json:"response_bytes"`
+		Problems      int                `json:"problems"`
+		Tags          string             `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &vars); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	op := vars["getPet"]
+	assert.Equal(t, 2, op.Requests)
+	assert.Equal(t, map[string]int{"200": 1, "404": 1}, op.Status)
+	assert.Equal(t, 0.0, op.Duration["0.025"])
+	assert.Equal(t, 1.0, op.Duration["0.05"])
+	assert.Equal(t, 1.0, op.Duration["10"])
+	assert.Equal(t, 2.0, op.Duration["+Inf"])
+	assert.InDelta(t, 20.05, op.Duration["sum"], 0.0001)
+	assert.Equal(t, 10, op.ResponseBytes)
+	assert.Equal(t, 1, op.Problems)
+	assert.Equal(t, "pets", op.Tags)
+}
@@ -13,6 +13,8 @@ type MiddlewareOptions struct {
 	jsonSelectors     []*regexp.Regexp
 	problemHandler    ProblemHandler
 	continueOnProblem bool
+	stripReadOnly     bool
+	applyDefaults     bool
 }
 
 // MiddlewareOption represent option for middleware.
@@ -47,6 +49,26 @@ func WithContinueOnProblem(contin bool) MiddlewareOption {
 	}
 }
 
+// WithStripReadOnly returns a middleware option that defines if request body
+// validator should remove properties marked as readOnly from the request body
+// instead of rejecting the request.
+func WithStripReadOnly(strip bool) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.stripReadOnly = strip
+	}
+}
+
+// WithApplyDefaults returns a middleware option that defines if query and
+// request body validators should set default values from the spec for missing
+// query parameters and body properties, so the handler sees a fully populated
+// request. Query defaults update req.URL.RawQuery, and body defaults
+// re-serialize the request body.
+func WithApplyDefaults(apply bool) MiddlewareOption {
+	return func(opts *MiddlewareOptions) {
+		opts.applyDefaults = apply
+	}
+}
+
 func parseMiddlewareOptions(opts ...MiddlewareOption) MiddlewareOptions {
 	options := MiddlewareOptions{
 		jsonSelectors:     nil,
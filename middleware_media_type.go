@@ -109,11 +109,11 @@ func (mw *responseContentTypeValidator) ServeHTTP(w http.ResponseWriter, req *ht
 
 	if !matchMediaType(ct, req.Header["Accept"]) {
 		err := fmt.Errorf("Content-Type header of the response does not match Accept header of the request")
-		mw.problemHandler.HandleProblem(NewProblem(w, req, err))
+		mw.problemHandler.HandleProblem(newProblem(ProblemKindResponseContentType, w, req, err))
 	}
 
 	if !matchMediaType(ct, produces) {
 		err := fmt.Errorf("Content-Type header of the response does not match any of the media types the operation can produce")
-		mw.problemHandler.HandleProblem(NewProblem(w, req, err))
+		mw.problemHandler.HandleProblem(newProblem(ProblemKindResponseContentType, w, req, err))
 	}
 }
@@ -15,6 +15,10 @@ type queryValidator struct {
 
 	problemHandler    ProblemHandler
 	continueOnProblem bool
+
+	// applyDefaults defines if missing query parameters should be set
+	// to their default values.
+	applyDefaults bool
 }
 
 func (mw *queryValidator) ServeHTTP(w http.ResponseWriter, req *http.Request, params []spec.Parameter, ok bool) {
@@ -23,9 +27,14 @@ func (mw *queryValidator) ServeHTTP(w http.ResponseWriter, req *http.Request, pa
 		return
 	}
 
-	if errs := validate.Query(params, req.URL.Query()); len(errs) > 0 {
+	q := req.URL.Query()
+	if mw.applyDefaults && validate.ApplyQueryDefaults(params, q) {
+		req.URL.RawQuery = q.Encode()
+	}
+
+	if errs := validate.Query(params, q); len(errs) > 0 {
 		me := newMultiError("query params do not match the schema", errs...)
-		mw.problemHandler.HandleProblem(NewProblem(w, req, me))
+		mw.problemHandler.HandleProblem(newProblem(ProblemKindQuery, w, req, me))
 		if !mw.continueOnProblem {
 			return
 		}
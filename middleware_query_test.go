@@ -48,6 +48,28 @@ func TestQueryValidator(t *testing.T) {
 	}
 }
 
+func TestQueryValidator_applyDefaults(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	params := doc.Analyzer.ParametersFor("loginUser")
+
+	var handledQuery string
+	v := &queryValidator{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			handledQuery = req.URL.RawQuery
+		}),
+		problemHandler:    problemHandlerResponseWriter(),
+		continueOnProblem: false,
+		applyDefaults:     true,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/user/login?username=johndoe&password=123", nil)
+	w := httptest.NewRecorder()
+	v.ServeHTTP(w, req, params, true)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "expiresIn=3600&password=123&username=johndoe", handledQuery)
+}
+
 func handleUserLogin(w http.ResponseWriter, req *http.Request) {
 	username := req.URL.Query().Get("username")
 	password := req.URL.Query().Get("password")
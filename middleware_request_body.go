@@ -26,9 +26,17 @@ type requestBodyValidator struct {
 
 	problemHandler    ProblemHandler
 	continueOnProblem bool
+
+	// stripReadOnly defines if properties marked as readOnly should be
+	// removed from the request body instead of being reported as problems.
+	stripReadOnly bool
+
+	// applyDefaults defines if missing properties should be set to their
+	// default values.
+	applyDefaults bool
 }
 
-func (mw *requestBodyValidator) ServeHTTP(w http.ResponseWriter, req *http.Request, params []spec.Parameter, ok bool) {
+func (mw *requestBodyValidator) ServeHTTP(w http.ResponseWriter, req *http.Request, params []spec.Parameter, validator *validate.SchemaValidator, ok bool) {
 	if !ok {
 		mw.next.ServeHTTP(w, req)
 		return
@@ -39,7 +47,7 @@ func (mw *requestBodyValidator) ServeHTTP(w http.ResponseWriter, req *http.Reque
 			if param.In == "body" && param.Required {
 				// No request body found, but operation actually requires body.
 				e := fmt.Errorf("request body is empty, but the operation requires non-empty body")
-				mw.problemHandler.HandleProblem(NewProblem(w, req, e))
+				mw.problemHandler.HandleProblem(newProblem(ProblemKindRequestBody, w, req, e))
 				if !mw.continueOnProblem {
 					return
 				}
@@ -59,15 +67,29 @@ func (mw *requestBodyValidator) ServeHTTP(w http.ResponseWriter, req *http.Reque
 	body, err := bodyPayload(req)
 	if err != nil {
 		e := fmt.Errorf("request body contains invalid json: %s", err)
-		mw.problemHandler.HandleProblem(NewProblem(w, req, e))
+		mw.problemHandler.HandleProblem(newProblem(ProblemKindRequestBody, w, req, e))
 		if !mw.continueOnProblem {
 			return
 		}
 	}
 
-	if errs := validate.Body(params, body); len(errs) > 0 {
+	rewrite := mw.stripReadOnly && validator.StripReadOnly(body)
+	if mw.applyDefaults && validator.ApplyDefaults(body) {
+		rewrite = true
+	}
+	if rewrite {
+		if err := setBodyPayload(req, body); err != nil {
+			e := fmt.Errorf("cannot rewrite request body: %s", err)
+			mw.problemHandler.HandleProblem(newProblem(ProblemKindRequestBody, w, req, e))
+			if !mw.continueOnProblem {
+				return
+			}
+		}
+	}
+
+	if errs := validator.Validate(body); len(errs) > 0 {
 		me := newMultiError("request body does not match the schema", errs...)
-		mw.problemHandler.HandleProblem(NewProblem(w, req, me))
+		mw.problemHandler.HandleProblem(newProblem(ProblemKindRequestBody, w, req, me))
 		if !mw.continueOnProblem {
 			return
 		}
@@ -95,11 +117,37 @@ func bodyPayload(req *http.Request) (interface{}, error) {
 	tr := io.TeeReader(req.Body, buf)
 	defer req.Body.Close()
 
-	var payload interface{}
-	if err := json.NewDecoder(tr).Decode(&payload); err != nil {
+	payload, err := decodeJSON(tr)
+	if err != nil {
 		return nil, err
 	}
 
 	req.Body = ioutil.NopCloser(buf)
 	return payload, nil
 }
+
+// decodeJSON decodes JSON value from r. Numbers are decoded as json.Number,
+// so integers beyond 2^53, e.g. int64 ids, are neither rounded on validation
+// nor when the body is rewritten.
+func decodeJSON(r io.Reader) (interface{}, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var data interface{}
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// setBodyPayload replaces req.Body with the payload serialized to json.
+func setBodyPayload(req *http.Request, payload interface{}) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	req.ContentLength = int64(len(b))
+	return nil
+}
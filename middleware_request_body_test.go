@@ -5,12 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
 	"testing"
 
+	"github.com/go-openapi/spec"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/hypnoglow/oas2/validate"
 )
 
 func TestRequestBodyValidator(t *testing.T) {
@@ -36,7 +40,7 @@ func TestRequestBodyValidator(t *testing.T) {
 			contentType:    "application/json",
 			body:           `{"name":"johndoe","age":"abc"}`,
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"errors":[{"message":"age in body must be of type integer: \"string\"","field":"age"}]}`,
+			expectedBody:   `{"errors":[{"message":"age in body must be of type integer: \"string\"","field":"age","value":"abc"}]}`,
 		},
 		"no body": {
 			contentType:    "application/json",
@@ -50,6 +54,12 @@ func TestRequestBodyValidator(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   `{"errors":[{"message":"request body contains invalid json: unexpected EOF"}]}`,
 		},
+		"readOnly field \"id\" is present": {
+			contentType:    "application/json",
+			body:           `{"id":12,"name":"johndoe","age":7}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"errors":[{"message":"id in body is read-only","field":"id","value":12}]}`,
+		},
 		"skip body validation for not application/json content type": {
 			contentType:    "text/plain",
 			body:           "some",
@@ -59,7 +69,9 @@ func TestRequestBodyValidator(t *testing.T) {
 	}
 
 	doc := loadDocFile(t, "testdata/petstore_1.yml")
-	params := doc.Analyzer.ParametersFor("addPet")
+	_, _, op, ok := doc.Analyzer.OperationForName("addPet")
+	assert.True(t, ok)
+	oi := newOperationInfo(doc, op, nil)
 
 	v := &requestBodyValidator{
 		next:              http.HandlerFunc(handleAddPet),
@@ -77,7 +89,189 @@ func TestRequestBodyValidator(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, "/v2/pet", body)
 			req.Header.Set("Content-Type", tc.contentType)
 			w := httptest.NewRecorder()
-			v.ServeHTTP(w, req, params, true)
+			v.ServeHTTP(w, req, oi.params, oi.bodyValidator, true)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRequestBodyValidator_stripReadOnly(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	_, _, op, ok := doc.Analyzer.OperationForName("addPet")
+	assert.True(t, ok)
+	oi := newOperationInfo(doc, op, nil)
+
+	var handledBody string
+	v := &requestBodyValidator{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			b, err := ioutil.ReadAll(req.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(b)), req.ContentLength)
+			handledBody = string(b)
+		}),
+		jsonSelectors:     []*regexp.Regexp{contentTypeSelectorRegexJSON},
+		problemHandler:    problemHandlerResponseWriter(),
+		continueOnProblem: false,
+		stripReadOnly:     true,
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v2/pet", bytes.NewBufferString(`{"id":12,"name":"johndoe","age":7}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	v.ServeHTTP(w, req, oi.params, oi.bodyValidator, true)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"name":"johndoe","age":7}`, handledBody)
+}
+
+func TestRequestBodyValidator_largeIntegers(t *testing.T) {
+	var params []spec.Parameter
+	err := json.Unmarshal([]byte(`[{
+	  "name": "pet",
+	  "in": "body",
+	  "required": true,
+	  "schema": {
+	    "type": "object",
+	    "required": ["ownerId"],
+	    "properties": {
+	      "id": {"type": "integer", "format": "int64", "readOnly": true},
+	      "ownerId": {"type": "integer", "format": "int64", "minimum": 1},
+	      "weight": {"type": "integer"}
+	    }
+	  }
+	}]`), &params)
+	assert.NoError(t, err)
+
+	testCases := map[string]struct {
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"int64 above 2^53 is kept exact": {
+			body:           `{"id":12,"ownerId":9007199254740993}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"ownerId":9007199254740993}`,
+		},
+		"max int64": {
+			body:           `{"ownerId":9223372036854775807}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"ownerId":9223372036854775807}`,
+		},
+		"not an integer": {
+			body:           `{"ownerId":1,"weight":1.5}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"errors":[{"message":"weight in body must be of type integer: \"number\"","field":"weight","value":1.5}]}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var handledBody string
+			v := &requestBodyValidator{
+				next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					b, err := ioutil.ReadAll(req.Body)
+					assert.NoError(t, err)
+					handledBody = string(b)
+				}),
+				jsonSelectors:     []*regexp.Regexp{contentTypeSelectorRegexJSON},
+				problemHandler:    problemHandlerResponseWriter(),
+				continueOnProblem: false,
+				stripReadOnly:     true,
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/pets", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			v.ServeHTTP(w, req, params, validate.NewBodyValidator(params), true)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus == http.StatusOK {
+				assert.Equal(t, tc.expectedBody, handledBody)
+			} else {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequestBodyValidator_applyDefaults(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	_, _, op, ok := doc.Analyzer.OperationForName("addPet")
+	assert.True(t, ok)
+	oi := newOperationInfo(doc, op, nil)
+
+	var handledBody string
+	v := &requestBodyValidator{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			b, err := ioutil.ReadAll(req.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(b)), req.ContentLength)
+			handledBody = string(b)
+		}),
+		jsonSelectors:     []*regexp.Regexp{contentTypeSelectorRegexJSON},
+		problemHandler:    problemHandlerResponseWriter(),
+		continueOnProblem: false,
+		applyDefaults:     true,
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v2/pet", bytes.NewBufferString(`{"name":"johndoe","age":7}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	v.ServeHTTP(w, req, oi.params, oi.bodyValidator, true)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"name":"johndoe","age":7,"status":"available"}`, handledBody)
+}
+
+func TestRequestBodyValidator_polymorphic(t *testing.T) {
+	testCases := map[string]struct {
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"valid subtype": {
+			body:           `{"kind":"Cat","name":"Tom","huntingSkill":"lazy"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		"invalid subtype property": {
+			body:           `{"kind":"dog","name":"Rex","packSize":-1}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"errors":[{"message":"packSize in body should be greater than or equal to 0","field":"packSize","value":-1}]}`,
+		},
+		"unknown discriminator value": {
+			body:           `{"kind":"Cow","name":"Milka"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"errors":[{"message":"kind in body has unknown discriminator value \"Cow\"","field":"kind","value":"Cow"}]}`,
+		},
+		"subtype of another base": {
+			body:           `{"kind":"Car","name":"Herbie","wheels":4}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"errors":[{"message":"kind in body has unknown discriminator value \"Car\"","field":"kind","value":"Car"}]}`,
+		},
+	}
+
+	doc := loadDocFile(t, "testdata/polymorphic.yml")
+	_, _, op, ok := doc.Analyzer.OperationForName("addAnimal")
+	assert.True(t, ok)
+	oi := newOperationInfo(doc, op, validate.NewSubtypes(doc.Spec().Definitions, doc.OrigSpec().Definitions))
+
+	v := &requestBodyValidator{
+		next: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}),
+		jsonSelectors:     []*regexp.Regexp{contentTypeSelectorRegexJSON},
+		problemHandler:    problemHandlerResponseWriter(),
+		continueOnProblem: false,
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/animals", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			v.ServeHTTP(w, req, oi.params, oi.bodyValidator, true)
 
 			assert.Equal(t, tc.expectedStatus, w.Code)
 			assert.Equal(t, tc.expectedBody, w.Body.String())
@@ -2,7 +2,6 @@ package oas
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -25,7 +24,7 @@ type responseBodyValidator struct {
 	problemHandler ProblemHandler
 }
 
-func (mw *responseBodyValidator) ServeHTTP(w http.ResponseWriter, req *http.Request, responses *spec.Responses, ok bool) {
+func (mw *responseBodyValidator) ServeHTTP(w http.ResponseWriter, req *http.Request, responses *spec.Responses, validators map[int]*validate.SchemaValidator, ok bool) {
 	if !ok {
 		mw.next.ServeHTTP(w, req)
 		return
@@ -57,8 +56,8 @@ func (mw *responseBodyValidator) ServeHTTP(w http.ResponseWriter, req *http.Requ
 		// > If this field does not exist, it means no content is returned as
 		// > part of the response.
 		if respBuf.Len() > 0 {
-			e := fmt.Errorf("response has non-emtpy body, but the operation does not define response schema for code %d", rr.Status())
-			mw.problemHandler.HandleProblem(NewProblem(w, req, e))
+			e := fmt.Errorf("response has non-empty body, but the operation does not define response schema for code %d", rr.Status())
+			mw.problemHandler.HandleProblem(newProblem(ProblemKindResponseBody, w, req, e))
 		}
 		return
 	}
@@ -69,16 +68,16 @@ func (mw *responseBodyValidator) ServeHTTP(w http.ResponseWriter, req *http.Requ
 		return
 	}
 
-	var body interface{}
-	if err := json.NewDecoder(respBuf).Decode(&body); err != nil {
+	body, err := decodeJSON(respBuf)
+	if err != nil {
 		e := fmt.Errorf("response body contains invalid json: %s", err)
-		mw.problemHandler.HandleProblem(NewProblem(w, req, e))
+		mw.problemHandler.HandleProblem(newProblem(ProblemKindResponseBody, w, req, e))
 		return
 	}
 
-	if errs := validate.BySchema(responseSpec.Schema, body); len(errs) > 0 {
+	if errs := validators[rr.Status()].Validate(body); len(errs) > 0 {
 		me := newMultiError("response body does not match the schema", errs...)
-		mw.problemHandler.HandleProblem(NewProblem(w, req, me))
+		mw.problemHandler.HandleProblem(newProblem(ProblemKindResponseBody, w, req, me))
 		return
 	}
 }
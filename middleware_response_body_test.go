@@ -25,6 +25,12 @@ func TestResponseBodyValidator(t *testing.T) {
 			expectedBody:      `{"id":123,"name":"Kitty"}`,
 			expectedLogBuffer: "problem handler: response body does not match the schema: field=age value=<nil> message=age in body is required",
 		},
+		"logs validation error when write-only field is present": {
+			url:               "/v2/pet/microchip",
+			expectedStatus:    http.StatusOK,
+			expectedBody:      `{"id":123,"name":"Kitty","age":3,"microchip":"abc"}`,
+			expectedLogBuffer: "problem handler: response body does not match the schema: field=microchip value=abc message=microchip in body is write-only",
+		},
 		"no logs when no response spec defined": {
 			url:               "/v2/pet/500",
 			expectedStatus:    http.StatusInternalServerError,
@@ -35,7 +41,7 @@ func TestResponseBodyValidator(t *testing.T) {
 			url:               "/v2/pet/404",
 			expectedStatus:    http.StatusNotFound,
 			expectedBody:      `{"error":"not found"}`,
-			expectedLogBuffer: "problem handler: response has non-emtpy body, but the operation does not define response schema for code 404",
+			expectedLogBuffer: "problem handler: response has non-empty body, but the operation does not define response schema for code 404",
 		},
 		"logs validation error when response body is bad json": {
 			url:               "/v2/pet/badjson",
@@ -48,6 +54,7 @@ func TestResponseBodyValidator(t *testing.T) {
 	doc := loadDocFile(t, "testdata/petstore_1.yml")
 	_, _, op, ok := doc.Analyzer.OperationForName("getPetById")
 	assert.True(t, ok)
+	oi := newOperationInfo(doc, op, nil)
 
 	logBuffer := &bytes.Buffer{}
 
@@ -64,7 +71,7 @@ func TestResponseBodyValidator(t *testing.T) {
 
 			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
 			w := httptest.NewRecorder()
-			v.ServeHTTP(w, req, op.Responses, true)
+			v.ServeHTTP(w, req, op.Responses, oi.responseValidators, true)
 
 			assert.Equal(t, tc.expectedStatus, w.Code)
 			assert.Equal(t, tc.expectedBody, strings.TrimSpace(w.Body.String()))
@@ -98,6 +105,12 @@ func handleGetPetByIDFaked(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// fake for leaked write-only field
+	if req.URL.Path == "/v2/pet/microchip" {
+		w.Write([]byte(`{"id":123,"name":"Kitty","age":3,"microchip":"abc"}`))
+		return
+	}
+
 	// normal
 
 	type pet struct {
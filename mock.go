@@ -0,0 +1,274 @@
+package oas
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/internal/mediatype"
+	"github.com/hypnoglow/oas2/internal/operations"
+)
+
+// DefaultMockStatusHeader is the default request header to pick the status
+// code of the mock response.
+const DefaultMockStatusHeader = "X-Mock-Status"
+
+// MockOptions represent options for mock handler.
+type MockOptions struct {
+	adapter      string
+	router       http.Handler
+	statusHeader string
+	middleware   []MiddlewareOption
+}
+
+// MockOption is option to use when creating mock handler.
+type MockOption func(*MockOptions)
+
+// MockRouter returns option that makes the mock handler route requests using
+// the adapter registered by the name, e.g. "chi", and the router of the type
+// the adapter expects, e.g. chi.Router. By default, the mock handler matches
+// request paths against spec path templates by itself.
+func MockRouter(adapter string, router http.Handler) MockOption {
+	return func(o *MockOptions) {
+		o.adapter = adapter
+		o.router = router
+	}
+}
+
+// MockStatusHeader returns option that sets the request header to pick
+// the status code of the mock response. It is DefaultMockStatusHeader
+// by default.
+func MockStatusHeader(name string) MockOption {
+	return func(o *MockOptions) {
+		o.statusHeader = name
+	}
+}
+
+// MockMiddlewareOptions returns option that sets options of the request
+// validators used by the mock handler, e.g. the problem handler.
+func MockMiddlewareOptions(opts ...MiddlewareOption) MockOption {
+	return func(o *MockOptions) {
+		o.middleware = append(o.middleware, opts...)
+	}
+}
+
+// NewMockHandler returns a handler that serves every operation of the spec
+// with mock responses, so clients can be developed before the API is
+// implemented.
+//
+// Requests are validated by the request validators, as the real API would do.
+// Valid requests are responded with the response examples from the spec for
+// the negotiated media type, or with data synthesized from the response
+// schema. Synthesized data respects formats, enums and limits of the schema,
+// but not patterns.
+//
+// The status code is the lowest 2xx code defined for the operation, unless
+// the client picks another one defined for the operation with the status
+// header, e.g. "X-Mock-Status: 404". The "default" response is used for
+// codes not defined explicitly.
+func NewMockHandler(doc *Document, opts ...MockOption) http.Handler {
+	options := MockOptions{statusHeader: DefaultMockStatusHeader}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	b := &ResolvingBasis{
+		adapter: templateAdapter{},
+		doc:     doc,
+		strict:  true,
+	}
+	var router http.Handler = &templateRouter{}
+	if options.adapter != "" {
+		b.adapter = mustGetAdapter(options.adapter)
+		router = options.router
+	}
+	b.initCache()
+
+	mock := &mockHandler{doc: doc, statusHeader: options.statusHeader}
+	handlers := make(map[string]http.Handler)
+	for id := range b.cache {
+		handlers[id] = mock
+	}
+
+	err := b.OperationRouter(router).
+		WithOperationHandlers(handlers).
+		WithMiddleware(
+			b.PathParamsContext(),
+			b.QueryValidator(options.middleware...),
+			b.RequestContentTypeValidator(options.middleware...),
+			b.RequestBodyValidator(options.middleware...),
+		).
+		Build()
+	if err != nil {
+		// Build fails only if the document or handlers are missing.
+		panic(fmt.Sprintf("oas: cannot build mock handler routes: %v", err))
+	}
+
+	return router
+}
+
+// mockHandler responds with mock response of the operation found in the
+// request context.
+type mockHandler struct {
+	doc          *Document
+	statusHeader string
+}
+
+func (h *mockHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi := mustOperationInfo(req)
+
+	code, resp, err := h.pickResponse(req, oi.operation)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for name, header := range resp.Headers {
+		w.Header().Set(name, mockHeaderValue(header))
+	}
+
+	if resp.Schema == nil && len(resp.Examples) == 0 {
+		w.WriteHeader(code)
+		return
+	}
+
+	produces := operations.Produces(h.doc.Spec(), oi.operation)
+	mediaType := negotiateMediaType(req.Header.Get("Accept"), produces)
+	body, mediaType, err := mockBody(resp, mediaType, produces)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if body != nil {
+		w.Header().Set("Content-Type", mediaType)
+	}
+	w.WriteHeader(code)
+	w.Write(body) // nolint
+}
+
+// pickResponse returns the status code and the response to mock.
+func (h *mockHandler) pickResponse(req *http.Request, op *spec.Operation) (int, spec.Response, error) {
+	if op.Responses == nil {
+		return http.StatusOK, spec.Response{}, nil
+	}
+	resps := op.Responses.StatusCodeResponses
+
+	if v := req.Header.Get(h.statusHeader); v != "" {
+		code, err := strconv.Atoi(v)
+		if err != nil || code < 100 || code > 599 {
+			return 0, spec.Response{}, fmt.Errorf("invalid %s header value %q", h.statusHeader, v)
+		}
+		if resp, ok := resps[code]; ok {
+			return code, resp, nil
+		}
+		if op.Responses.Default != nil {
+			return code, *op.Responses.Default, nil
+		}
+		return 0, spec.Response{}, fmt.Errorf("response %d is not defined for operation %q", code, op.ID)
+	}
+
+	codes := make([]int, 0, len(resps))
+	for code := range resps {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	for _, code := range codes {
+		if code >= 200 && code < 300 {
+			return code, resps[code], nil
+		}
+	}
+	if op.Responses.Default != nil {
+		return http.StatusOK, *op.Responses.Default, nil
+	}
+	if len(codes) > 0 {
+		return codes[0], resps[codes[0]], nil
+	}
+	return http.StatusOK, spec.Response{}, nil
+}
+
+// negotiateMediaType returns the produced media type most preferred by
+// the Accept header, or the first one if none is acceptable. Of media types
+// equally preferred, the first one is returned, so produces must be in the
+// spec order.
+func negotiateMediaType(accept string, produces []string) string {
+	if len(produces) == 0 {
+		return "application/json"
+	}
+
+	best, bestQ := produces[0], 0.0
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		q := quality(params)
+		if q <= bestQ {
+			continue
+		}
+		for _, p := range produces {
+			if mediatype.Match(p, []string{mediaType}) {
+				best, bestQ = p, q
+				break
+			}
+		}
+	}
+	return best
+}
+
+// mockBody returns the response body for the media type. Response examples
+// are preferred over data synthesized from the schema. Synthesized data is
+// encoded as JSON, so a JSON media type is chosen if the negotiated one has
+// no example.
+func mockBody(resp spec.Response, mediaType string, produces []string) ([]byte, string, error) {
+	if example, ok := resp.Examples[mediaType]; ok {
+		if s, ok := example.(string); ok && !mediatype.IsJSON(mediaType) {
+			return []byte(s), mediaType, nil
+		}
+		b, err := json.Marshal(example)
+		return b, mediaType, err
+	}
+
+	if !mediatype.IsJSON(mediaType) {
+		mediaType = mediatype.JSON(produces)
+		if example, ok := resp.Examples[mediaType]; ok {
+			b, err := json.Marshal(example)
+			return b, mediaType, err
+		}
+	}
+
+	if resp.Schema == nil {
+		return nil, mediaType, nil
+	}
+
+	b, err := json.Marshal(sampleSchema(resp.Schema, 0))
+	return b, mediaType, err
+}
+
+// mockHeaderValue returns value of the response header.
+func mockHeaderValue(h spec.Header) string {
+	var v interface{}
+	switch {
+	case h.Default != nil:
+		v = h.Default
+	case len(h.Enum) > 0:
+		v = h.Enum[0]
+	default:
+		v = sampleSimple(h.Type, h.Format, h.CommonValidations)
+	}
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,183 @@
+package oas
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// maxSampleDepth limits depth of synthesized data, as schemas may be
+// recursive.
+const maxSampleDepth = 16
+
+// sampleFormats are sample values of string formats.
+var sampleFormats = map[string]string{
+	"date":      "2018-08-08",
+	"date-time": "2018-08-08T12:00:00Z",
+	"uuid":      "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"email":     "user@example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"uri":       "https://example.com/",
+	"url":       "https://example.com/",
+	"byte":      "c3RyaW5n",
+	"password":  "password",
+}
+
+// sampleSchema synthesizes data valid against the response schema. The schema
+// example, default and enum values are preferred.
+func sampleSchema(sch *spec.Schema, depth int) interface{} {
+	if sch == nil || depth > maxSampleDepth {
+		return nil
+	}
+
+	switch {
+	case sch.Example != nil:
+		return sch.Example
+	case sch.Default != nil:
+		return sch.Default
+	case len(sch.Enum) > 0:
+		return sch.Enum[0]
+	}
+
+	if len(sch.AllOf) > 0 {
+		obj := make(map[string]interface{})
+		for i := range sch.AllOf {
+			if m, ok := sampleSchema(&sch.AllOf[i], depth+1).(map[string]interface{}); ok {
+				for k, v := range m {
+					obj[k] = v
+				}
+			}
+		}
+		for k, v := range sampleProperties(sch, depth) {
+			obj[k] = v
+		}
+		return obj
+	}
+
+	typ := ""
+	if len(sch.Type) > 0 {
+		typ = sch.Type[0]
+	} else if len(sch.Properties) > 0 {
+		typ = "object"
+	}
+
+	switch typ {
+	case "object":
+		return sampleProperties(sch, depth)
+	case "array":
+		n := int64(1)
+		if sch.MinItems != nil && *sch.MinItems > n {
+			n = *sch.MinItems
+		}
+		if sch.MaxItems != nil && *sch.MaxItems < n {
+			n = *sch.MaxItems
+		}
+		items := make([]interface{}, 0, n)
+		if sch.Items == nil || sch.Items.Schema == nil {
+			return items
+		}
+		for i := int64(0); i < n; i++ {
+			items = append(items, sampleSchema(sch.Items.Schema, depth+1))
+		}
+		return items
+	default:
+		return sampleSimple(typ, sch.Format, spec.CommonValidations{
+			Maximum:          sch.Maximum,
+			ExclusiveMaximum: sch.ExclusiveMaximum,
+			Minimum:          sch.Minimum,
+			ExclusiveMinimum: sch.ExclusiveMinimum,
+			MaxLength:        sch.MaxLength,
+			MinLength:        sch.MinLength,
+			MultipleOf:       sch.MultipleOf,
+		})
+	}
+}
+
+func sampleProperties(sch *spec.Schema, depth int) map[string]interface{} {
+	names := make([]string, 0, len(sch.Properties))
+	for name := range sch.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	obj := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		prop := sch.Properties[name]
+		if validate.IsWriteOnly(&prop) {
+			continue
+		}
+		if v := sampleSchema(&prop, depth+1); v != nil {
+			obj[name] = v
+		}
+	}
+	return obj
+}
+
+// sampleSimple synthesizes a primitive value of the type and format within
+// the limits.
+func sampleSimple(typ, format string, v spec.CommonValidations) interface{} {
+	switch typ {
+	case "integer":
+		return int64(sampleNumber(v, 1))
+	case "number":
+		return sampleNumber(v, 0)
+	case "boolean":
+		return true
+	case "string":
+		if s, ok := sampleFormats[format]; ok {
+			return s
+		}
+		s := "string"
+		if v.MinLength != nil && int64(len(s)) < *v.MinLength {
+			s += strings.Repeat("s", int(*v.MinLength)-len(s))
+		}
+		if v.MaxLength != nil && int64(len(s)) > *v.MaxLength {
+			s = s[:*v.MaxLength]
+		}
+		return s
+	default:
+		return nil
+	}
+}
+
+// sampleNumber returns number within the limits, closest to zero. Step is
+// the distance to exclusive limits, which is 1 for integers.
+func sampleNumber(v spec.CommonValidations, step float64) float64 {
+	if step == 0 {
+		step = 0.5
+	}
+
+	n := 0.0
+	if v.Minimum != nil && n <= *v.Minimum {
+		n = *v.Minimum
+		if v.ExclusiveMinimum {
+			n += step
+		}
+	}
+	if v.Maximum != nil && n >= *v.Maximum {
+		n = *v.Maximum
+		if v.ExclusiveMaximum {
+			n -= step
+		}
+	}
+
+	if v.MultipleOf != nil && *v.MultipleOf > 0 {
+		m := *v.MultipleOf
+		rounded := math.Ceil(n/m) * m
+		if v.Maximum != nil && (rounded > *v.Maximum || (v.ExclusiveMaximum && rounded == *v.Maximum)) {
+			rounded = math.Floor(n/m) * m
+		}
+		n = rounded
+	}
+
+	if step == 1 {
+		n = math.Ceil(n)
+	}
+	return n
+}
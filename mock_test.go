@@ -0,0 +1,203 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMockHandler(t *testing.T) {
+	doc := loadDocFile(t, "testdata/mock.yml")
+	h := NewMockHandler(doc)
+
+	pet := `{"bornAt":"2018-08-08T12:00:00Z","id":1,"name":"stringssss","price":0.5,"status":"available","tag":"cute"}`
+
+	testCases := map[string]struct {
+		method  string
+		path    string
+		headers map[string]string
+		body    string
+
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+		expectedHeaders     map[string]string
+	}{
+		"synthesized from schema": {
+			method:              http.MethodGet,
+			path:                "/api/pets/12",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        pet,
+		},
+		"array and headers": {
+			method:              http.MethodGet,
+			path:                "/api/pets?limit=10",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        "[" + pet + "," + pet + "]",
+			expectedHeaders:     map[string]string{"X-Total-Count": "1"},
+		},
+		"example for negotiated media type": {
+			method:              http.MethodGet,
+			path:                "/api/pets",
+			headers:             map[string]string{"Accept": "application/xml"},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/xml",
+			expectedBody:        "<pets><pet>Rex</pet></pets>",
+		},
+		"literal path before template": {
+			method:              http.MethodGet,
+			path:                "/api/pets/mine",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        "[]",
+		},
+		"example": {
+			method:              http.MethodPost,
+			path:                "/api/pets",
+			headers:             map[string]string{"Content-Type": "application/json"},
+			body:                `{"name":"Rex the dog"}`,
+			expectedStatus:      http.StatusCreated,
+			expectedContentType: "application/json",
+			expectedBody:        `{"id":1,"name":"Rex"}`,
+		},
+		"status picked by header": {
+			method:              http.MethodGet,
+			path:                "/api/pets/12",
+			headers:             map[string]string{"X-Mock-Status": "404"},
+			expectedStatus:      http.StatusNotFound,
+			expectedContentType: "application/json",
+			expectedBody:        `{"message":"string"}`,
+		},
+		"default response for undefined status": {
+			method:              http.MethodPost,
+			path:                "/api/pets",
+			headers:             map[string]string{"Content-Type": "application/json", "X-Mock-Status": "409"},
+			body:                `{"name":"Rex the dog"}`,
+			expectedStatus:      http.StatusConflict,
+			expectedContentType: "application/json",
+			expectedBody:        `{"message":"string"}`,
+		},
+		"undefined status": {
+			method:         http.MethodGet,
+			path:           "/api/pets/12",
+			headers:        map[string]string{"X-Mock-Status": "500"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		"no content": {
+			method:         http.MethodDelete,
+			path:           "/api/pets/12",
+			expectedStatus: http.StatusNoContent,
+		},
+		"invalid query": {
+			method:         http.MethodGet,
+			path:           "/api/pets?limit=1000",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"invalid body": {
+			method:         http.MethodPost,
+			path:           "/api/pets",
+			headers:        map[string]string{"Content-Type": "application/json"},
+			body:           `{"id":1}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		"method not allowed": {
+			method:          http.MethodPut,
+			path:            "/api/pets/12",
+			expectedStatus:  http.StatusMethodNotAllowed,
+			expectedHeaders: map[string]string{"Allow": "DELETE, GET"},
+		},
+		"not found": {
+			method:         http.MethodGet,
+			path:           "/api/owners",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+
+			h.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedStatus, rr.Code)
+			for k, v := range tc.expectedHeaders {
+				assert.Equal(t, v, rr.Header().Get(k))
+			}
+			if tc.expectedContentType != "" {
+				assert.Equal(t, tc.expectedContentType, rr.Header().Get("Content-Type"))
+			}
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewMockHandler_produces(t *testing.T) {
+	doc := loadDocFile(t, "testdata/mock_produces.yml")
+	h := NewMockHandler(doc)
+
+	testCases := map[string]struct {
+		accept string
+
+		expectedContentType string
+		expectedBody        string
+	}{
+		"first declared media type without Accept": {
+			expectedContentType: "text/plain",
+			expectedBody:        "Rex",
+		},
+		"requested media type": {
+			accept:              "text/html",
+			expectedContentType: "text/html",
+			expectedBody:        "<b>Rex</b>",
+		},
+		"requested json": {
+			accept:              "application/json",
+			expectedContentType: "application/json",
+			expectedBody:        `{"name":"Rex"}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Media types are chosen in the spec order, so the choice
+			// must not vary between requests.
+			for i := 0; i < 20; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/api/pets/12/card", nil)
+				if tc.accept != "" {
+					req.Header.Set("Accept", tc.accept)
+				}
+				rr := httptest.NewRecorder()
+
+				h.ServeHTTP(rr, req)
+
+				assert.Equal(t, http.StatusOK, rr.Code)
+				assert.Equal(t, tc.expectedContentType, rr.Header().Get("Content-Type"))
+				assert.Equal(t, tc.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestSampleNumber(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+
+	assert.Equal(t, 0.0, sampleNumber(spec.CommonValidations{}, 0))
+	assert.Equal(t, 5.0, sampleNumber(spec.CommonValidations{Minimum: f(5)}, 1))
+	assert.Equal(t, 6.0, sampleNumber(spec.CommonValidations{Minimum: f(5), ExclusiveMinimum: true}, 1))
+	assert.Equal(t, -3.0, sampleNumber(spec.CommonValidations{Maximum: f(-3)}, 1))
+	assert.Equal(t, -4.0, sampleNumber(spec.CommonValidations{Maximum: f(-3), ExclusiveMaximum: true}, 1))
+	assert.Equal(t, 1.5, sampleNumber(spec.CommonValidations{Minimum: f(1.2), MultipleOf: f(0.5)}, 0))
+	assert.Equal(t, 10.0, sampleNumber(spec.CommonValidations{Minimum: f(7), Maximum: f(12), MultipleOf: f(5)}, 1))
+}
@@ -0,0 +1,408 @@
+// Package oastest provides contract testing of HTTP handlers against
+// OpenAPI specification.
+//
+// Tester serves requests with the handler, as httptest does, and checks that
+// requests are valid for the operations they resolve to, and that responses
+// match the spec: status code, headers, content type and body. Mismatches
+// fail the test with readable reports.
+//
+//  func TestGetPet(t *testing.T) {
+//      req := httptest.NewRequest(http.MethodGet, "/api/pets/12", nil)
+//      resp := oastest.New(t, doc, handler).Do(req)
+//      // Check that the response has expected values.
+//  }
+package oastest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2"
+	"github.com/hypnoglow/oas2/convert"
+	"github.com/hypnoglow/oas2/internal/mediatype"
+	"github.com/hypnoglow/oas2/internal/operations"
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// TB is the part of testing.TB used by Tester.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// Tester serves requests with the handler and checks them and their
+// responses against the spec.
+type Tester struct {
+	t        TB
+	doc      *oas.Document
+	handler  http.Handler
+	resolver *oas.PathResolver
+	subtypes *validate.Subtypes
+}
+
+// New returns a new Tester for the handler. The handler is usually the
+// router of the whole API, but may be the handler of a single operation.
+// Requests are resolved to operations by their method and path, which must
+// include the base path of the spec.
+func New(t TB, doc *oas.Document, handler http.Handler) *Tester {
+	return &Tester{
+		t:        t,
+		doc:      doc,
+		handler:  handler,
+		resolver: oas.NewPathResolver(doc),
+		subtypes: validate.NewSubtypes(doc.Spec().Definitions, doc.OrigSpec().Definitions),
+	}
+}
+
+// Do serves the request with the handler and returns the response.
+//
+// The test fails if the request is invalid for the operation, or if the
+// response does not match the spec. The test fails immediately if the
+// request does not resolve to any operation of the spec.
+func (tt *Tester) Do(req *http.Request) *http.Response {
+	tt.t.Helper()
+	return tt.do(req, true)
+}
+
+// DoInvalid is like Do, but the request is expected to be invalid, e.g. to
+// test that the handler rejects it. The test fails if the request is valid
+// for the operation.
+func (tt *Tester) DoInvalid(req *http.Request) *http.Response {
+	tt.t.Helper()
+	return tt.do(req, false)
+}
+
+func (tt *Tester) do(req *http.Request, valid bool) *http.Response {
+	tt.t.Helper()
+
+	id, pathParams, ok := tt.resolver.ResolvePath(req.Method, req.URL.Path)
+	if !ok {
+		tt.t.Fatalf("oastest: request %s %s does not match any operation of the spec", req.Method, req.URL.Path)
+		return nil
+	}
+	method, path, op, _ := tt.doc.Analyzer.OperationForName(id)
+	o := operations.New(tt.doc.Spec(), method, path, op)
+	what := fmt.Sprintf("%s %s (operation %s)", req.Method, req.URL.Path, id)
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		if err != nil {
+			tt.t.Fatalf("oastest: cannot read request body: %s", err)
+			return nil
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	problems := tt.checkRequest(o, req, pathParams, body)
+	switch {
+	case valid && len(problems) > 0:
+		tt.t.Errorf("oastest: request %s does not match the spec:\n%s", what, problems)
+	case !valid && len(problems) == 0:
+		tt.t.Errorf("oastest: request %s matches the spec, but is expected to be invalid", what)
+	}
+
+	rec := httptest.NewRecorder()
+	tt.handler.ServeHTTP(rec, req)
+
+	if problems := tt.checkResponse(o, rec); len(problems) > 0 {
+		tt.t.Errorf("oastest: response %d to %s does not match the spec:\n%s\nResponse body:\n%s",
+			rec.Code, what, problems, indentBody(rec.Body.Bytes()))
+	}
+
+	return rec.Result()
+}
+
+func (tt *Tester) checkRequest(op operations.Operation, req *http.Request, pathParams map[string]string, body []byte) problems {
+	var ps problems
+
+	for _, err := range validate.Path(op.Params, pathParams) {
+		ps.add(err)
+	}
+	for _, err := range validate.Header(op.Params, req.Header) {
+		ps.add(err)
+	}
+	for _, err := range validate.Query(op.Params, req.URL.Query()) {
+		ps.add(err)
+	}
+
+	if hasFormData(op.Params) {
+		return append(ps, checkForm(op, req, body)...)
+	}
+
+	bodyParam, ok := findBodyParam(op.Params)
+	if !ok {
+		return ps
+	}
+
+	if len(body) == 0 {
+		if bodyParam.Required {
+			ps.addf("body is required")
+		}
+		return ps
+	}
+
+	contentType := req.Header.Get("Content-Type")
+	if !checkConsumes(&ps, op, contentType) || !mediatype.IsJSON(contentType) {
+		return ps
+	}
+
+	data, err := decodeJSON(body)
+	if err != nil {
+		ps.addf("body is not valid JSON: %s", err)
+		return ps
+	}
+	v := validate.NewBodyValidator(op.Params, validate.WithSubtypes(tt.subtypes))
+	for _, err := range v.Validate(data) {
+		ps.add(err)
+	}
+	return ps
+}
+
+// checkForm checks formData parameters of the request, which is either
+// a url-encoded or a multipart form.
+func checkForm(op operations.Operation, req *http.Request, body []byte) problems {
+	var ps problems
+
+	contentType := req.Header.Get("Content-Type")
+	if len(body) > 0 && !checkConsumes(&ps, op, contentType) {
+		return ps
+	}
+
+	// Parse the form from a copy of the request, so that the handler still
+	// reads the original body.
+	r := &http.Request{
+		Method: req.Method,
+		Header: req.Header,
+		Body:   ioutil.NopCloser(bytes.NewReader(body)),
+	}
+	var (
+		form  url.Values
+		files map[string][]*multipart.FileHeader
+	)
+	switch mediatype.Base(contentType) {
+	case "multipart/form-data":
+		if err := r.ParseMultipartForm(maxFormMemory); err != nil {
+			ps.addf("body is not a valid multipart form: %s", err)
+			return ps
+		}
+		defer r.MultipartForm.RemoveAll()
+		form, files = r.MultipartForm.Value, r.MultipartForm.File
+	default:
+		if len(body) > 0 {
+			var err error
+			if form, err = url.ParseQuery(string(body)); err != nil {
+				ps.addf("body is not a valid form: %s", err)
+				return ps
+			}
+		}
+	}
+
+	for _, err := range validate.FormData(op.Params, form) {
+		ps.add(err)
+	}
+	for _, p := range op.Params {
+		if p.In == "formData" && p.Type == "file" && p.Required && len(files[p.Name]) == 0 {
+			ps.addf("file %s is required", p.Name)
+		}
+	}
+	return ps
+}
+
+// checkConsumes checks that the operation consumes the content type, and
+// adds a problem otherwise.
+func checkConsumes(ps *problems, op operations.Operation, contentType string) bool {
+	if contentType == "" && len(op.Consumes) > 0 {
+		ps.addf("content type is missing, expected one of: %s", strings.Join(op.Consumes, ", "))
+		return false
+	}
+	if !mediatype.Match(contentType, op.Consumes) {
+		ps.addf("content type %q is not consumed, expected one of: %s", contentType, strings.Join(op.Consumes, ", "))
+		return false
+	}
+	return true
+}
+
+func (tt *Tester) checkResponse(op operations.Operation, rec *httptest.ResponseRecorder) problems {
+	var ps problems
+
+	resp, ok := findResponse(op.Responses, rec.Code)
+	if !ok {
+		ps.addf("status %d is not defined, expected one of: %s", rec.Code, strings.Join(statusCodes(op.Responses), ", "))
+		return ps
+	}
+
+	names := make([]string, 0, len(resp.Headers))
+	for name := range resp.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h := resp.Headers[name]
+		v := rec.Header().Get(name)
+		if v == "" {
+			ps.addf("header %s is missing", name)
+			continue
+		}
+		if h.Type == "array" {
+			continue
+		}
+		if _, err := convert.Primitive(v, h.Type, h.Format); err != nil {
+			ps.addf("header %s: %s", name, err)
+		}
+	}
+
+	body := rec.Body.Bytes()
+	if resp.Schema == nil {
+		if len(body) > 0 {
+			ps.addf("body is not empty, but no schema is defined for status %d", rec.Code)
+		}
+		return ps
+	}
+	if len(body) == 0 {
+		ps.addf("body is empty, but schema is defined for status %d", rec.Code)
+		return ps
+	}
+
+	contentType := rec.Header().Get("Content-Type")
+	if contentType == "" && len(op.Produces) > 0 {
+		ps.addf("content type is missing, expected one of: %s", strings.Join(op.Produces, ", "))
+		return ps
+	}
+	if !mediatype.Match(contentType, op.Produces) {
+		ps.addf("content type %q is not produced, expected one of: %s", contentType, strings.Join(op.Produces, ", "))
+		return ps
+	}
+	if !mediatype.IsJSON(contentType) {
+		return ps
+	}
+
+	data, err := decodeJSON(body)
+	if err != nil {
+		ps.addf("body is not valid JSON: %s", err)
+		return ps
+	}
+	v := validate.NewResponseValidator(resp.Schema, validate.WithSubtypes(tt.subtypes))
+	for _, err := range v.Validate(data) {
+		ps.add(err)
+	}
+	return ps
+}
+
+// problems is a list of mismatches between the spec and the request or
+// the response.
+type problems []string
+
+func (ps *problems) add(err error) {
+	if de, ok := err.(validate.DetailedValidationError); ok && de.Pointer() != "" {
+		*ps = append(*ps, fmt.Sprintf("%s: %s", de.Pointer(), de.Error()))
+		return
+	}
+	*ps = append(*ps, err.Error())
+}
+
+func (ps *problems) addf(format string, args ...interface{}) {
+	*ps = append(*ps, fmt.Sprintf(format, args...))
+}
+
+func (ps problems) String() string {
+	var buf bytes.Buffer
+	for _, p := range ps {
+		fmt.Fprintf(&buf, "  - %s\n", p)
+	}
+	return buf.String()
+}
+
+// maxFormMemory is the maximum memory used to parse multipart forms,
+// the same as net/http uses by default.
+const maxFormMemory = 32 << 20
+
+// decodeJSON decodes the body keeping numbers as json.Number, as the
+// middlewares do, so that integers beyond 2^53 are validated exactly.
+func decodeJSON(body []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var data interface{}
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func hasFormData(params []spec.Parameter) bool {
+	for _, p := range params {
+		if p.In == "formData" {
+			return true
+		}
+	}
+	return false
+}
+
+func findBodyParam(params []spec.Parameter) (spec.Parameter, bool) {
+	for _, p := range params {
+		if p.In == "body" {
+			return p, true
+		}
+	}
+	return spec.Parameter{}, false
+}
+
+// findResponse returns the response defined for the status code, or the
+// default response.
+func findResponse(responses *spec.Responses, code int) (spec.Response, bool) {
+	if responses == nil {
+		return spec.Response{}, false
+	}
+	if resp, ok := responses.StatusCodeResponses[code]; ok {
+		return resp, true
+	}
+	if responses.Default != nil {
+		return *responses.Default, true
+	}
+	return spec.Response{}, false
+}
+
+func statusCodes(responses *spec.Responses) []string {
+	if responses == nil {
+		return nil
+	}
+
+	codes := make([]int, 0, len(responses.StatusCodeResponses))
+	for code := range responses.StatusCodeResponses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	ss := make([]string, len(codes))
+	for i, code := range codes {
+		ss[i] = fmt.Sprint(code)
+	}
+	return ss
+}
+
+// indentBody returns the body indented for the report, pretty-printed if it
+// is JSON.
+func indentBody(body []byte) string {
+	if len(body) == 0 {
+		return "    <empty>"
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, body, "    ", "  "); err == nil {
+		return "    " + buf.String()
+	}
+	return "    " + strings.Replace(strings.TrimRight(string(body), "\n"), "\n", "\n    ", -1)
+}
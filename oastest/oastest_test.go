@@ -0,0 +1,253 @@
+package oastest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hypnoglow/oas2"
+)
+
+func TestTester(t *testing.T) {
+	doc, err := oas.LoadFile("testdata/petstore.yml")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	respond := func(code int, header http.Header, body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(code)
+			fmt.Fprint(w, body)
+		})
+	}
+	jsonHeader := http.Header{"Content-Type": {"application/json"}}
+
+	cases := map[string]struct {
+		invalid  bool
+		method   string
+		target   string
+		body     string
+		header   http.Header
+		handler  http.Handler
+		errors   []string
+		fatal    string
+		expected int
+	}{
+		"valid": {
+			method:   http.MethodGet,
+			target:   "/api/pets?limit=10",
+			handler:  respond(200, http.Header{"Content-Type": {"application/json"}, "X-Total-Count": {"1"}}, `[{"name":"Rex"}]`),
+			expected: 200,
+		},
+		"valid with body": {
+			method:   http.MethodPost,
+			target:   "/api/pets",
+			body:     `{"name":"Rex"}`,
+			header:   jsonHeader,
+			handler:  respond(201, jsonHeader, `{"name":"Rex"}`),
+			expected: 201,
+		},
+		"default response": {
+			method:   http.MethodPost,
+			target:   "/api/pets",
+			body:     `{"name":"Rex"}`,
+			header:   jsonHeader,
+			handler:  respond(409, jsonHeader, `{"message":"conflict"}`),
+			expected: 409,
+		},
+		"unknown operation": {
+			method:  http.MethodGet,
+			target:  "/api/owners",
+			handler: respond(200, nil, ""),
+			fatal:   "oastest: request GET /api/owners does not match any operation of the spec",
+		},
+		"invalid request": {
+			method:  http.MethodGet,
+			target:  "/api/pets?limit=1000&sort=name",
+			handler: respond(200, http.Header{"Content-Type": {"application/json"}, "X-Total-Count": {"0"}}, `[]`),
+			errors: []string{
+				"oastest: request GET /api/pets (operation listPets) does not match the spec:\n" +
+					"  - limit in query should be less than or equal to 100\n" +
+					"  - parameter sort is unknown\n",
+			},
+			expected: 200,
+		},
+		"invalid request body": {
+			method:  http.MethodPost,
+			target:  "/api/pets",
+			body:    `{}`,
+			header:  jsonHeader,
+			handler: respond(201, jsonHeader, `{"name":"Rex"}`),
+			errors: []string{
+				"oastest: request POST /api/pets (operation createPet) does not match the spec:\n" +
+					"  - /name: name in body is required\n",
+			},
+			expected: 201,
+		},
+		"invalid path parameter": {
+			method:  http.MethodDelete,
+			target:  "/api/pets/rex",
+			handler: respond(204, nil, ""),
+			errors: []string{
+				"oastest: request DELETE /api/pets/rex (operation deletePet) does not match the spec:\n" +
+					"  - param id: cannot convert rex to int64\n",
+			},
+			expected: 204,
+		},
+		"invalid path and header parameters": {
+			method:  http.MethodDelete,
+			target:  "/api/pets/0",
+			header:  http.Header{"X-Reason": {"bored"}},
+			handler: respond(204, nil, ""),
+			errors: []string{
+				"oastest: request DELETE /api/pets/0 (operation deletePet) does not match the spec:\n" +
+					"  - id in path should be greater than or equal to 1\n" +
+					"  - X-Reason in header should be one of [sold lost]\n",
+			},
+			expected: 204,
+		},
+		"form": {
+			method:   http.MethodPut,
+			target:   "/api/pets/12/name",
+			body:     "name=Rex",
+			header:   http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			handler:  respond(204, nil, ""),
+			expected: 204,
+		},
+		"invalid form": {
+			method:  http.MethodPut,
+			target:  "/api/pets/12/name",
+			body:    "name=",
+			header:  http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			handler: respond(204, nil, ""),
+			errors: []string{
+				"oastest: request PUT /api/pets/12/name (operation renamePet) does not match the spec:\n" +
+					"  - name in formData must be of type string: \"null\"\n",
+			},
+			expected: 204,
+		},
+		"form with wrong content type": {
+			method:  http.MethodPut,
+			target:  "/api/pets/12/name",
+			body:    `{"name":"Rex"}`,
+			header:  jsonHeader,
+			handler: respond(204, nil, ""),
+			errors: []string{
+				"oastest: request PUT /api/pets/12/name (operation renamePet) does not match the spec:\n" +
+					"  - content type \"application/json\" is not consumed, expected one of: application/x-www-form-urlencoded\n",
+			},
+			expected: 204,
+		},
+		"expected invalid request": {
+			invalid:  true,
+			method:   http.MethodDelete,
+			target:   "/api/pets/rex",
+			handler:  respond(400, nil, ""),
+			errors:   []string{"oastest: response 400 to DELETE /api/pets/rex (operation deletePet) does not match the spec:\n  - status 400 is not defined, expected one of: 204\n\nResponse body:\n    <empty>"},
+			expected: 400,
+		},
+		"unexpected valid request": {
+			invalid:  true,
+			method:   http.MethodDelete,
+			target:   "/api/pets/12",
+			handler:  respond(204, nil, ""),
+			errors:   []string{"oastest: request DELETE /api/pets/12 (operation deletePet) matches the spec, but is expected to be invalid"},
+			expected: 204,
+		},
+		"invalid response": {
+			method:  http.MethodGet,
+			target:  "/api/pets",
+			handler: respond(200, http.Header{"Content-Type": {"text/plain"}}, `[{"name":1}]`),
+			errors: []string{
+				"oastest: response 200 to GET /api/pets (operation listPets) does not match the spec:\n" +
+					"  - header X-Total-Count is missing\n" +
+					"  - content type \"text/plain\" is not produced, expected one of: application/json\n" +
+					"\nResponse body:\n" +
+					"    [\n" +
+					"      {\n" +
+					"        \"name\": 1\n" +
+					"      }\n" +
+					"    ]",
+			},
+			expected: 200,
+		},
+		"invalid response body": {
+			method:  http.MethodGet,
+			target:  "/api/pets",
+			handler: respond(200, http.Header{"Content-Type": {"application/json"}, "X-Total-Count": {"many"}}, `[{"name":1}]`),
+			errors: []string{
+				"oastest: response 200 to GET /api/pets (operation listPets) does not match the spec:\n" +
+					"  - header X-Total-Count: cannot convert many to int64\n" +
+					"  - /0/name: 0.name in body must be of type string: \"number\"\n" +
+					"\nResponse body:\n" +
+					"    [\n" +
+					"      {\n" +
+					"        \"name\": 1\n" +
+					"      }\n" +
+					"    ]",
+			},
+			expected: 200,
+		},
+		"unexpected body": {
+			method:  http.MethodDelete,
+			target:  "/api/pets/12",
+			handler: respond(204, nil, "deleted"),
+			errors: []string{
+				"oastest: response 204 to DELETE /api/pets/12 (operation deletePet) does not match the spec:\n" +
+					"  - body is not empty, but no schema is defined for status 204\n" +
+					"\nResponse body:\n" +
+					"    deleted",
+			},
+			expected: 204,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(c.method, c.target, strings.NewReader(c.body))
+			for k, v := range c.header {
+				req.Header[k] = v
+			}
+
+			ft := &fakeT{}
+			tester := New(ft, doc, c.handler)
+
+			var resp *http.Response
+			if c.invalid {
+				resp = tester.DoInvalid(req)
+			} else {
+				resp = tester.Do(req)
+			}
+
+			assert.Equal(t, c.errors, ft.errors)
+			assert.Equal(t, c.fatal, ft.fatal)
+			if c.fatal != "" {
+				assert.Nil(t, resp)
+				return
+			}
+			assert.Equal(t, c.expected, resp.StatusCode)
+		})
+	}
+}
+
+type fakeT struct {
+	errors []string
+	fatal  string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.fatal = fmt.Sprintf(format, args...)
+}
@@ -5,6 +5,8 @@ import (
 	"net/http"
 
 	"github.com/go-openapi/spec"
+
+	"github.com/hypnoglow/oas2/validate"
 )
 
 type operationInfo struct {
@@ -21,6 +23,41 @@ type operationInfo struct {
 	// produces is either operation-defined "produces" property or spec-wide
 	// "produces" property.
 	produces []string
+
+	// bodyValidator is a validator for the body parameter schema.
+	// It is nil if the operation does not define a body parameter.
+	bodyValidator *validate.SchemaValidator
+
+	// responseValidators are validators for the response schemas
+	// mapped by status code. Responses without schema are not present.
+	responseValidators map[int]*validate.SchemaValidator
+}
+
+// newOperationInfo returns operation info for the operation with all
+// validators built. Subtypes are used to validate polymorphic bodies,
+// and may be nil.
+func newOperationInfo(doc *Document, operation *spec.Operation, subtypes *validate.Subtypes) operationInfo {
+	params := doc.Analyzer.ParametersFor(operation.ID)
+
+	oi := operationInfo{
+		operation:     operation,
+		params:        params,
+		consumes:      doc.Analyzer.ConsumesFor(operation),
+		produces:      doc.Analyzer.ProducesFor(operation),
+		bodyValidator: validate.NewBodyValidator(params, validate.WithSubtypes(subtypes)),
+	}
+
+	if operation.Responses != nil {
+		oi.responseValidators = make(map[int]*validate.SchemaValidator)
+		for code, resp := range operation.Responses.StatusCodeResponses {
+			if resp.Schema == nil {
+				continue
+			}
+			oi.responseValidators[code] = validate.NewResponseValidator(resp.Schema, validate.WithSubtypes(subtypes))
+		}
+	}
+
+	return oi
 }
 
 // operationContext is a middleware that adds operation info to the request
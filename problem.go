@@ -1,10 +1,22 @@
 package oas
 
 import (
-	"log"
 	"net/http"
 )
 
+// Problem kinds, see Problem.Kind.
+const (
+	ProblemKindQuery               = "query"
+	ProblemKindRequestBody         = "request body"
+	ProblemKindResponseContentType = "response content type"
+	ProblemKindResponseBody        = "response body"
+
+	// ProblemKindRequest and ProblemKindResponse are kinds of problems of
+	// outgoing requests and responses to them, see NewValidatingTransport.
+	ProblemKindRequest  = "request"
+	ProblemKindResponse = "response"
+)
+
 // NewProblem returns a new problem occurred while processing the request.
 func NewProblem(w http.ResponseWriter, req *http.Request, err error) Problem {
 	return Problem{
@@ -14,12 +26,21 @@ func NewProblem(w http.ResponseWriter, req *http.Request, err error) Problem {
 	}
 }
 
+// newProblem returns a new problem of the kind.
+func newProblem(kind string, w http.ResponseWriter, req *http.Request, err error) Problem {
+	p := NewProblem(w, req, err)
+	p.kind = kind
+	return p
+}
+
 // Problem describes a problem occurred while processing the request (or the response).
 // In most cases, the problem represents a validation error.
 type Problem struct {
-	w   http.ResponseWriter
-	req *http.Request
-	err error
+	kind string
+	w    http.ResponseWriter
+	req  *http.Request
+	resp *http.Response
+	err  error
 }
 
 // Cause returns the underlying error that represents the problem.
@@ -27,7 +48,14 @@ func (p Problem) Cause() error {
 	return p.err
 }
 
+// Kind returns the kind of the problem, i.e. what has been validated, e.g.
+// ProblemKindQuery. It is empty for problems created with NewProblem.
+func (p Problem) Kind() string {
+	return p.kind
+}
+
 // ResponseWriter retruns the ResponseWriter relative to the request.
+// It is nil for problems of outgoing requests, see NewValidatingTransport.
 func (p Problem) ResponseWriter() http.ResponseWriter {
 	return p.w
 }
@@ -37,6 +65,13 @@ func (p Problem) Request() *http.Request {
 	return p.req
 }
 
+// Response returns the response on which the problem occurred. It is set
+// only for problems of responses to outgoing requests, see
+// NewValidatingTransport.
+func (p Problem) Response() *http.Response {
+	return p.resp
+}
+
 // ProblemHandlerFunc is a function that handles problems occurred in a middleware
 // while processing a request or a response.
 //
@@ -55,6 +90,12 @@ func (f ProblemHandlerFunc) HandleProblem(problem Problem) {
 // middleware the error will describe query validation failure. Usually, the
 // handler should not wrap the error with a message like "query validation failure",
 // because the message will be already present in such error.
+//
+// Problems of outgoing requests and their responses, see
+// NewValidatingTransport, have no ResponseWriter: Problem.ResponseWriter
+// returns nil, and Problem.Response returns the response, if any. A handler
+// that may be used with the transport must check ResponseWriter for nil before
+// writing an error response.
 type ProblemHandler interface {
 	HandleProblem(problem Problem)
 }
@@ -68,11 +109,3 @@ func newProblemHandlerErrorResponder() ProblemHandlerFunc {
 		p.ResponseWriter().Write([]byte(p.err.Error())) // nolint
 	}
 }
-
-// newProblemHandlerWarnLogger is a very simple ProblemHandler that writes
-// problem error to the standard logger with a warning prefix.
-func newProblemHandlerWarnLogger(kind string) ProblemHandlerFunc {
-	return func(p Problem) {
-		log.Printf("[WARN] oas %s problem on \"%s %s\": %v", kind, p.Request().Method, p.Request().URL.String(), p.Cause())
-	}
-}
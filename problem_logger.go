@@ -0,0 +1,290 @@
+package oas
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// Logger writes structured log records. Arguments are alternating keys and
+// values, the same as for log/slog, so a method of *slog.Logger, e.g.
+// logger.Warn, can be used as Logger with LoggerFunc.
+type Logger interface {
+	Log(msg string, keyvals ...interface{})
+}
+
+// LoggerFunc is a function that implements Logger.
+type LoggerFunc func(msg string, keyvals ...interface{})
+
+// Log implements Logger.
+func (f LoggerFunc) Log(msg string, keyvals ...interface{}) {
+	f(msg, keyvals...)
+}
+
+// stdLogger writes records to the standard logger with a warning prefix.
+var stdLogger = LoggerFunc(func(msg string, keyvals ...interface{}) {
+	var buf bytes.Buffer
+	buf.WriteString("[WARN] ")
+	buf.WriteString(msg)
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if s, ok := keyvals[i+1].(string); ok {
+			fmt.Fprintf(&buf, " %v=%q", keyvals[i], s)
+			continue
+		}
+		fmt.Fprintf(&buf, " %v=%v", keyvals[i], keyvals[i+1])
+	}
+	log.Print(buf.String())
+})
+
+// ProblemFieldError is a validation error of the problem, as written to log
+// records by NewLoggingProblemHandler.
+type ProblemFieldError struct {
+	Message string      `json:"message"`
+	In      string      `json:"in,omitempty"`
+	Field   string      `json:"field,omitempty"`
+	Value   interface{} `json:"value,omitempty"`
+	Pointer string      `json:"pointer,omitempty"`
+	Keyword string      `json:"keyword,omitempty"`
+}
+
+// String returns the error message.
+func (e ProblemFieldError) String() string {
+	return e.Message
+}
+
+// LogOptions represent options for logging problem handler.
+type LogOptions struct {
+	dedupWindow time.Duration
+
+	rateLimit    int
+	rateInterval time.Duration
+}
+
+// LogOption is option to use when creating logging problem handler.
+type LogOption func(*LogOptions)
+
+// LogDeduplication returns option that makes the logging problem handler
+// write identical problems, i.e. problems of the same kind and operation
+// with the same error, at most once per the window. The number of suppressed
+// problems is written with the next record of the problem as "repeated".
+func LogDeduplication(window time.Duration) LogOption {
+	return func(o *LogOptions) {
+		o.dedupWindow = window
+	}
+}
+
+// LogRateLimit returns option that makes the logging problem handler write
+// at most n records per the interval. The number of dropped records is
+// written with the next record as "dropped".
+func LogRateLimit(n int, interval time.Duration) LogOption {
+	return func(o *LogOptions) {
+		o.rateLimit = n
+		o.rateInterval = interval
+	}
+}
+
+// NewLoggingProblemHandler returns a problem handler that writes problems to
+// the logger as records with message "oas problem" and keys:
+//
+//  - "kind": the problem kind, see Problem.Kind;
+//  - "method": the request method;
+//  - "path": the request path;
+//  - "operation_id": the operation id, if the request has operation context;
+//  - "error": the problem message;
+//  - "errors": validation errors as []ProblemFieldError, if any;
+//  - "repeated": the number of problems suppressed by deduplication, if any;
+//  - "dropped": the number of records dropped by rate limit, if any.
+//
+// By default, every problem is written. See LogDeduplication and
+// LogRateLimit.
+func NewLoggingProblemHandler(logger Logger, opts ...LogOption) ProblemHandler {
+	var options LogOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &loggingProblemHandler{
+		logger:  logger,
+		options: options,
+		now:     time.Now,
+		seen:    make(map[problemKey]*seenProblem),
+	}
+}
+
+type loggingProblemHandler struct {
+	logger  Logger
+	options LogOptions
+	now     func() time.Time
+
+	mu sync.Mutex
+
+	// seen are problems written within the deduplication window.
+	seen      map[problemKey]*seenProblem
+	lastPrune time.Time
+
+	// rateStart is the start of the current rate limit interval.
+	rateStart time.Time
+	rateCount int
+	dropped   int
+}
+
+// problemKey identifies identical problems.
+type problemKey struct {
+	kind      string
+	method    string
+	operation string
+	err       string
+}
+
+type seenProblem struct {
+	at         time.Time
+	suppressed int
+}
+
+// HandleProblem implements ProblemHandler.
+func (h *loggingProblemHandler) HandleProblem(p Problem) {
+	req := p.Request()
+	key := problemKey{
+		kind:   p.Kind(),
+		method: req.Method,
+		err:    p.Cause().Error(),
+	}
+	oi, resolved := getOperationInfo(req)
+	if resolved {
+		key.operation = oi.operation.ID
+	} else {
+		key.operation = req.URL.Path
+	}
+
+	repeated, ok := h.admit(key)
+	if !ok {
+		return
+	}
+
+	keyvals := []interface{}{
+		"kind", p.Kind(),
+		"method", req.Method,
+		"path", req.URL.Path,
+	}
+	if resolved {
+		keyvals = append(keyvals, "operation_id", key.operation)
+	}
+
+	msg, errs := problemFieldErrors(p.Cause())
+	keyvals = append(keyvals, "error", msg)
+	if len(errs) > 0 {
+		keyvals = append(keyvals, "errors", errs)
+	}
+	if repeated > 0 {
+		keyvals = append(keyvals, "repeated", repeated)
+	}
+	if dropped := h.takeDropped(); dropped > 0 {
+		keyvals = append(keyvals, "dropped", dropped)
+	}
+
+	h.logger.Log("oas problem", keyvals...)
+}
+
+// admit returns true if the problem should be written, and the number of
+// identical problems suppressed since it was written last time.
+func (h *loggingProblemHandler) admit(key problemKey) (repeated int, ok bool) {
+	now := h.now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var seen *seenProblem
+	if h.options.dedupWindow > 0 {
+		h.prune(now)
+
+		seen = h.seen[key]
+		if seen != nil && now.Sub(seen.at) < h.options.dedupWindow {
+			seen.suppressed++
+			return 0, false
+		}
+	}
+
+	if h.options.rateLimit > 0 {
+		if now.Sub(h.rateStart) >= h.options.rateInterval {
+			h.rateStart = now
+			h.rateCount = 0
+		}
+		if h.rateCount >= h.options.rateLimit {
+			h.dropped++
+			return 0, false
+		}
+		h.rateCount++
+	}
+
+	if h.options.dedupWindow > 0 {
+		if seen != nil {
+			repeated = seen.suppressed
+		}
+		h.seen[key] = &seenProblem{at: now}
+	}
+	return repeated, true
+}
+
+// prune forgets problems written before the deduplication window. Problems
+// with suppressed duplicates are kept for another window, so the number of
+// duplicates is reported if the problem recurs.
+func (h *loggingProblemHandler) prune(now time.Time) {
+	if now.Sub(h.lastPrune) < h.options.dedupWindow {
+		return
+	}
+	h.lastPrune = now
+
+	for key, seen := range h.seen {
+		age := now.Sub(seen.at)
+		if age >= 2*h.options.dedupWindow || (age >= h.options.dedupWindow && seen.suppressed == 0) {
+			delete(h.seen, key)
+		}
+	}
+}
+
+func (h *loggingProblemHandler) takeDropped() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	dropped := h.dropped
+	h.dropped = 0
+	return dropped
+}
+
+// problemFieldErrors returns the problem message and its validation errors.
+// Nested multi errors are flattened.
+func problemFieldErrors(err error) (string, []ProblemFieldError) {
+	me, ok := err.(MultiError)
+	if !ok {
+		return err.Error(), nil
+	}
+
+	var errs []ProblemFieldError
+	var walk func(es []error)
+	walk = func(es []error) {
+		for _, e := range es {
+			if nested, ok := e.(MultiError); ok {
+				walk(nested.Errors())
+				continue
+			}
+			fe := ProblemFieldError{Message: e.Error()}
+			if ve, ok := e.(validate.ValidationError); ok {
+				fe.Field = ve.Field()
+				fe.Value = ve.Value()
+			}
+			if de, ok := e.(validate.DetailedValidationError); ok {
+				fe.In = de.In()
+				fe.Pointer = de.Pointer()
+				fe.Keyword = de.Keyword()
+			}
+			errs = append(errs, fe)
+		}
+	}
+	walk(me.Errors())
+
+	return me.Message(), errs
+}
@@ -0,0 +1,115 @@
+package oas
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type logRecord struct {
+	msg     string
+	keyvals []interface{}
+}
+
+type fakeLogger struct {
+	records []logRecord
+}
+
+func (l *fakeLogger) Log(msg string, keyvals ...interface{}) {
+	l.records = append(l.records, logRecord{msg: msg, keyvals: keyvals})
+}
+
+func TestNewLoggingProblemHandler(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	b := &ResolvingBasis{adapter: templateAdapter{}, doc: doc}
+	b.initCache()
+
+	logger := &fakeLogger{}
+	router := &templateRouter{}
+	err := b.OperationRouter(router).
+		WithOperationHandlers(map[string]http.Handler{
+			"getPetById": http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}),
+		}).
+		WithMiddleware(
+			b.QueryValidator(WithProblemHandler(NewLoggingProblemHandler(logger)), WithContinueOnProblem(true)),
+		).
+		Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v2/pet/1?debug=maybe", nil))
+
+	assert.Equal(t, []logRecord{
+		{
+			msg: "oas problem",
+			keyvals: []interface{}{
+				"kind", ProblemKindQuery,
+				"method", http.MethodGet,
+				"path", "/v2/pet/1",
+				"operation_id", "getPetById",
+				"error", "query params do not match the schema",
+				"errors", []ProblemFieldError{
+					{Message: "param debug: unknown format maybe for type boolean", In: "query", Field: "debug", Value: "maybe", Keyword: "type"},
+				},
+			},
+		},
+	}, logger.records)
+}
+
+func TestNewLoggingProblemHandler_limits(t *testing.T) {
+	logger := &fakeLogger{}
+	h := NewLoggingProblemHandler(logger, LogDeduplication(time.Minute), LogRateLimit(2, time.Second)).(*loggingProblemHandler)
+
+	now := time.Date(2018, 8, 8, 12, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	problem := func(msg string) Problem {
+		return NewProblem(nil, httptest.NewRequest(http.MethodGet, "/pets", nil), errors.New(msg))
+	}
+
+	h.HandleProblem(problem("foo"))
+	h.HandleProblem(problem("foo")) // duplicate
+	h.HandleProblem(problem("bar"))
+	h.HandleProblem(problem("baz")) // rate limited
+
+	now = now.Add(time.Second)
+	h.HandleProblem(problem("baz"))
+	h.HandleProblem(problem("foo")) // duplicate
+
+	now = now.Add(time.Minute)
+	h.HandleProblem(problem("foo"))
+
+	var written []interface{}
+	for _, r := range logger.records {
+		written = append(written, r.keyvals[7:])
+	}
+	assert.Equal(t, []interface{}{
+		[]interface{}{"foo"},
+		[]interface{}{"bar"},
+		[]interface{}{"baz", "dropped", 1},
+		[]interface{}{"foo", "repeated", 2},
+	}, written)
+}
+
+func TestStdLogger(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	stdLogger.Log("oas problem", "kind", "response body", "errors", []ProblemFieldError{{Message: "name is required"}}, "repeated", 2)
+
+	assert.Equal(t, "[WARN] oas problem kind=\"response body\" errors=[name is required] repeated=2\n", buf.String())
+}
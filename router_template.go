@@ -0,0 +1,272 @@
+package oas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// templateAdapter implements Adapter using templateRouter. It is used by
+// handlers that need routing when no router adapter is given.
+type templateAdapter struct{}
+
+// Resolver returns a resolver that resolves operation id from the route
+// matched by templateRouter.
+func (templateAdapter) Resolver(meta interface{}) Resolver {
+	return templateResolver{}
+}
+
+// OperationRouter returns the meta, which must be *templateRouter.
+func (templateAdapter) OperationRouter(meta interface{}) OperationRouter {
+	r, ok := meta.(*templateRouter)
+	if !ok {
+		panic("oas: OperationRouter meta is not *templateRouter")
+	}
+	return r
+}
+
+// PathParamExtractor returns path param extractor that extracts parameters
+// from the route matched by templateRouter.
+func (templateAdapter) PathParamExtractor() PathParamExtractor {
+	return PathParamExtractorFunc(func(req *http.Request, key string) string {
+		m, _ := req.Context().Value(contextKeyTemplateMatch{}).(templateMatch)
+		return m.params[key]
+	})
+}
+
+type templateResolver struct{}
+
+func (templateResolver) Resolve(req *http.Request) (string, bool) {
+	m, ok := req.Context().Value(contextKeyTemplateMatch{}).(templateMatch)
+	if !ok {
+		return "", false
+	}
+	return m.operationID, true
+}
+
+// templateRouter is a simple operation router that matches request paths
+// against spec path templates, e.g. "/pets/{id}".
+type templateRouter struct {
+	doc      *Document
+	mws      []Middleware
+	handlers map[string]http.Handler
+
+	onMissingOperationHandler func(op string)
+
+	routes []templateRoute
+}
+
+type templateRoute struct {
+	method      string
+	segments    []string
+	operationID string
+	handler     http.Handler
+}
+
+// templateMatch is the route matched by the request.
+type templateMatch struct {
+	operationID string
+	params      map[string]string
+}
+
+type contextKeyTemplateMatch struct{}
+
+func (r *templateRouter) WithDocument(doc *Document) OperationRouter {
+	r.doc = doc
+	return r
+}
+
+func (r *templateRouter) WithMiddleware(mws ...Middleware) OperationRouter {
+	r.mws = append(r.mws, mws...)
+	return r
+}
+
+func (r *templateRouter) WithOperationHandlers(hh map[string]http.Handler) OperationRouter {
+	r.handlers = hh
+	return r
+}
+
+func (r *templateRouter) WithMissingOperationHandlerFunc(fn func(string)) OperationRouter {
+	r.onMissingOperationHandler = fn
+	return r
+}
+
+func (r *templateRouter) Build() error {
+	if r.doc == nil {
+		return fmt.Errorf("no doc is given")
+	}
+	if r.handlers == nil {
+		return fmt.Errorf("no operation handlers given")
+	}
+
+	r.routes = nil
+	for method, pathOps := range r.doc.Analyzer.Operations() {
+		for path, operation := range pathOps {
+			h, ok := r.handlers[operation.ID]
+			if !ok {
+				if r.onMissingOperationHandler != nil {
+					r.onMissingOperationHandler(operation.ID)
+				}
+				continue
+			}
+
+			for i := len(r.mws) - 1; i >= 0; i-- {
+				h = r.mws[i](h)
+			}
+
+			r.routes = append(r.routes, templateRoute{
+				method:      method,
+				segments:    pathSegments(path),
+				operationID: operation.ID,
+				handler:     h,
+			})
+		}
+	}
+
+	sortRoutes(r.routes)
+	return nil
+}
+
+func (r *templateRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	route, params, allowed, ok := matchRoutes(r.routes, r.doc.BasePath(), req.Method, req.URL.Path)
+	if ok {
+		m := templateMatch{operationID: route.operationID, params: params}
+		req = req.WithContext(context.WithValue(req.Context(), contextKeyTemplateMatch{}, m))
+		route.handler.ServeHTTP(w, req)
+		return
+	}
+
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	http.NotFound(w, req)
+}
+
+// PathResolver resolves operations by matching request method and path
+// against path templates of the spec. Unlike resolvers of router adapters,
+// it needs no router, so it can be used where requests are not routed,
+// e.g. in HTTP clients and tests.
+type PathResolver struct {
+	basePath string
+	routes   []templateRoute
+}
+
+// NewPathResolver returns a new path resolver for the spec.
+func NewPathResolver(doc *Document) *PathResolver {
+	r := &PathResolver{basePath: doc.BasePath()}
+	for method, pathOps := range doc.Analyzer.Operations() {
+		for path, operation := range pathOps {
+			r.routes = append(r.routes, templateRoute{
+				method:      method,
+				segments:    pathSegments(path),
+				operationID: operation.ID,
+			})
+		}
+	}
+	sortRoutes(r.routes)
+	return r
+}
+
+// Resolve resolves operation id from the request.
+func (r *PathResolver) Resolve(req *http.Request) (string, bool) {
+	id, _, ok := r.ResolvePath(req.Method, req.URL.Path)
+	return id, ok
+}
+
+// ResolvePath returns id of the operation for the method and the request
+// path, which includes the base path, and values of path parameters.
+func (r *PathResolver) ResolvePath(method, path string) (id string, params map[string]string, ok bool) {
+	route, params, _, ok := matchRoutes(r.routes, r.basePath, method, path)
+	if !ok {
+		return "", nil, false
+	}
+	return route.operationID, params, true
+}
+
+// matchRoutes returns the route matching the method and the request path,
+// and path parameters. If the path matches routes of other methods only,
+// the methods are returned as allowed.
+func matchRoutes(routes []templateRoute, basePath, method, path string) (templateRoute, map[string]string, []string, bool) {
+	basePath = strings.TrimSuffix(basePath, "/")
+	if !strings.HasPrefix(path, basePath+"/") {
+		return templateRoute{}, nil, nil, false
+	}
+	segments := pathSegments(strings.TrimPrefix(path, basePath))
+
+	var allowed []string
+	for _, route := range routes {
+		params, ok := matchTemplate(route.segments, segments)
+		if !ok {
+			continue
+		}
+		if !strings.EqualFold(route.method, method) {
+			allowed = append(allowed, strings.ToUpper(route.method))
+			continue
+		}
+		return route, params, nil, true
+	}
+
+	sort.Strings(allowed)
+	return templateRoute{}, nil, allowed, false
+}
+
+func pathSegments(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// matchTemplate matches path segments against the template segments and
+// returns path parameters.
+func matchTemplate(template, segments []string) (map[string]string, bool) {
+	if len(template) != len(segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, t := range template {
+		if isTemplateParam(t) {
+			if segments[i] == "" {
+				return nil, false
+			}
+			params[t[1:len(t)-1]] = segments[i]
+			continue
+		}
+		if t != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func isTemplateParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// sortRoutes sorts routes so that literal segments take precedence over
+// templates, e.g. "/pets/mine" is matched before "/pets/{id}".
+func sortRoutes(routes []templateRoute) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		return templateRouteLess(routes[i], routes[j])
+	})
+}
+
+// templateRouteLess orders routes by the number of segments, and routes of
+// the same length so that at the first position where one route has literal
+// segment and another has template parameter the literal one comes first.
+// Only routes of the same length can match the same path.
+func templateRouteLess(a, b templateRoute) bool {
+	if len(a.segments) != len(b.segments) {
+		return len(a.segments) < len(b.segments)
+	}
+	for i := range a.segments {
+		pa, pb := isTemplateParam(a.segments[i]), isTemplateParam(b.segments[i])
+		if pa != pb {
+			return pb
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package oas
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathResolver(t *testing.T) {
+	r := NewPathResolver(loadDocFile(t, "testdata/mock.yml"))
+
+	testCases := map[string]struct {
+		method string
+		path   string
+		id     string
+		params map[string]string
+		ok     bool
+	}{
+		"literal path": {
+			method: http.MethodPost,
+			path:   "/api/pets",
+			id:     "addPet",
+			params: map[string]string{},
+			ok:     true,
+		},
+		"literal path before template": {
+			method: http.MethodGet,
+			path:   "/api/pets/mine",
+			id:     "listMyPets",
+			params: map[string]string{},
+			ok:     true,
+		},
+		"template": {
+			method: http.MethodDelete,
+			path:   "/api/pets/12",
+			id:     "deletePet",
+			params: map[string]string{"id": "12"},
+			ok:     true,
+		},
+		"method not defined": {
+			method: http.MethodPut,
+			path:   "/api/pets/12",
+		},
+		"path without base path": {
+			method: http.MethodGet,
+			path:   "/pets",
+		},
+		"unknown path": {
+			method: http.MethodGet,
+			path:   "/api/pets/12/owner",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			id, params, ok := r.ResolvePath(tc.method, tc.path)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.id, id)
+			assert.Equal(t, tc.params, params)
+
+			id, ok = r.Resolve(httptest.NewRequest(tc.method, tc.path, nil))
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.id, id)
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package oas
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// Vendor extensions that control spec publication.
+const (
+	// extInternal marks operation as internal. See SpecExcludeInternal.
+	extInternal = "x-internal"
+
+	// extAudience lists audiences the operation is published to.
+	// See SpecAudience.
+	extAudience = "x-audience"
+)
+
+// SpecHandlerOptions represent options for spec handlers.
+type SpecHandlerOptions struct {
+	excludeTags     []string
+	excludeInternal bool
+	filters         []OperationFilter
+	audience        func(req *http.Request) string
+
+	// options of the dynamic spec handler
+	trustedProxies []*net.IPNet
+	keepSchemes    bool
+}
+
+// SpecHandlerOption is option to use when creating spec handler.
+type SpecHandlerOption func(*SpecHandlerOptions)
+
+// OperationFilter reports whether the operation should be published.
+type OperationFilter func(op *spec.Operation) bool
+
+// SpecExcludeTags returns option that removes operations tagged with any of
+// the tags from the served spec.
+func SpecExcludeTags(tags ...string) SpecHandlerOption {
+	return func(o *SpecHandlerOptions) {
+		o.excludeTags = append(o.excludeTags, tags...)
+	}
+}
+
+// SpecExcludeInternal returns option that removes operations marked with
+// "x-internal: true" vendor extension from the served spec.
+func SpecExcludeInternal() SpecHandlerOption {
+	return func(o *SpecHandlerOptions) {
+		o.excludeInternal = true
+	}
+}
+
+// SpecFilterOperations returns option that removes operations the filter
+// does not accept from the served spec.
+func SpecFilterOperations(f OperationFilter) SpecHandlerOption {
+	return func(o *SpecHandlerOptions) {
+		o.filters = append(o.filters, f)
+	}
+}
+
+// SpecAudience returns option that publishes operations depending on the
+// audience derived from the request, e.g. from the role of authenticated
+// user. Operation with "x-audience" vendor extension, which is either
+// a string or a list of strings, is served only to the listed audiences.
+// Operations without the extension are served to everyone.
+//
+// The spec is rendered once for every audience, so the number of distinct
+// audiences should be small.
+func SpecAudience(f func(req *http.Request) string) SpecHandlerOption {
+	return func(o *SpecHandlerOptions) {
+		o.audience = f
+	}
+}
+
+// SpecTrustedProxies returns option that makes the dynamic spec handler
+// consider forwarding headers only in requests coming from the trusted
+// proxies, so clients cannot spoof the host in the spec. Proxies that forward
+// the request are identified by X-Forwarded-For header or "for" parameter of
+// Forwarded header, and the values added by the outermost trusted proxy are
+// used.
+//
+// By default, no proxy is trusted, and forwarding headers are ignored.
+func SpecTrustedProxies(proxies ...*net.IPNet) SpecHandlerOption {
+	return func(o *SpecHandlerOptions) {
+		o.trustedProxies = append(o.trustedProxies, proxies...)
+	}
+}
+
+// SpecKeepSchemes returns option that makes the dynamic spec handler keep
+// schemes defined in the spec, putting the scheme of the request first.
+// By default, the request scheme is the only scheme in the served spec.
+func SpecKeepSchemes() SpecHandlerOption {
+	return func(o *SpecHandlerOptions) {
+		o.keepSchemes = true
+	}
+}
+
+func parseSpecHandlerOptions(opts ...SpecHandlerOption) SpecHandlerOptions {
+	options := SpecHandlerOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// filtering checks if any operation can be removed from the spec.
+func (o SpecHandlerOptions) filtering() bool {
+	return len(o.excludeTags) > 0 || o.excludeInternal || len(o.filters) > 0 || o.audience != nil
+}
+
+// keep returns a filter that accepts operations to be published for
+// the audience.
+func (o SpecHandlerOptions) keep(audience string) OperationFilter {
+	return func(op *spec.Operation) bool {
+		for _, tag := range op.Tags {
+			if containsString(o.excludeTags, tag) {
+				return false
+			}
+		}
+
+		if o.excludeInternal {
+			if internal, ok := op.Extensions.GetBool(extInternal); ok && internal {
+				return false
+			}
+		}
+
+		if o.audience != nil {
+			if audiences, ok := operationAudiences(op); ok && !containsString(audiences, audience) {
+				return false
+			}
+		}
+
+		for _, f := range o.filters {
+			if !f(op) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// operationAudiences returns audiences listed in the operation "x-audience"
+// vendor extension.
+func operationAudiences(op *spec.Operation) ([]string, bool) {
+	v, ok := op.Extensions[extAudience]
+	if !ok {
+		return nil, false
+	}
+
+	switch v := v.(type) {
+	case string:
+		return []string{v}, true
+	case []interface{}:
+		audiences := make([]string, 0, len(v))
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				audiences = append(audiences, s)
+			}
+		}
+		return audiences, true
+	default:
+		return []string{}, true
+	}
+}
+
+// filterSpec returns a copy of the document spec with operations accepted by
+// keep. Paths without operations are removed. Definitions, parameters and
+// responses not referenced by the remaining operations are pruned. Tags
+// excluded with SpecExcludeTags are removed as well.
+//
+// The returned spec shares unchanged parts with the document spec, so it
+// must not be modified.
+func filterSpec(doc *Document, keep OperationFilter, excludeTags []string) *spec.Swagger {
+	s := doc.Spec()
+
+	filtered := &spec.Swagger{
+		VendorExtensible: s.VendorExtensible,
+		SwaggerProps:     s.SwaggerProps,
+	}
+
+	// refs are names of the referenced definitions, parameters and responses.
+	refs := newRefCollector(doc.OrigSpec())
+
+	if s.Paths != nil {
+		filtered.Paths = &spec.Paths{
+			VendorExtensible: s.Paths.VendorExtensible,
+			Paths:            make(map[string]spec.PathItem),
+		}
+
+		for path, item := range s.Paths.Paths {
+			origItem := origPathItem(doc.OrigSpec(), path)
+
+			ops := []struct {
+				op, orig **spec.Operation
+			}{
+				{&item.Get, &origItem.Get},
+				{&item.Put, &origItem.Put},
+				{&item.Post, &origItem.Post},
+				{&item.Delete, &origItem.Delete},
+				{&item.Options, &origItem.Options},
+				{&item.Head, &origItem.Head},
+				{&item.Patch, &origItem.Patch},
+			}
+
+			published := 0
+			for _, o := range ops {
+				if *o.op == nil {
+					continue
+				}
+				if !keep(*o.op) {
+					*o.op = nil
+					continue
+				}
+				published++
+				refs.collect(*o.orig)
+			}
+
+			if published == 0 {
+				continue
+			}
+
+			refs.collect(origItem.Parameters)
+			filtered.Paths.Paths[path] = item
+		}
+	}
+
+	// Global parameters and responses are applicable to all operations,
+	// so they are referenced only explicitly.
+	filtered.Definitions = pruneDefinitions(s.Definitions, refs.names("definitions"))
+	filtered.Parameters = pruneParameters(s.Parameters, refs.names("parameters"))
+	filtered.Responses = pruneResponses(s.Responses, refs.names("responses"))
+
+	if len(excludeTags) > 0 {
+		var tags []spec.Tag
+		for _, tag := range s.Tags {
+			if !containsString(excludeTags, tag.Name) {
+				tags = append(tags, tag)
+			}
+		}
+		filtered.Tags = tags
+	}
+
+	return filtered
+}
+
+// origPathItem returns a copy of the path item from the original spec.
+func origPathItem(s *spec.Swagger, path string) spec.PathItem {
+	if s == nil || s.Paths == nil {
+		return spec.PathItem{}
+	}
+	return s.Paths.Paths[path]
+}
+
+// refCollector collects local references from the original spec,
+// including transitive ones.
+type refCollector struct {
+	orig *spec.Swagger
+
+	// refs are collected references, e.g. "#/definitions/Pet".
+	refs map[string]bool
+}
+
+func newRefCollector(orig *spec.Swagger) *refCollector {
+	return &refCollector{
+		orig: orig,
+		refs: make(map[string]bool),
+	}
+}
+
+// collect collects references from v, which is any part of the spec.
+func (c *refCollector) collect(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return
+	}
+
+	c.walk(data)
+}
+
+func (c *refCollector) walk(data interface{}) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			c.add(ref)
+		}
+		for _, value := range v {
+			c.walk(value)
+		}
+	case []interface{}:
+		for _, value := range v {
+			c.walk(value)
+		}
+	}
+}
+
+// add adds the reference and collects references from the referenced object.
+func (c *refCollector) add(ref string) {
+	if !strings.HasPrefix(ref, "#/") || c.refs[ref] {
+		return
+	}
+	c.refs[ref] = true
+
+	if c.orig == nil {
+		return
+	}
+
+	section, name := refSection(ref)
+	switch section {
+	case "definitions":
+		if def, ok := c.orig.Definitions[name]; ok {
+			c.collect(def)
+		}
+	case "parameters":
+		if param, ok := c.orig.Parameters[name]; ok {
+			c.collect(param)
+		}
+	case "responses":
+		if resp, ok := c.orig.Responses[name]; ok {
+			c.collect(resp)
+		}
+	}
+}
+
+// names returns names of objects referenced in the section of the spec.
+func (c *refCollector) names(section string) map[string]bool {
+	names := make(map[string]bool)
+	for ref := range c.refs {
+		if s, name := refSection(ref); s == section {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// refSection splits local reference like "#/definitions/Pet" to
+// the section and the name of the object.
+func refSection(ref string) (section, name string) {
+	parts := strings.SplitN(strings.TrimPrefix(ref, "#/"), "/", 3)
+	if len(parts) < 2 {
+		return "", ""
+	}
+	name = strings.Replace(strings.Replace(parts[1], "~1", "/", -1), "~0", "~", -1)
+	return parts[0], name
+}
+
+func pruneDefinitions(defs spec.Definitions, keep map[string]bool) spec.Definitions {
+	if defs == nil {
+		return nil
+	}
+	pruned := make(spec.Definitions)
+	for name, def := range defs {
+		if keep[name] {
+			pruned[name] = def
+		}
+	}
+	return pruned
+}
+
+func pruneParameters(params map[string]spec.Parameter, keep map[string]bool) map[string]spec.Parameter {
+	if params == nil {
+		return nil
+	}
+	pruned := make(map[string]spec.Parameter)
+	for name, param := range params {
+		if keep[name] {
+			pruned[name] = param
+		}
+	}
+	return pruned
+}
+
+func pruneResponses(resps map[string]spec.Response, keep map[string]bool) map[string]spec.Response {
+	if resps == nil {
+		return nil
+	}
+	pruned := make(map[string]spec.Response)
+	for name, resp := range resps {
+		if keep[name] {
+			pruned[name] = resp
+		}
+	}
+	return pruned
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,19 @@
 package oas
 
 import (
+	"bytes"
+	"compress/gzip"
+	"container/list"
+	"crypto/sha1"
+	"encoding/json"
 	"fmt"
+	"mime"
 	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/go-openapi/spec"
@@ -19,60 +30,351 @@ const (
 	SpecHandlerTypeStatic
 )
 
+// maxRenderedSpecs is the maximum number of rendered specs a spec handler
+// keeps. Host and audience come from the request, so the number of distinct
+// specs is not limited by the handler itself, and the least recently used
+// specs are evicted.
+const maxRenderedSpecs = 32
+
 // NewDynamicSpecHandler returns HTTP handler for OpenAPI spec that
-// changes its host and schemes dynamically based on incoming request.
-func NewDynamicSpecHandler(doc *Document) http.Handler {
-	return &dynamicSpecHandler{s: doc.Spec()}
+// changes its host, schemes and basePath dynamically based on incoming
+// request.
+//
+// Host and scheme are taken from the standard Forwarded header (RFC 7239),
+// or X-Forwarded-Host, X-Forwarded-Proto and X-Scheme headers. If the request
+// has X-Forwarded-Prefix header, the prefix is prepended to basePath. Only
+// http, https, ws and wss schemes are accepted.
+//
+// These headers are considered only in requests from proxies trusted with
+// SpecTrustedProxies option, and values added by the outermost trusted proxy
+// are used, so clients cannot spoof the host in the served spec. By default,
+// no proxy is trusted, and the host and the scheme are taken from the request
+// itself.
+//
+// The spec is served the same way as by the handler returned from
+// NewStaticSpecHandler. The spec is rendered once for every host, scheme and
+// prefix.
+func NewDynamicSpecHandler(doc *Document, opts ...SpecHandlerOption) http.Handler {
+	return &dynamicSpecHandler{
+		doc:     doc,
+		options: parseSpecHandlerOptions(opts...),
+		modTime: time.Now(),
+		cache:   newSpecCache(),
+	}
 }
 
 type dynamicSpecHandler struct {
-	s *spec.Swagger
+	doc     *Document
+	options SpecHandlerOptions
+	modTime time.Time
+	cache   *specCache
 }
 
 func (h *dynamicSpecHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	host := req.Header.Get("X-Forwarded-Host")
-	if host == "" {
-		host = req.Host
+	f := forwardedRequest(req, h.options.trustedProxies)
+
+	var audience string
+	if h.options.audience != nil {
+		audience = h.options.audience(req)
 	}
 
-	scheme := req.Header.Get("X-Forwarded-Proto")
-	if scheme == "" {
-		scheme = req.Header.Get("X-Scheme")
-		if scheme == "" {
-			scheme = "http"
+	key := f.scheme + "://" + f.host + f.prefix + " " + audience
+	rs := h.cache.get(key, func() *renderedSpec {
+		s := publishedSpec(h.doc, h.options, audience)
+
+		specShallowCopy := &spec.Swagger{
+			VendorExtensible: s.VendorExtensible,
+			SwaggerProps:     s.SwaggerProps,
+		}
+		specShallowCopy.Host = f.host
+		specShallowCopy.Schemes = []string{f.scheme}
+		if h.options.keepSchemes {
+			for _, scheme := range s.Schemes {
+				if scheme != f.scheme {
+					specShallowCopy.Schemes = append(specShallowCopy.Schemes, scheme)
+				}
+			}
+		}
+		if f.prefix != "" {
+			specShallowCopy.BasePath = path.Join(f.prefix, s.BasePath)
 		}
-	}
 
-	specShallowCopy := &spec.Swagger{
-		VendorExtensible: h.s.VendorExtensible,
-		SwaggerProps:     h.s.SwaggerProps,
-	}
-	specShallowCopy.Host = host
-	specShallowCopy.Schemes = []string{scheme}
+		return renderSpec(specShallowCopy, h.modTime)
+	})
 
-	writeSpec(w, specShallowCopy)
+	serveSpec(w, req, rs)
 }
 
 // NewStaticSpecHandler returns HTTP handler for static OpenAPI spec.
-func NewStaticSpecHandler(doc *Document) http.Handler {
-	return &staticSpecHandler{s: doc.Spec()}
+//
+// The spec is rendered once, when the handler is created. It is served as
+// JSON if the request path ends with ".json" or the request Accept header
+// prefers JSON, and as YAML otherwise. The handler sets ETag and Last-Modified
+// headers, responds to conditional requests with 304 Not Modified, and
+// compresses the spec with gzip if the client accepts it.
+//
+// Options allow to publish only a part of the spec, e.g. to hide internal
+// operations from partners. Definitions that are not referenced by
+// the published operations are removed from the spec.
+func NewStaticSpecHandler(doc *Document, opts ...SpecHandlerOption) http.Handler {
+	h := &staticSpecHandler{
+		doc:     doc,
+		options: parseSpecHandlerOptions(opts...),
+		modTime: time.Now(),
+		cache:   newSpecCache(),
+	}
+
+	if h.options.audience == nil {
+		h.rs = renderSpec(publishedSpec(doc, h.options, ""), h.modTime)
+	}
+
+	return h
 }
 
 type staticSpecHandler struct {
-	s *spec.Swagger
+	doc     *Document
+	options SpecHandlerOptions
+	modTime time.Time
+
+	// rs is the rendered spec. It is nil if the spec depends on
+	// the audience, in which case rendered specs are cached.
+	rs    *renderedSpec
+	cache *specCache
 }
 
 func (h *staticSpecHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	writeSpec(w, h.s)
+	if h.rs != nil {
+		serveSpec(w, req, h.rs)
+		return
+	}
+
+	audience := h.options.audience(req)
+	rs := h.cache.get(audience, func() *renderedSpec {
+		return renderSpec(publishedSpec(h.doc, h.options, audience), h.modTime)
+	})
+
+	serveSpec(w, req, rs)
+}
+
+// publishedSpec returns the spec to be published to the audience.
+func publishedSpec(doc *Document, options SpecHandlerOptions, audience string) *spec.Swagger {
+	if !options.filtering() {
+		return doc.Spec()
+	}
+
+	return filterSpec(doc, options.keep(audience), options.excludeTags)
+}
+
+// specCache keeps at most maxRenderedSpecs recently used rendered specs by
+// key.
+type specCache struct {
+	mu    sync.Mutex
+	specs map[string]*list.Element
+
+	// lru holds cached specs, the most recently used first.
+	lru *list.List
+}
+
+type cachedSpec struct {
+	key string
+	rs  *renderedSpec
+}
+
+func newSpecCache() *specCache {
+	return &specCache{
+		specs: make(map[string]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+// get returns the spec by key, rendering it if it is not in the cache.
+func (c *specCache) get(key string, render func() *renderedSpec) *renderedSpec {
+	if rs, ok := c.lookup(key); ok {
+		return rs
+	}
+
+	rs := render()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.specs[key]; ok {
+		// The spec has been rendered concurrently.
+		c.lru.MoveToFront(el)
+		return el.Value.(*cachedSpec).rs
+	}
+
+	c.specs[key] = c.lru.PushFront(&cachedSpec{key: key, rs: rs})
+	if c.lru.Len() > maxRenderedSpecs {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.specs, oldest.Value.(*cachedSpec).key)
+	}
+	return rs
+}
+
+func (c *specCache) lookup(key string) (*renderedSpec, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.specs[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*cachedSpec).rs, true
+}
+
+// renderedSpec is a spec rendered in all supported formats.
+type renderedSpec struct {
+	json    specRepresentation
+	yaml    specRepresentation
+	modTime time.Time
+
+	// err is set if the spec cannot be rendered.
+	err error
 }
 
-func writeSpec(w http.ResponseWriter, s *spec.Swagger) {
-	b, err := yaml.Marshal(s)
+// specRepresentation is a spec rendered in a single format.
+type specRepresentation struct {
+	contentType string
+
+	body []byte
+	etag string
+
+	gzipBody []byte
+	gzipETag string
+}
+
+func renderSpec(s *spec.Swagger, modTime time.Time) *renderedSpec {
+	// Last-Modified has a precision of one second.
+	rs := &renderedSpec{modTime: modTime.Truncate(time.Second)}
+
+	jsonBody, err := json.Marshal(s)
 	if err != nil {
+		rs.err = err
+		return rs
+	}
+
+	yamlBody, err := yaml.JSONToYAML(jsonBody)
+	if err != nil {
+		rs.err = err
+		return rs
+	}
+
+	if rs.json, err = newSpecRepresentation("application/json", jsonBody); err != nil {
+		rs.err = err
+		return rs
+	}
+	if rs.yaml, err = newSpecRepresentation("application/x-yaml", yamlBody); err != nil {
+		rs.err = err
+		return rs
+	}
+
+	return rs
+}
+
+func newSpecRepresentation(contentType string, body []byte) (specRepresentation, error) {
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(body); err != nil {
+		return specRepresentation{}, err
+	}
+	if err := zw.Close(); err != nil {
+		return specRepresentation{}, err
+	}
+
+	sum := fmt.Sprintf("%x", sha1.Sum(body))
+	return specRepresentation{
+		contentType: contentType,
+		body:        body,
+		etag:        `"` + sum + `"`,
+		gzipBody:    buf.Bytes(),
+		gzipETag:    `"` + sum + `-gzip"`,
+	}, nil
+}
+
+func serveSpec(w http.ResponseWriter, req *http.Request, rs *renderedSpec) {
+	if rs.err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	rep := rs.yaml
+	if prefersJSON(req) {
+		rep = rs.json
 	}
 
-	w.Header().Set("Content-Type", "application/x-yaml")
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(b)))
-	w.Write(b) // nolint
+	w.Header().Add("Vary", "Accept")
+	w.Header().Add("Vary", "Accept-Encoding")
+	w.Header().Set("Content-Type", rep.contentType)
+
+	body := rep.body
+	if acceptsGzip(req) {
+		body = rep.gzipBody
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Set("ETag", rep.gzipETag)
+	} else {
+		w.Header().Set("ETag", rep.etag)
+	}
+
+	// ServeContent handles conditional requests, including If-None-Match.
+	http.ServeContent(w, req, "", rs.modTime, bytes.NewReader(body))
+}
+
+// prefersJSON checks if the spec should be served as JSON. The path suffix
+// takes precedence over the Accept header. YAML is preferred by default.
+func prefersJSON(req *http.Request) bool {
+	switch path.Ext(req.URL.Path) {
+	case ".json":
+		return true
+	case ".yaml", ".yml":
+		return false
+	}
+
+	var jsonQ, yamlQ float64
+	for _, mediaRange := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		q := quality(params)
+		switch {
+		case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+			if q > jsonQ {
+				jsonQ = q
+			}
+		case strings.HasSuffix(mediaType, "yaml"):
+			if q > yamlQ {
+				yamlQ = q
+			}
+		}
+	}
+
+	return jsonQ > yamlQ
+}
+
+// acceptsGzip checks if the client accepts gzip content coding.
+func acceptsGzip(req *http.Request) bool {
+	for _, coding := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		name, params, err := mime.ParseMediaType("x/" + strings.TrimSpace(coding))
+		if err != nil {
+			continue
+		}
+		if (name == "x/gzip" || name == "x/*") && quality(params) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// quality returns the value of "q" parameter, which defaults to 1.
+func quality(params map[string]string) float64 {
+	q, err := strconv.ParseFloat(params["q"], 64)
+	if err != nil {
+		return 1
+	}
+	return q
 }
@@ -1,16 +1,30 @@
 package oas
 
 import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"sort"
 	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestDynamicSpecHandler(t *testing.T) {
 	doc := loadDocFile(t, "testdata/petstore_1.yml")
 
-	h := NewDynamicSpecHandler(doc)
+	// Requests made with httptest come from 192.0.2.1.
+	_, proxy, err := net.ParseCIDR("192.0.2.1/32")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	h := NewDynamicSpecHandler(doc, SpecTrustedProxies(proxy))
 
 	rr := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/foo", nil)
@@ -68,3 +82,511 @@ func TestStaticSpecHandler(t *testing.T) {
 		t.Errorf("Expected schemes to be [https] but got %v", writtenDoc.Spec().Schemes)
 	}
 }
+
+func TestStaticSpecHandler_format(t *testing.T) {
+	testCases := map[string]struct {
+		path                string
+		accept              string
+		expectedContentType string
+	}{
+		"yaml by default": {
+			path:                "/openapi",
+			expectedContentType: "application/x-yaml",
+		},
+		"json suffix": {
+			path:                "/openapi.json",
+			accept:              "application/x-yaml",
+			expectedContentType: "application/json",
+		},
+		"yaml suffix": {
+			path:                "/openapi.yaml",
+			accept:              "application/json",
+			expectedContentType: "application/x-yaml",
+		},
+		"json accepted": {
+			path:                "/openapi",
+			accept:              "text/html, application/json",
+			expectedContentType: "application/json",
+		},
+		"yaml preferred by quality": {
+			path:                "/openapi",
+			accept:              "application/json;q=0.5, application/yaml",
+			expectedContentType: "application/x-yaml",
+		},
+	}
+
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	h := NewStaticSpecHandler(doc)
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("Accept", tc.accept)
+
+			h.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status to be 200 but got %d", rr.Code)
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != tc.expectedContentType {
+				t.Errorf("Expected Content-Type to be %q but got %q", tc.expectedContentType, ct)
+			}
+			if tc.expectedContentType == "application/json" && !json.Valid(rr.Body.Bytes()) {
+				t.Errorf("Expected body to be valid json")
+			}
+
+			writtenDoc := loadDocBytes(rr.Body.Bytes())
+			if writtenDoc.Spec().Host != doc.Spec().Host {
+				t.Errorf("Expected host to be %q but got %q", doc.Spec().Host, writtenDoc.Spec().Host)
+			}
+		})
+	}
+}
+
+func TestStaticSpecHandler_conditional(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	h := NewStaticSpecHandler(doc)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi", nil))
+
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("Expected ETag to be set")
+	}
+	if rr.Header().Get("Last-Modified") == "" {
+		t.Fatalf("Expected Last-Modified to be set")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("Expected status to be 304 but got %d", rr.Code)
+	}
+	if rr.Body.Len() != 0 {
+		t.Errorf("Expected empty body but got %q", rr.Body.String())
+	}
+
+	// Another representation has another ETag.
+	req = httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status to be 200 but got %d", rr.Code)
+	}
+}
+
+func TestStaticSpecHandler_gzip(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+	h := NewStaticSpecHandler(doc)
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi", nil)
+	req.Header.Set("Accept-Encoding", "deflate, gzip")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if ce := rr.Header().Get("Content-Encoding"); ce != "gzip" {
+		t.Fatalf("Expected Content-Encoding to be gzip but got %q", ce)
+	}
+
+	zr, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	writtenDoc := loadDocBytes(b)
+	if writtenDoc.Spec().Host != doc.Spec().Host {
+		t.Errorf("Expected host to be %q but got %q", doc.Spec().Host, writtenDoc.Spec().Host)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/openapi", nil)
+	req.Header.Set("Accept-Encoding", "gzip;q=0")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if ce := rr.Header().Get("Content-Encoding"); ce != "" {
+		t.Errorf("Expected no Content-Encoding but got %q", ce)
+	}
+}
+
+func TestStaticSpecHandler_filter(t *testing.T) {
+	testCases := map[string]struct {
+		opts                []SpecHandlerOption
+		header              string
+		expectedPaths       []string
+		expectedDefinitions []string
+		expectedParameters  []string
+		expectedResponses   []string
+		expectedTags        []string
+	}{
+		"no filter": {
+			expectedPaths:       []string{"/admin/stats", "/partners/orders", "/pets"},
+			expectedDefinitions: []string{"Category", "Error", "Order", "Pet", "Stats"},
+			expectedParameters:  []string{"Limit"},
+			expectedResponses:   []string{"Error"},
+			expectedTags:        []string{"pets", "admin", "partners"},
+		},
+		"exclude tags": {
+			opts:                []SpecHandlerOption{SpecExcludeTags("admin", "partners")},
+			expectedPaths:       []string{"/pets"},
+			expectedDefinitions: []string{"Category", "Pet"},
+			expectedParameters:  []string{"Limit"},
+			expectedTags:        []string{"pets"},
+		},
+		"exclude internal": {
+			opts:                []SpecHandlerOption{SpecExcludeInternal()},
+			expectedPaths:       []string{"/partners/orders", "/pets"},
+			expectedDefinitions: []string{"Category", "Order", "Pet"},
+			expectedParameters:  []string{"Limit"},
+			expectedTags:        []string{"pets", "admin", "partners"},
+		},
+		"custom filter": {
+			opts: []SpecHandlerOption{SpecFilterOperations(func(op *spec.Operation) bool {
+				return op.ID == "getStats"
+			})},
+			expectedPaths:       []string{"/admin/stats"},
+			expectedDefinitions: []string{"Error", "Stats"},
+			expectedResponses:   []string{"Error"},
+			expectedTags:        []string{"pets", "admin", "partners"},
+		},
+		"audience without access": {
+			opts:                []SpecHandlerOption{SpecExcludeInternal(), SpecAudience(audienceFromHeader)},
+			header:              "guest",
+			expectedPaths:       []string{"/pets"},
+			expectedDefinitions: []string{"Category", "Pet"},
+			expectedParameters:  []string{"Limit"},
+			expectedTags:        []string{"pets", "admin", "partners"},
+		},
+		"audience with access": {
+			opts:                []SpecHandlerOption{SpecExcludeInternal(), SpecAudience(audienceFromHeader)},
+			header:              "partner",
+			expectedPaths:       []string{"/partners/orders", "/pets"},
+			expectedDefinitions: []string{"Category", "Order", "Pet"},
+			expectedParameters:  []string{"Limit"},
+			expectedTags:        []string{"pets", "admin", "partners"},
+		},
+	}
+
+	doc := loadDocFile(t, "testdata/publication.yml")
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			h := NewStaticSpecHandler(doc, tc.opts...)
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+			req.Header.Set("X-Audience", tc.header)
+			h.ServeHTTP(rr, req)
+
+			var s spec.Swagger
+			if err := json.Unmarshal(rr.Body.Bytes(), &s); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var paths, definitions, parameters, responses, tags []string
+			for p := range s.Paths.Paths {
+				paths = append(paths, p)
+			}
+			for name := range s.Definitions {
+				definitions = append(definitions, name)
+			}
+			for name := range s.Parameters {
+				parameters = append(parameters, name)
+			}
+			for name := range s.Responses {
+				responses = append(responses, name)
+			}
+			for _, tag := range s.Tags {
+				tags = append(tags, tag.Name)
+			}
+			sort.Strings(paths)
+			sort.Strings(definitions)
+			sort.Strings(parameters)
+			sort.Strings(responses)
+
+			assert.Equal(t, tc.expectedPaths, paths)
+			assert.Equal(t, tc.expectedDefinitions, definitions)
+			assert.Equal(t, tc.expectedParameters, parameters)
+			assert.Equal(t, tc.expectedResponses, responses)
+			assert.Equal(t, tc.expectedTags, tags)
+		})
+	}
+
+	// The document itself is not modified.
+	assert.Len(t, doc.Spec().Paths.Paths, 3)
+	assert.NotNil(t, doc.Spec().Paths.Paths["/admin/stats"].Get)
+	assert.Len(t, doc.Spec().Definitions, 5)
+}
+
+func audienceFromHeader(req *http.Request) string {
+	return req.Header.Get("X-Audience")
+}
+
+func TestDynamicSpecHandler_forwarded(t *testing.T) {
+	_, trusted, err := net.ParseCIDR("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	// Requests made with httptest come from 192.0.2.1.
+	_, local, err := net.ParseCIDR("192.0.2.1/32")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	trustLocal := SpecTrustedProxies(local, trusted)
+
+	testCases := map[string]struct {
+		opts             []SpecHandlerOption
+		remoteAddr       string
+		headers          map[string]string
+		headerLines      map[string][]string
+		expectedHost     string
+		expectedSchemes  []string
+		expectedBasePath string
+	}{
+		"no forwarding headers": {
+			expectedHost:     "example.com",
+			expectedSchemes:  []string{"http"},
+			expectedBasePath: "/v2",
+		},
+		"no trusted proxies": {
+			headers: map[string]string{
+				"Forwarded":          "proto=https;host=evil.example.com",
+				"X-Forwarded-Host":   "evil.example.com",
+				"X-Forwarded-Proto":  "https",
+				"X-Forwarded-Prefix": "/evil",
+			},
+			expectedHost:     "example.com",
+			expectedSchemes:  []string{"http"},
+			expectedBasePath: "/v2",
+		},
+		"forwarded header": {
+			opts: []SpecHandlerOption{trustLocal},
+			headers: map[string]string{
+				"Forwarded":         `for=192.0.2.60;proto=https;host="api.example.com", for=10.0.0.1;host=internal`,
+				"X-Forwarded-Host":  "ignored.example.com",
+				"X-Forwarded-Proto": "http",
+			},
+			expectedHost:     "api.example.com",
+			expectedSchemes:  []string{"https"},
+			expectedBasePath: "/v2",
+		},
+		"x-forwarded headers with prefix": {
+			opts: []SpecHandlerOption{trustLocal},
+			headers: map[string]string{
+				"X-Forwarded-For":    "192.0.2.60, 10.0.0.1",
+				"X-Forwarded-Host":   "api.example.com, proxy.local",
+				"X-Forwarded-Proto":  "https",
+				"X-Forwarded-Prefix": "/petstore/",
+			},
+			expectedHost:     "api.example.com",
+			expectedSchemes:  []string{"https"},
+			expectedBasePath: "/petstore/v2",
+		},
+		"trusted proxy": {
+			opts:       []SpecHandlerOption{SpecTrustedProxies(trusted)},
+			remoteAddr: "10.1.2.3:4567",
+			headers: map[string]string{
+				"Forwarded": "proto=https;host=api.example.com",
+			},
+			expectedHost:     "api.example.com",
+			expectedSchemes:  []string{"https"},
+			expectedBasePath: "/v2",
+		},
+		"untrusted proxy": {
+			opts:       []SpecHandlerOption{SpecTrustedProxies(trusted)},
+			remoteAddr: "192.0.2.60:4567",
+			headers: map[string]string{
+				"Forwarded":          "proto=https;host=evil.example.com",
+				"X-Forwarded-Prefix": "/evil",
+			},
+			expectedHost:     "example.com",
+			expectedSchemes:  []string{"http"},
+			expectedBasePath: "/v2",
+		},
+		"spoofed values before trusted proxies": {
+			opts:       []SpecHandlerOption{SpecTrustedProxies(trusted)},
+			remoteAddr: "10.1.2.3:4567",
+			headers: map[string]string{
+				"X-Forwarded-For":    "203.0.113.7, 192.0.2.60, 10.0.0.1",
+				"X-Forwarded-Host":   "evil.example.com, api.example.com, proxy.local",
+				"X-Forwarded-Proto":  "http, https, http",
+				"X-Forwarded-Prefix": "/evil, /petstore, /",
+			},
+			expectedHost:     "api.example.com",
+			expectedSchemes:  []string{"https"},
+			expectedBasePath: "/petstore/v2",
+		},
+		"spoofed forwarded element before trusted proxies": {
+			opts:       []SpecHandlerOption{SpecTrustedProxies(trusted)},
+			remoteAddr: "10.1.2.3:4567",
+			headers: map[string]string{
+				"Forwarded": `host=evil.example.com;proto=http, for=192.0.2.60;host=api.example.com;proto=https, for="10.0.0.1:8080";host=internal`,
+			},
+			expectedHost:     "api.example.com",
+			expectedSchemes:  []string{"https"},
+			expectedBasePath: "/v2",
+		},
+		"multiple header lines": {
+			opts:       []SpecHandlerOption{SpecTrustedProxies(trusted)},
+			remoteAddr: "10.1.2.3:4567",
+			headerLines: map[string][]string{
+				"Forwarded": {
+					"host=evil.example.com",
+					"for=192.0.2.60;host=api.example.com;proto=https",
+				},
+			},
+			expectedHost:     "api.example.com",
+			expectedSchemes:  []string{"https"},
+			expectedBasePath: "/v2",
+		},
+		"unknown proxy addresses": {
+			opts:       []SpecHandlerOption{SpecTrustedProxies(trusted)},
+			remoteAddr: "10.1.2.3:4567",
+			headers: map[string]string{
+				"X-Forwarded-Host": "evil.example.com, api.example.com",
+			},
+			expectedHost:     "api.example.com",
+			expectedSchemes:  []string{"http"},
+			expectedBasePath: "/v2",
+		},
+		"invalid host and prefix": {
+			opts: []SpecHandlerOption{trustLocal},
+			headers: map[string]string{
+				"X-Forwarded-Host":   "api.example.com/<script>",
+				"X-Forwarded-Prefix": "/pet store",
+			},
+			expectedHost:     "example.com",
+			expectedSchemes:  []string{"http"},
+			expectedBasePath: "/v2",
+		},
+		"unsupported scheme": {
+			opts: []SpecHandlerOption{trustLocal},
+			headers: map[string]string{
+				"X-Forwarded-Proto": "javascript",
+				"X-Scheme":          "HTTPS",
+			},
+			expectedHost:     "example.com",
+			expectedSchemes:  []string{"https"},
+			expectedBasePath: "/v2",
+		},
+		"unsupported scheme in x-scheme": {
+			opts: []SpecHandlerOption{trustLocal},
+			headers: map[string]string{
+				"X-Scheme": "ftp",
+			},
+			expectedHost:     "example.com",
+			expectedSchemes:  []string{"http"},
+			expectedBasePath: "/v2",
+		},
+		"keep schemes": {
+			opts: []SpecHandlerOption{trustLocal, SpecKeepSchemes()},
+			headers: map[string]string{
+				"X-Forwarded-Proto": "https",
+			},
+			expectedHost:     "example.com",
+			expectedSchemes:  []string{"https", "http"},
+			expectedBasePath: "/v2",
+		},
+	}
+
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			h := NewDynamicSpecHandler(doc, tc.opts...)
+
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/openapi.json", nil)
+			if tc.remoteAddr != "" {
+				req.RemoteAddr = tc.remoteAddr
+			}
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			for k, lines := range tc.headerLines {
+				for _, v := range lines {
+					req.Header.Add(k, v)
+				}
+			}
+			h.ServeHTTP(rr, req)
+
+			var s spec.Swagger
+			if err := json.Unmarshal(rr.Body.Bytes(), &s); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			assert.Equal(t, tc.expectedHost, s.Host)
+			assert.Equal(t, tc.expectedSchemes, s.Schemes)
+			assert.Equal(t, tc.expectedBasePath, s.BasePath)
+		})
+	}
+}
+
+func TestSpecCache(t *testing.T) {
+	c := newSpecCache()
+
+	var renders []string
+	get := func(key string) {
+		c.get(key, func() *renderedSpec {
+			renders = append(renders, key)
+			return &renderedSpec{}
+		})
+	}
+
+	for i := 0; i < maxRenderedSpecs; i++ {
+		get(fmt.Sprint(i))
+	}
+	get("0")
+	assert.Len(t, renders, maxRenderedSpecs)
+
+	// The least recently used spec is evicted.
+	get("new")
+	get("0")
+	get("1")
+	assert.Equal(t, []string{"new", "1"}, renders[maxRenderedSpecs:])
+	assert.Equal(t, maxRenderedSpecs, c.lru.Len())
+	assert.Len(t, c.specs, maxRenderedSpecs)
+}
+
+func TestParseForwarded(t *testing.T) {
+	testCases := map[string]struct {
+		header   string
+		expected []map[string]string
+	}{
+		"single element": {
+			header:   "for=192.0.2.60;proto=http;by=203.0.113.43",
+			expected: []map[string]string{{"for": "192.0.2.60", "proto": "http", "by": "203.0.113.43"}},
+		},
+		"multiple elements": {
+			header: "for=192.0.2.43, for=198.51.100.17;host=other",
+			expected: []map[string]string{
+				{"for": "192.0.2.43"},
+				{"for": "198.51.100.17", "host": "other"},
+			},
+		},
+		"quoted values and case-insensitive names": {
+			header:   `For="[2001:db8:cafe::17]:4711"; Host="a\"b;c,d"`,
+			expected: []map[string]string{{"for": "[2001:db8:cafe::17]:4711", "host": `a"b;c,d`}},
+		},
+		"malformed": {
+			header:   "for;proto=https",
+			expected: []map[string]string{{"proto": "https"}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, parseForwarded(tc.header))
+		})
+	}
+}
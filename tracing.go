@@ -0,0 +1,224 @@
+package oas
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracer starts spans. It is a minimal interface to plug in a tracing
+// library, e.g. OpenTelemetry, with a small adapter.
+type Tracer interface {
+	// Start starts a span with the name as a child of the span in the
+	// context, if any, and returns the context with the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by Tracer.
+type Span interface {
+	// SetAttribute sets the attribute of the span. Values are strings,
+	// integers, or slices of strings.
+	SetAttribute(key string, value interface{})
+
+	// RecordError records the error occurred within the span.
+	RecordError(err error)
+
+	// End ends the span.
+	End()
+}
+
+// Tracing returns a middleware that starts a span per request named after
+// the operation id. The span has attributes:
+//
+//  - "http.method": the request method;
+//  - "http.route": the path template, including the base path;
+//  - "http.status_code": the response status code;
+//  - "oas.operation_id": the operation id;
+//  - "oas.tags": the operation tags;
+//  - "oas.validation.problems": the number of problems, if any were handled
+//    by problem handlers wrapped with NewTracingProblemHandler.
+//
+// To record time of validators as child spans, wrap them with
+// TracedMiddleware.
+func (b *ResolvingBasis) Tracing(tracer Tracer) Middleware {
+	routes := make(map[string]string)
+	basePath := strings.TrimSuffix(b.doc.BasePath(), "/")
+	for _, pathOps := range b.doc.Analyzer.Operations() {
+		for path, op := range pathOps {
+			routes[op.ID] = basePath + path
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &resolvingTracing{
+			next:   next,
+			tracer: tracer,
+			routes: routes,
+			strict: b.strict,
+		}
+	}
+}
+
+type resolvingTracing struct {
+	next   http.Handler
+	tracer Tracer
+
+	// routes are path templates by operation id.
+	routes map[string]string
+
+	// strict enforces tracing. If false, then requests without operation
+	// context are not traced.
+	strict bool
+}
+
+func (mw *resolvingTracing) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	oi, ok := getOperationInfo(req)
+	if !ok {
+		if mw.strict {
+			panic("tracing middleware: cannot find operation info in the request context")
+		}
+		mw.next.ServeHTTP(w, req)
+		return
+	}
+
+	ctx, span := mw.tracer.Start(req.Context(), oi.operation.ID)
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.route", mw.routes[oi.operation.ID])
+	span.SetAttribute("oas.operation_id", oi.operation.ID)
+	span.SetAttribute("oas.tags", oi.operation.Tags)
+
+	st := &traceState{span: span}
+	ctx = context.WithValue(ctx, contextKeyTraceState{}, st)
+
+	ww := newWrapResponseWriter(w, req.ProtoMajor)
+	mw.next.ServeHTTP(ww, req.WithContext(ctx))
+
+	status := ww.Status()
+	if status == 0 {
+		// Nothing has been written, so net/http responds with 200.
+		status = http.StatusOK
+	}
+	span.SetAttribute("http.status_code", status)
+	if n := st.problemCount(); n > 0 {
+		span.SetAttribute("oas.validation.problems", n)
+	}
+	span.End()
+}
+
+// traceState is the state of the request span.
+type traceState struct {
+	span Span
+
+	mu       sync.Mutex
+	problems int
+}
+
+func (st *traceState) addProblem() {
+	st.mu.Lock()
+	st.problems++
+	st.mu.Unlock()
+}
+
+func (st *traceState) problemCount() int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.problems
+}
+
+type contextKeyTraceState struct{}
+
+// TracedMiddleware returns the middleware that records each call of mw as
+// a child span with the name, e.g. "QueryValidator". The span covers the
+// whole call, including the work done after the next handler returns, e.g.
+// response validation. The time spent in the next handler is recorded as
+// "oas.next.duration_us" attribute in microseconds, so that the own time of
+// the middleware is the span duration minus that time.
+//
+// The span is not put into the request context, so the next handler gets
+// the request with context values added by the middleware, and spans it
+// starts are children of the request span, not of the middleware span.
+func TracedMiddleware(tracer Tracer, name string, mw Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		inner := mw(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			call, _ := req.Context().Value(contextKeyTracedCall{}).(*tracedCall)
+			if call == nil {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			// The next handler is not a part of the middleware, so its
+			// problems must not be recorded by the middleware span.
+			ctx := context.WithValue(req.Context(), contextKeyTracedCall{}, (*tracedCall)(nil))
+			start := time.Now()
+			next.ServeHTTP(w, req.WithContext(ctx))
+			call.addNext(time.Since(start))
+		}))
+
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, span := tracer.Start(req.Context(), name)
+			call := &tracedCall{span: span}
+			inner.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), contextKeyTracedCall{}, call)))
+			if d, ok := call.nextDuration(); ok {
+				span.SetAttribute("oas.next.duration_us", int64(d/time.Microsecond))
+			}
+			span.End()
+		})
+	}
+}
+
+// tracedCall is the call of the traced middleware.
+type tracedCall struct {
+	span Span
+
+	mu     sync.Mutex
+	next   time.Duration
+	called bool
+}
+
+// addNext adds the time spent in the next handler. The middleware may call
+// the next handler more than once, e.g. to retry.
+func (c *tracedCall) addNext(d time.Duration) {
+	c.mu.Lock()
+	c.next += d
+	c.called = true
+	c.mu.Unlock()
+}
+
+// nextDuration returns the time spent in the next handler, and whether
+// the next handler has been called.
+func (c *tracedCall) nextDuration() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.next, c.called
+}
+
+func (c *tracedCall) recordError(err error) {
+	c.span.RecordError(err)
+}
+
+type contextKeyTracedCall struct{}
+
+// NewTracingProblemHandler returns a problem handler that records problems
+// as errors of the current middleware span, see TracedMiddleware, and counts
+// them in the request span, see ResolvingBasis.Tracing. Problems are passed
+// to next.
+func NewTracingProblemHandler(next ProblemHandler) ProblemHandler {
+	return ProblemHandlerFunc(func(p Problem) {
+		ctx := p.Request().Context()
+		call, _ := ctx.Value(contextKeyTracedCall{}).(*tracedCall)
+		traced := call != nil
+		if traced {
+			call.recordError(p.Cause())
+		}
+		if st, ok := ctx.Value(contextKeyTraceState{}).(*traceState); ok {
+			st.addProblem()
+			if !traced {
+				// The problem is not recorded by a middleware span.
+				st.span.RecordError(p.Cause())
+			}
+		}
+		next.HandleProblem(p)
+	})
+}
@@ -0,0 +1,179 @@
+package oas
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeTracer struct {
+	mu    sync.Mutex
+	spans []*fakeSpan
+}
+
+type fakeSpan struct {
+	name   string
+	parent string
+	attrs  map[string]interface{}
+	errors []string
+	ended  bool
+}
+
+type contextKeyFakeSpan struct{}
+
+func (t *fakeTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &fakeSpan{name: name, attrs: make(map[string]interface{})}
+	if parent, ok := ctx.Value(contextKeyFakeSpan{}).(*fakeSpan); ok {
+		s.parent = parent.name
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return context.WithValue(ctx, contextKeyFakeSpan{}, s), s
+}
+
+func (s *fakeSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *fakeSpan) RecordError(err error)                      { s.errors = append(s.errors, err.Error()) }
+func (s *fakeSpan) End()                                       { s.ended = true }
+
+func TestResolvingBasis_Tracing(t *testing.T) {
+	doc := loadDocFile(t, "testdata/petstore_1.yml")
+
+	b := &ResolvingBasis{adapter: templateAdapter{}, doc: doc}
+	b.initCache()
+
+	newRouter := func(tracer Tracer, problemHandler ProblemHandler) http.Handler {
+		router := &templateRouter{}
+		err := b.OperationRouter(router).
+			WithOperationHandlers(map[string]http.Handler{
+				"getPetById": http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					_, span := tracer.Start(req.Context(), "handler")
+					defer span.End()
+					w.Header().Set("Content-Type", "application/json")
+					w.Write([]byte(`{"name":"Rex"}`)) // nolint
+				}),
+			}).
+			WithMiddleware(
+				b.Tracing(tracer),
+				TracedMiddleware(tracer, "QueryValidator", b.QueryValidator(WithProblemHandler(problemHandler))),
+				TracedMiddleware(tracer, "ResponseBodyValidator", b.ResponseBodyValidator(WithProblemHandler(problemHandler))),
+			).
+			Build()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return router
+	}
+
+	t.Run("request is rejected", func(t *testing.T) {
+		tracer := &fakeTracer{}
+		router := newRouter(tracer, NewTracingProblemHandler(newProblemHandlerErrorResponder()))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/pet/1?debug=maybe", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		if !assert.Len(t, tracer.spans, 2) {
+			return
+		}
+
+		root := tracer.spans[0]
+		assert.Equal(t, "getPetById", root.name)
+		assert.Equal(t, "", root.parent)
+		assert.Equal(t, map[string]interface{}{
+			"http.method":             http.MethodGet,
+			"http.route":              "/v2/pet/{petId}",
+			"http.status_code":        http.StatusBadRequest,
+			"oas.operation_id":        "getPetById",
+			"oas.tags":                []string{"pet"},
+			"oas.validation.problems": 1,
+		}, root.attrs)
+		assert.Empty(t, root.errors)
+		assert.True(t, root.ended)
+
+		validator := tracer.spans[1]
+		assert.Equal(t, "QueryValidator", validator.name)
+		assert.Equal(t, "getPetById", validator.parent)
+		assert.Len(t, validator.errors, 1)
+		assert.NotContains(t, validator.attrs, "oas.next.duration_us")
+		assert.True(t, validator.ended)
+	})
+
+	t.Run("response is validated", func(t *testing.T) {
+		tracer := &fakeTracer{}
+		router := newRouter(tracer, NewTracingProblemHandler(ProblemHandlerFunc(func(Problem) {})))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v2/pet/1", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var names []string
+		for _, s := range tracer.spans {
+			names = append(names, s.name+" < "+s.parent)
+			assert.True(t, s.ended, "span %s is not ended", s.name)
+		}
+		assert.Equal(t, []string{
+			"getPetById < ",
+			"QueryValidator < getPetById",
+			"ResponseBodyValidator < getPetById",
+			"handler < getPetById",
+		}, names)
+
+		assert.Equal(t, http.StatusOK, tracer.spans[0].attrs["http.status_code"])
+		assert.Equal(t, 1, tracer.spans[0].attrs["oas.validation.problems"])
+		assert.Empty(t, tracer.spans[1].errors)
+		assert.Len(t, tracer.spans[2].errors, 1)
+		for _, s := range tracer.spans[1:3] {
+			assert.IsType(t, int64(0), s.attrs["oas.next.duration_us"], "span %s", s.name)
+		}
+	})
+
+	t.Run("context values are passed to the next handler", func(t *testing.T) {
+		type contextKeyUser struct{}
+
+		tracer := &fakeTracer{}
+		auth := func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				ctx := context.WithValue(req.Context(), contextKeyUser{}, "alice")
+				next.ServeHTTP(w, req.WithContext(ctx))
+			})
+		}
+
+		var user interface{}
+		router := &templateRouter{}
+		err := b.OperationRouter(router).
+			WithOperationHandlers(map[string]http.Handler{
+				"getPetById": http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					user = req.Context().Value(contextKeyUser{})
+					_, span := tracer.Start(req.Context(), "handler")
+					span.End()
+				}),
+			}).
+			WithMiddleware(
+				b.Tracing(tracer),
+				TracedMiddleware(tracer, "Auth", auth),
+			).
+			Build()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v2/pet/1", nil))
+
+		assert.Equal(t, "alice", user)
+
+		var names []string
+		for _, s := range tracer.spans {
+			names = append(names, s.name+" < "+s.parent)
+		}
+		assert.Equal(t, []string{
+			"getPetById < ",
+			"Auth < getPetById",
+			"handler < getPetById",
+		}, names)
+	})
+}
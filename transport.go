@@ -0,0 +1,276 @@
+package oas
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+
+	"github.com/hypnoglow/oas2/convert"
+	"github.com/hypnoglow/oas2/internal/mediatype"
+	"github.com/hypnoglow/oas2/validate"
+)
+
+// NewValidatingTransport returns a RoundTripper that validates outgoing
+// requests, and responses to them, against the spec of the called API. If
+// next is nil, http.DefaultTransport is used.
+//
+// Requests are resolved to operations by method and path, which must include
+// the base path of the spec. Requests that do not resolve to any operation
+// are sent as is. Path and query parameters, required headers, Accept and
+// Content-Type headers and the body are validated before the request is
+// sent. Headers, Content-Type and the body of responses with status codes
+// defined for the operation are validated after the response is received.
+// Responses with other status codes are not validated, as OpenAPI does not
+// expect the spec to cover all of them. Only JSON bodies of responses with
+// a schema are read to validate them; other bodies are passed through as is.
+//
+// By default, problems are returned as errors: the invalid request is not
+// sent, and the invalid response is closed. With WithProblemHandler option,
+// problems are handled by the problem handler instead, and the request and
+// the response proceed. The problem handler gets problems with nil
+// ResponseWriter, see ProblemHandler.
+//
+// Only WithProblemHandler, WithProblemHandlerFunc and WithJSONSelectors
+// options apply to the transport. Other options are ignored, as the transport
+// never modifies requests and responses, nor writes responses itself.
+func NewValidatingTransport(doc *Document, next http.RoundTripper, opts ...MiddlewareOption) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	options := parseMiddlewareOptions(opts...)
+
+	b := &ResolvingBasis{doc: doc}
+	b.initCache()
+
+	defaults := make(map[string]*validate.SchemaValidator)
+	subtypes := validate.NewSubtypes(doc.Spec().Definitions, doc.OrigSpec().Definitions)
+	for id, oi := range b.cache {
+		if oi.operation.Responses == nil || oi.operation.Responses.Default == nil {
+			continue
+		}
+		if sch := oi.operation.Responses.Default.Schema; sch != nil {
+			defaults[id] = validate.NewResponseValidator(sch, validate.WithSubtypes(subtypes))
+		}
+	}
+
+	return &validatingTransport{
+		next:           next,
+		resolver:       NewPathResolver(doc),
+		cache:          b.cache,
+		defaults:       defaults,
+		jsonSelectors:  options.jsonSelectors,
+		problemHandler: options.problemHandler,
+	}
+}
+
+type validatingTransport struct {
+	next     http.RoundTripper
+	resolver *PathResolver
+	cache    map[string]operationInfo
+
+	// defaults are validators of default response schemas mapped by
+	// operation id.
+	defaults map[string]*validate.SchemaValidator
+
+	jsonSelectors []*regexp.Regexp
+
+	// problemHandler handles problems. If nil, problems are returned as
+	// errors.
+	problemHandler ProblemHandler
+}
+
+func (t *validatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id, pathParams, ok := t.resolver.ResolvePath(req.Method, req.URL.Path)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+	oi := t.cache[id]
+
+	// RoundTripper must not modify the request, so the body is read and
+	// set to the copy of the request.
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close() // nolint
+		if err != nil {
+			return nil, err
+		}
+		r := new(http.Request)
+		*r = *req
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if len(body) == 0 {
+			r.Body = http.NoBody
+		}
+		req = r
+	}
+
+	if errs := t.checkRequest(req, oi, pathParams); len(errs) > 0 {
+		me := newMultiError(fmt.Sprintf("request of operation %s does not match the spec", id), errs...)
+		if t.problemHandler == nil {
+			return nil, me
+		}
+		t.problemHandler.HandleProblem(Problem{kind: ProblemKindRequest, req: req, err: me})
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	errs, err := t.checkResponse(resp, id, oi)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		me := newMultiError(fmt.Sprintf("response %d of operation %s does not match the spec", resp.StatusCode, id), errs...)
+		if t.problemHandler == nil {
+			return nil, me
+		}
+		t.problemHandler.HandleProblem(Problem{kind: ProblemKindResponse, req: req, resp: resp, err: me})
+	}
+
+	return resp, nil
+}
+
+func (t *validatingTransport) checkRequest(req *http.Request, oi operationInfo, pathParams map[string]string) []error {
+	var errs []error
+
+	for _, p := range oi.params {
+		switch p.In {
+		case "path":
+			if _, err := convert.Primitive(pathParams[p.Name], p.Type, p.Format); err != nil {
+				errs = append(errs, fmt.Errorf("path parameter %s: %s", p.Name, err))
+			}
+		case "header":
+			if p.Required && req.Header.Get(p.Name) == "" {
+				errs = append(errs, fmt.Errorf("header %s is required", p.Name))
+			}
+		}
+	}
+
+	if qerrs := validate.Query(oi.params, req.URL.Query()); len(qerrs) > 0 {
+		errs = append(errs, newMultiError("query params do not match the schema", qerrs...))
+	}
+
+	if !mediatype.MatchAny(req.Header["Accept"], oi.produces) {
+		errs = append(errs, fmt.Errorf("Accept header of the request does not match any of the media types the operation can produce"))
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		for _, p := range oi.params {
+			if p.In == "body" && p.Required {
+				errs = append(errs, fmt.Errorf("request body is empty, but the operation requires non-empty body"))
+			}
+		}
+		return errs
+	}
+
+	contentType := req.Header.Get("Content-Type")
+	if !mediatype.Match(contentType, oi.consumes) {
+		errs = append(errs, fmt.Errorf("Content-Type header of the request does not match any of the media types the operation can consume"))
+		return errs
+	}
+	if oi.bodyValidator == nil || !matchSelectors(t.jsonSelectors, contentType) {
+		return errs
+	}
+
+	// The body has been read to the buffer, see RoundTrip.
+	body, _ := ioutil.ReadAll(req.Body)
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	data, err := decodeJSON(bytes.NewReader(body))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("request body contains invalid json: %s", err))
+		return errs
+	}
+	if verrs := oi.bodyValidator.Validate(data); len(verrs) > 0 {
+		errs = append(errs, newMultiError("request body does not match the schema", verrs...))
+	}
+	return errs
+}
+
+// checkResponse returns errors of the response. The body is read to the
+// buffer only if it is validated, otherwise it is passed through as is. The
+// error is returned if the body cannot be read.
+func (t *validatingTransport) checkResponse(resp *http.Response, id string, oi operationInfo) ([]error, error) {
+	responses := oi.operation.Responses
+	if responses == nil {
+		return nil, nil
+	}
+
+	responseSpec, ok := responses.StatusCodeResponses[resp.StatusCode]
+	validator := oi.responseValidators[resp.StatusCode]
+	if !ok {
+		if responses.Default == nil {
+			// If no response is explicitly defined for the status code,
+			// consider it is ok, the same as ResponseBodyValidator does.
+			return nil, nil
+		}
+		responseSpec = *responses.Default
+		validator = t.defaults[id]
+	}
+
+	var errs []error
+
+	for name, h := range responseSpec.Headers {
+		v := resp.Header.Get(name)
+		if v == "" || h.Type == "array" {
+			continue
+		}
+		if _, err := convert.Primitive(v, h.Type, h.Format); err != nil {
+			errs = append(errs, fmt.Errorf("header %s: %s", name, err))
+		}
+	}
+
+	if responseSpec.Schema == nil {
+		// The body is not read, so only its declared length is checked.
+		if resp.ContentLength > 0 {
+			errs = append(errs, fmt.Errorf("response has non-empty body, but the operation does not define response schema for code %d", resp.StatusCode))
+		}
+		return errs, nil
+	}
+	if resp.ContentLength == 0 {
+		return errs, nil
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !mediatype.Match(contentType, oi.produces) {
+		errs = append(errs, fmt.Errorf("Content-Type header of the response does not match any of the media types the operation can produce"))
+		return errs, nil
+	}
+	if !matchSelectors(t.jsonSelectors, contentType) {
+		return errs, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close() // nolint
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if len(body) == 0 {
+		return errs, nil
+	}
+
+	data, err := decodeJSON(bytes.NewReader(body))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("response body contains invalid json: %s", err))
+		return errs, nil
+	}
+	if verrs := validator.Validate(data); len(verrs) > 0 {
+		errs = append(errs, newMultiError("response body does not match the schema", verrs...))
+	}
+	return errs, nil
+}
+
+// matchSelectors checks if content type matches any selector.
+func matchSelectors(selectors []*regexp.Regexp, contentType string) bool {
+	for _, selector := range selectors {
+		if selector.MatchString(contentType) {
+			return true
+		}
+	}
+	return false
+}
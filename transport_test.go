@@ -0,0 +1,220 @@
+package oas
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestValidatingTransport(t *testing.T) {
+	doc := loadDocFile(t, "testdata/mock.yml")
+
+	respond := func(code int, contentType string, body string) roundTripperFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			rec := httptest.NewRecorder()
+			if contentType != "" {
+				rec.Header().Set("Content-Type", contentType)
+			}
+			rec.WriteHeader(code)
+			rec.WriteString(body) // nolint
+			return rec.Result(), nil
+		}
+	}
+
+	pet := `{"id":1,"name":"Rexxxxxxxx"}`
+
+	testCases := map[string]struct {
+		method string
+		url    string
+		body   string
+		next   roundTripperFunc
+
+		expectedError string
+	}{
+		"valid": {
+			method: http.MethodGet,
+			url:    "http://example.com/api/pets/1",
+			next:   respond(http.StatusOK, "application/json; charset=utf-8", pet),
+		},
+		"valid body": {
+			method: http.MethodPost,
+			url:    "http://example.com/api/pets",
+			body:   pet,
+			next:   respond(http.StatusCreated, "", ""),
+		},
+		"unknown operation": {
+			method: http.MethodGet,
+			url:    "http://example.com/api/owners",
+			next:   respond(http.StatusNotFound, "text/plain", "not found"),
+		},
+		"invalid path parameter": {
+			method:        http.MethodGet,
+			url:           "http://example.com/api/pets/rex",
+			expectedError: "request of operation getPet does not match the spec: path parameter id: ",
+		},
+		"invalid query": {
+			method:        http.MethodGet,
+			url:           "http://example.com/api/pets?limit=1000",
+			expectedError: "request of operation listPets does not match the spec: query params do not match the schema: ",
+		},
+		"missing body": {
+			method:        http.MethodPost,
+			url:           "http://example.com/api/pets",
+			expectedError: "request of operation addPet does not match the spec: request body is empty, but the operation requires non-empty body",
+		},
+		"invalid body": {
+			method:        http.MethodPost,
+			url:           "http://example.com/api/pets",
+			body:          `{"name":"Rex"}`,
+			expectedError: "request of operation addPet does not match the spec: request body does not match the schema: ",
+		},
+		"undefined status": {
+			method: http.MethodDelete,
+			url:    "http://example.com/api/pets/1",
+			next:   respond(http.StatusServiceUnavailable, "text/plain", "unavailable"),
+		},
+		"invalid response body": {
+			method:        http.MethodGet,
+			url:           "http://example.com/api/pets/1",
+			next:          respond(http.StatusOK, "application/json", `{"name":1}`),
+			expectedError: "response 200 of operation getPet does not match the spec: response body does not match the schema: ",
+		},
+		"invalid default response body": {
+			method:        http.MethodPost,
+			url:           "http://example.com/api/pets",
+			body:          pet,
+			next:          respond(http.StatusConflict, "application/json", `{"message":false}`),
+			expectedError: "response 409 of operation addPet does not match the spec: response body does not match the schema: ",
+		},
+		"invalid response content type": {
+			method:        http.MethodGet,
+			url:           "http://example.com/api/pets/1",
+			next:          respond(http.StatusOK, "text/plain", "Rex"),
+			expectedError: "response 200 of operation getPet does not match the spec: Content-Type header of the response does not match any of the media types the operation can produce",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var sent bool
+			next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				sent = true
+				return tc.next(req)
+			})
+
+			req := httptest.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			if tc.body == "" {
+				req.Body = http.NoBody
+			} else {
+				req.Header.Set("Content-Type", "application/json")
+			}
+
+			resp, err := NewValidatingTransport(doc, next).RoundTrip(req)
+			if tc.expectedError == "" {
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.True(t, sent)
+				return
+			}
+
+			if !assert.Error(t, err) {
+				return
+			}
+			assert.Nil(t, resp)
+			assert.True(t, strings.HasPrefix(err.Error(), tc.expectedError), err.Error())
+			assert.Equal(t, tc.next != nil, sent)
+		})
+	}
+}
+
+func TestValidatingTransport_responseBody(t *testing.T) {
+	doc := loadDocFile(t, "testdata/mock.yml")
+
+	var body io.ReadCloser
+	respond := func(code int, contentType string, b string) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			rec := httptest.NewRecorder()
+			rec.Header().Set("Content-Type", contentType)
+			rec.WriteHeader(code)
+			rec.WriteString(b) // nolint
+			resp := rec.Result()
+			body = resp.Body
+			return resp, nil
+		})
+	}
+
+	t.Run("not validated body is passed through", func(t *testing.T) {
+		tr := NewValidatingTransport(doc, respond(http.StatusBadGateway, "text/html", "<h1>Bad Gateway</h1>"))
+
+		resp, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com/api/pets/1", nil))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, body == resp.Body, "body is replaced")
+	})
+
+	t.Run("validated body is buffered", func(t *testing.T) {
+		tr := NewValidatingTransport(doc, respond(http.StatusOK, "application/json", `{"id":1,"name":"Rexxxxxxxx"}`))
+
+		resp, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "http://example.com/api/pets/1", nil))
+		if !assert.NoError(t, err) {
+			return
+		}
+		b, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, `{"id":1,"name":"Rexxxxxxxx"}`, string(b))
+	})
+}
+
+func TestValidatingTransport_problemHandler(t *testing.T) {
+	doc := loadDocFile(t, "testdata/mock.yml")
+
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := ioutil.ReadAll(req.Body)
+		assert.Equal(t, `{"name":"Rex"}`, string(body))
+
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json")
+		rec.Header().Set("Content-Length", "10")
+		rec.WriteHeader(http.StatusCreated)
+		rec.WriteString(`{"name":1}`) // nolint
+		return rec.Result(), nil
+	})
+
+	var problems []Problem
+	tr := NewValidatingTransport(doc, next, WithProblemHandlerFunc(func(p Problem) {
+		problems = append(problems, p)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/api/pets", strings.NewReader(`{"name":"Rex"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := tr.RoundTrip(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, `{"name":1}`, string(body))
+
+	if !assert.Len(t, problems, 2) {
+		return
+	}
+	assert.Contains(t, problems[0].Cause().Error(), "request of operation addPet does not match the spec")
+	assert.Nil(t, problems[0].Response())
+	assert.Contains(t, problems[1].Cause().Error(), "response 201 of operation addPet does not match the spec")
+	assert.Equal(t, resp, problems[1].Response())
+	for _, p := range problems {
+		assert.Nil(t, p.ResponseWriter())
+	}
+}
@@ -0,0 +1,169 @@
+package validate
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// ApplyQueryDefaults sets default values of query parameters from ps that are
+// missing in q. Array defaults are formatted according to the parameter
+// collectionFormat. It reports whether any value has been set.
+func ApplyQueryDefaults(ps []spec.Parameter, q url.Values) bool {
+	applied := false
+	for _, p := range ps {
+		if p.In != "query" || p.Default == nil {
+			continue
+		}
+		if _, ok := q[p.Name]; ok {
+			continue
+		}
+
+		q[p.Name] = formatParamDefault(p)
+		applied = true
+	}
+	return applied
+}
+
+// formatParamDefault returns the default value of the parameter as it would
+// be represented in the query.
+func formatParamDefault(p spec.Parameter) []string {
+	items, ok := p.Default.([]interface{})
+	if !ok {
+		return []string{formatDefault(p.Default)}
+	}
+
+	vals := make([]string, len(items))
+	for i, item := range items {
+		vals[i] = formatDefault(item)
+	}
+
+	switch p.CollectionFormat {
+	case "multi":
+		return vals
+	case "ssv":
+		return []string{strings.Join(vals, " ")}
+	case "tsv":
+		return []string{strings.Join(vals, "\t")}
+	case "pipes":
+		return []string{strings.Join(vals, "|")}
+	default: // "csv"
+		return []string{strings.Join(vals, ",")}
+	}
+}
+
+// formatDefault formats the default value. Numbers are formatted without
+// exponent, since defaults for integers are parsed as float64.
+func formatDefault(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// ApplyDefaults sets default values of properties that are missing in data,
+// including nested ones. Properties marked as readOnly are skipped for
+// request body validators. It reports whether any value has been set.
+func (v *SchemaValidator) ApplyDefaults(data interface{}) bool {
+	if v == nil {
+		return false
+	}
+
+	return applyDefaults(v.schema, data, v.subtypes, v.direction)
+}
+
+func applyDefaults(sch *spec.Schema, data interface{}, subtypes *Subtypes, dir direction) bool {
+	if sch == nil {
+		return false
+	}
+
+	applied := false
+	switch v := data.(type) {
+	case map[string]interface{}:
+		sch = concreteSchema(sch, v, subtypes)
+		for key, prop := range properties(sch) {
+			if _, ok := v[key]; ok || prop.Default == nil {
+				continue
+			}
+			if dir == directionRequest && prop.ReadOnly {
+				continue
+			}
+			v[key] = copyValue(prop.Default)
+			applied = true
+		}
+		for key, value := range v {
+			if applyDefaults(schemaStep(sch, key), value, subtypes, dir) {
+				applied = true
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			if applyDefaults(schemaStep(sch, strconv.Itoa(i)), value, subtypes, dir) {
+				applied = true
+			}
+		}
+	}
+	return applied
+}
+
+// concreteSchema returns the subtype definition for the polymorphic object,
+// or sch itself if the object is not polymorphic or subtype is unknown.
+func concreteSchema(sch *spec.Schema, obj map[string]interface{}, subtypes *Subtypes) *spec.Schema {
+	if subtypes == nil || sch.Discriminator == "" {
+		return sch
+	}
+
+	h := subtypes.hierarchy(sch)
+	if h == nil {
+		return sch
+	}
+
+	value, _ := obj[sch.Discriminator].(string)
+	if sub, ok := h.byValue[value]; ok {
+		return sub.schema
+	}
+	return sch
+}
+
+// properties returns all properties of the schema, including those
+// defined by allOf schemas.
+func properties(sch *spec.Schema) map[string]spec.Schema {
+	props := make(map[string]spec.Schema)
+	for i := range sch.AllOf {
+		for key, prop := range properties(&sch.AllOf[i]) {
+			props[key] = prop
+		}
+	}
+	for key, prop := range sch.Properties {
+		props[key] = prop
+	}
+	return props
+}
+
+// copyValue returns a deep copy of the value decoded from JSON, so the default
+// value in the schema is never modified through the data.
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[key] = copyValue(value)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, value := range v {
+			s[i] = copyValue(value)
+		}
+		return s
+	default:
+		return v
+	}
+}
@@ -0,0 +1,312 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/validate"
+)
+
+// KeywordDiscriminator is reported when the value of the discriminator
+// property does not match any known definition.
+const KeywordDiscriminator = "discriminator"
+
+// extDiscriminatorValue is a vendor extension that overrides the value of
+// the discriminator property for the definition. By default, the value is
+// the definition name.
+const extDiscriminatorValue = "x-discriminator-value"
+
+// NewSubtypes returns subtypes of polymorphic schemas found in definitions.
+//
+// A definition that defines a discriminator is a base of polymorphic schema.
+// Its subtypes are the base itself and definitions that inherit from it using
+// allOf with a reference to the base, e.g. "$ref: '#/definitions/Pet'",
+// directly or through other subtypes. Hierarchies are kept apart, so a
+// subtype of one base never matches another base, even if both use the same
+// discriminator property name.
+//
+// References are only present in the original definitions, so both original
+// and expanded definitions are required. Expanded definitions, i.e. ones that
+// contain no references, are used for validation. For a loaded document, these
+// are doc.OrigSpec().Definitions and doc.Spec().Definitions.
+func NewSubtypes(defs, orig spec.Definitions) *Subtypes {
+	st := &Subtypes{
+		bases: make(map[string]*hierarchy),
+	}
+
+	for name, def := range defs {
+		def := def
+		if def.Discriminator == "" {
+			continue
+		}
+		st.bases[name] = &hierarchy{
+			schema:        &def,
+			discriminator: def.Discriminator,
+			byValue:       make(map[string]*SchemaValidator),
+		}
+	}
+
+	for name, def := range defs {
+		def := def
+		value := name
+		for k, v := range def.Extensions {
+			if s, ok := v.(string); ok && strings.EqualFold(k, extDiscriminatorValue) {
+				value = s
+			}
+		}
+
+		var validator *SchemaValidator
+		for _, base := range ancestors(orig, name) {
+			h, ok := st.bases[base]
+			if !ok {
+				continue
+			}
+			if validator == nil {
+				validator = &SchemaValidator{
+					schema:    &def,
+					validator: validate.NewSchemaValidator(&def, nil, "", formatRegistry),
+					subtypes:  st,
+				}
+			}
+			h.byValue[value] = validator
+		}
+	}
+
+	return st
+}
+
+// Subtypes holds concrete definitions of polymorphic schemas, i.e. schemas
+// with discriminator, by the base definition and the discriminator value.
+// It is used by validators to validate data against the definition the data
+// actually represents.
+type Subtypes struct {
+	bases map[string]*hierarchy
+}
+
+// hierarchy holds subtypes of the base definition.
+type hierarchy struct {
+	schema        *spec.Schema
+	discriminator string
+	byValue       map[string]*SchemaValidator
+}
+
+// values returns known discriminator values of the hierarchy.
+func (h *hierarchy) values() []string {
+	values := make([]string, 0, len(h.byValue))
+	for value := range h.byValue {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// hierarchy returns the hierarchy of the base definition described by the
+// schema. The base is found by the discriminator property name, or, if several
+// bases share it, by comparing the schema with the base definitions.
+func (st *Subtypes) hierarchy(sch *spec.Schema) *hierarchy {
+	var candidates []string
+	for name, h := range st.bases {
+		if h.discriminator == sch.Discriminator {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 1 {
+		return st.bases[candidates[0]]
+	}
+
+	sort.Strings(candidates)
+	for _, name := range candidates {
+		if h := st.bases[name]; reflect.DeepEqual(h.schema, sch) {
+			return h
+		}
+	}
+	return nil
+}
+
+// ancestors returns the name of the definition and names of all definitions
+// it inherits from using allOf with a reference.
+func ancestors(orig spec.Definitions, name string) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	var walk func(name string)
+	walk = func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		names = append(names, name)
+
+		def, ok := orig[name]
+		if !ok {
+			return
+		}
+		for _, sch := range def.AllOf {
+			if parent, ok := definitionRef(sch.Ref); ok {
+				walk(parent)
+			}
+		}
+	}
+	walk(name)
+
+	return names
+}
+
+// definitionRef returns the definition name if the reference points to
+// a definition of the same document, e.g. "#/definitions/Pet".
+func definitionRef(ref spec.Ref) (string, bool) {
+	tokens := pointerTokens(strings.TrimPrefix(ref.String(), "#"))
+	if !strings.HasPrefix(ref.String(), "#/") || len(tokens) != 2 || tokens[0] != "definitions" {
+		return "", false
+	}
+	return tokens[1], true
+}
+
+// WithSubtypes returns a validator option that makes the validator to
+// validate polymorphic data against the concrete definition resolved by
+// the discriminator property value. Unknown discriminator values are reported
+// as errors.
+func WithSubtypes(st *Subtypes) ValidatorOption {
+	return func(v *SchemaValidator) {
+		v.subtypes = st
+	}
+}
+
+// checkSubtypes validates every polymorphic object in data against its
+// concrete definition.
+func (v *SchemaValidator) checkSubtypes(data interface{}, skipRoot bool) (errs ValidationErrors) {
+	walkNodes(v.schema, data, nil, func(sch *spec.Schema, data interface{}, tokens []string) bool {
+		if sch.Discriminator == "" || (skipRoot && len(tokens) == 0) {
+			return true
+		}
+
+		obj, ok := data.(map[string]interface{})
+		if !ok {
+			return true
+		}
+
+		value, ok := obj[sch.Discriminator].(string)
+		if !ok {
+			// Missing or invalid discriminator is reported by the schema
+			// validator, since discriminator property must be required.
+			return true
+		}
+
+		h := v.subtypes.hierarchy(sch)
+		if h == nil {
+			// Not a definition, so no subtypes are known.
+			return true
+		}
+
+		sub, ok := h.byValue[value]
+		if !ok {
+			propTokens := append(tokens[:len(tokens):len(tokens)], sch.Discriminator)
+			field := strings.Join(propTokens, ".")
+			errs = append(errs, valErr{
+				message:  fmt.Sprintf("%s in body has unknown discriminator value %q", field, value),
+				field:    field,
+				value:    value,
+				in:       "body",
+				pointer:  pointer(propTokens),
+				keyword:  KeywordDiscriminator,
+				expected: h.values(),
+			})
+			return false
+		}
+
+		sv := *sub
+		sv.direction = v.direction
+		errs = append(errs, prefixErrors(skipSummary(sv.validate(data, true)), tokens)...)
+		return false
+	})
+
+	return errs
+}
+
+// walkNodes calls fn for every value in data with the schema describing
+// the value, starting from the root. If fn returns false, nested values are
+// not walked.
+func walkNodes(sch *spec.Schema, data interface{}, tokens []string, fn func(sch *spec.Schema, data interface{}, tokens []string) bool) {
+	if sch == nil || !fn(sch, data, tokens) {
+		return
+	}
+
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			walkNodes(schemaStep(sch, key), value, append(tokens[:len(tokens):len(tokens)], key), fn)
+		}
+	case []interface{}:
+		for i, value := range v {
+			idx := fmt.Sprintf("%d", i)
+			walkNodes(schemaStep(sch, idx), value, append(tokens[:len(tokens):len(tokens)], idx), fn)
+		}
+	}
+}
+
+// skipSummary removes summary errors that have no location, such as
+// "must validate all the schemas (allOf)" reported for the subtype when
+// any of its allOf schemas fails. Such errors duplicate the detailed ones,
+// because the subtype is defined using allOf.
+func skipSummary(errs ValidationErrors) ValidationErrors {
+	var filtered ValidationErrors
+	for _, e := range errs {
+		if ve, ok := e.(valErr); ok && ve.field == "" && ve.keyword == "" && len(errs) > 1 {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	return filtered
+}
+
+// prefixErrors prepends the tokens to the location of each error.
+func prefixErrors(errs ValidationErrors, tokens []string) ValidationErrors {
+	if len(tokens) == 0 {
+		return errs
+	}
+
+	prefixed := make(ValidationErrors, len(errs))
+	for i, e := range errs {
+		ve, ok := e.(valErr)
+		if !ok {
+			prefixed[i] = e
+			continue
+		}
+
+		prefix := strings.Join(tokens, ".")
+		if ve.field != "" {
+			ve.message = strings.Replace(ve.message, ve.field, prefix+"."+ve.field, 1)
+			ve.field = prefix + "." + ve.field
+		} else {
+			ve.field = prefix
+		}
+		ve.pointer = pointer(tokens) + ve.pointer
+		prefixed[i] = ve
+	}
+	return prefixed
+}
+
+// unique returns errors without duplicates.
+func (es ValidationErrors) unique() ValidationErrors {
+	type key struct {
+		pointer, keyword, message string
+	}
+
+	seen := make(map[key]bool)
+	var errs ValidationErrors
+	for _, e := range es {
+		k := key{message: e.Error()}
+		if ve, ok := e.(valErr); ok {
+			k.pointer, k.keyword = ve.pointer, ve.keyword
+		}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		errs = append(errs, e)
+	}
+	return errs
+}
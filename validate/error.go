@@ -0,0 +1,532 @@
+package validate
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/errors"
+	"github.com/go-openapi/spec"
+	"github.com/go-openapi/validate"
+)
+
+// Validation keywords reported by ValidationError.Keyword.
+// Most of them match the corresponding JSON Schema keywords.
+const (
+	KeywordType                 = "type"
+	KeywordFormat               = "format"
+	KeywordRequired             = "required"
+	KeywordEnum                 = "enum"
+	KeywordMaximum              = "maximum"
+	KeywordMinimum              = "minimum"
+	KeywordMultipleOf           = "multipleOf"
+	KeywordMaxLength            = "maxLength"
+	KeywordMinLength            = "minLength"
+	KeywordPattern              = "pattern"
+	KeywordMaxItems             = "maxItems"
+	KeywordMinItems             = "minItems"
+	KeywordUniqueItems          = "uniqueItems"
+	KeywordAdditionalItems      = "additionalItems"
+	KeywordMaxProperties        = "maxProperties"
+	KeywordMinProperties        = "minProperties"
+	KeywordAdditionalProperties = "additionalProperties"
+	KeywordPatternProperties    = "patternProperties"
+)
+
+// codeKeywords maps go-openapi validation error codes to keywords.
+var codeKeywords = map[int32]string{
+	errors.InvalidTypeCode:           KeywordType,
+	errors.RequiredFailCode:          KeywordRequired,
+	errors.TooLongFailCode:           KeywordMaxLength,
+	errors.TooShortFailCode:          KeywordMinLength,
+	errors.PatternFailCode:           KeywordPattern,
+	errors.EnumFailCode:              KeywordEnum,
+	errors.MultipleOfFailCode:        KeywordMultipleOf,
+	errors.MaxFailCode:               KeywordMaximum,
+	errors.MinFailCode:               KeywordMinimum,
+	errors.UniqueFailCode:            KeywordUniqueItems,
+	errors.MaxItemsFailCode:          KeywordMaxItems,
+	errors.MinItemsFailCode:          KeywordMinItems,
+	errors.NoAdditionalItemsCode:     KeywordAdditionalItems,
+	errors.TooFewPropertiesCode:      KeywordMinProperties,
+	errors.TooManyPropertiesCode:     KeywordMaxProperties,
+	errors.UnallowedPropertyCode:     KeywordAdditionalProperties,
+	errors.FailedAllPatternPropsCode: KeywordPatternProperties,
+}
+
+// valErr implements ValidationError.
+type valErr struct {
+	message  string
+	field    string
+	value    interface{}
+	in       string
+	pointer  string
+	keyword  string
+	expected interface{}
+}
+
+func (v valErr) Error() string {
+	return v.message
+}
+
+func (v valErr) Field() string {
+	return v.field
+}
+
+func (v valErr) Value() interface{} {
+	return v.value
+}
+
+func (v valErr) In() string {
+	return v.in
+}
+
+func (v valErr) Pointer() string {
+	return v.pointer
+}
+
+func (v valErr) Keyword() string {
+	return v.keyword
+}
+
+func (v valErr) Expected() interface{} {
+	return v.expected
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v valErr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Message  string      `json:"message"`
+		Field    string      `json:"field,omitempty"`
+		In       string      `json:"in,omitempty"`
+		Pointer  string      `json:"pointer,omitempty"`
+		Keyword  string      `json:"keyword,omitempty"`
+		Expected interface{} `json:"expected,omitempty"`
+		Value    interface{} `json:"value,omitempty"`
+	}{
+		Message:  v.message,
+		Field:    v.field,
+		In:       v.in,
+		Pointer:  v.pointer,
+		Keyword:  v.keyword,
+		Expected: v.expected,
+		Value:    v.value,
+	})
+}
+
+// convertSchemaResult converts go-openapi schema validation result to
+// validation errors. The schema and the data are used to find the actual
+// value and the expected constraint for each error.
+func convertSchemaResult(res *validate.Result, sch *spec.Schema, data interface{}) ValidationErrors {
+	if res == nil {
+		return nil
+	}
+
+	return convertSchemaErrors(res.Errors, sch, data)
+}
+
+func convertSchemaErrors(es []error, sch *spec.Schema, data interface{}) (errs ValidationErrors) {
+	// revalidated holds paths of arrays which items were validated one by one.
+	revalidated := make(map[string]bool)
+
+	for _, e := range es {
+		ve, ok := e.(*errors.Validation)
+		if !ok {
+			errs = append(errs, valErr{message: e.Error(), in: "body"})
+			continue
+		}
+
+		tokens := splitPath(strings.TrimPrefix(ve.Name, "."), sch, data)
+
+		// go-openapi does not keep item index in the error path when array
+		// items are described by a single schema. In such case, validate
+		// array items one by one to get the exact location of the error.
+		if prefix, items, arr, ok := unindexedArray(sch, data, tokens); ok {
+			key := strings.Join(prefix, ".")
+			if revalidated[key] {
+				continue
+			}
+			revalidated[key] = true
+
+			for i, item := range arr {
+				path := "." + strings.Join(append(prefix, strconv.Itoa(i)), ".")
+				r := validate.NewSchemaValidator(items, nil, path, formatRegistry).Validate(item)
+				errs = append(errs, convertSchemaErrors(r.Errors, sch, data)...)
+			}
+			continue
+		}
+
+		errs = append(errs, newSchemaError(ve, tokens, sch, data))
+	}
+
+	return errs
+}
+
+// newSchemaError returns a new validation error located by the tokens.
+func newSchemaError(ve *errors.Validation, tokens []string, sch *spec.Schema, data interface{}) valErr {
+	field := strings.Join(tokens, ".")
+	keyword := codeKeywords[ve.Code()]
+
+	if keyword == KeywordAdditionalProperties || keyword == KeywordPatternProperties {
+		// Path points to the object, and the property name is the value.
+		if key, ok := ve.Value.(string); ok {
+			tokens = append(tokens, key)
+		}
+	}
+
+	value, _ := lookupValue(data, tokens)
+	sub := lookupSchema(sch, tokens)
+
+	if keyword == KeywordType && sub != nil && sub.Format != "" {
+		if _, isString := value.(string); isString && sub.Type.Contains("string") {
+			keyword = KeywordFormat
+		}
+	}
+
+	return valErr{
+		message:  strings.TrimPrefix(ve.Error(), "."),
+		field:    field,
+		value:    value,
+		in:       "body",
+		pointer:  pointer(tokens),
+		keyword:  keyword,
+		expected: schemaExpected(keyword, sub, ve),
+	}
+}
+
+// convertParamResult converts go-openapi parameter validation result to
+// validation errors.
+func convertParamResult(res *validate.Result, p *spec.Parameter, value interface{}) (errs ValidationErrors) {
+	if res == nil {
+		return errs
+	}
+
+	for _, e := range res.Errors {
+		ve, ok := e.(*errors.Validation)
+		if !ok {
+			errs = append(errs, valErr{message: e.Error(), field: p.Name, value: value, in: p.In})
+			continue
+		}
+
+		keyword := codeKeywords[ve.Code()]
+		if keyword == KeywordType && p.Format != "" {
+			keyword = KeywordFormat
+		}
+
+		errs = append(errs, valErr{
+			message:  e.Error(),
+			field:    p.Name,
+			value:    value,
+			in:       p.In,
+			keyword:  keyword,
+			expected: expected(keyword, paramConstraints(p), ve),
+		})
+	}
+
+	return errs
+}
+
+// constraints are the validations applicable to either a schema or
+// a parameter.
+type constraints struct {
+	spec.CommonValidations
+	typ    interface{}
+	format string
+}
+
+func paramConstraints(p *spec.Parameter) constraints {
+	return constraints{
+		CommonValidations: p.CommonValidations,
+		typ:               p.Type,
+		format:            p.Format,
+	}
+}
+
+func schemaConstraints(sch *spec.Schema) constraints {
+	c := constraints{
+		CommonValidations: spec.CommonValidations{
+			Maximum:          sch.Maximum,
+			ExclusiveMaximum: sch.ExclusiveMaximum,
+			Minimum:          sch.Minimum,
+			ExclusiveMinimum: sch.ExclusiveMinimum,
+			MaxLength:        sch.MaxLength,
+			MinLength:        sch.MinLength,
+			Pattern:          sch.Pattern,
+			MaxItems:         sch.MaxItems,
+			MinItems:         sch.MinItems,
+			UniqueItems:      sch.UniqueItems,
+			MultipleOf:       sch.MultipleOf,
+			Enum:             sch.Enum,
+		},
+		format: sch.Format,
+	}
+
+	switch len(sch.Type) {
+	case 0:
+	case 1:
+		c.typ = sch.Type[0]
+	default:
+		c.typ = []string(sch.Type)
+	}
+
+	return c
+}
+
+func schemaExpected(keyword string, sch *spec.Schema, ve *errors.Validation) interface{} {
+	switch keyword {
+	case KeywordRequired:
+		return true
+	case KeywordAdditionalProperties, KeywordAdditionalItems:
+		return false
+	case KeywordMaxProperties, KeywordMinProperties:
+		return ve.Value
+	}
+
+	if sch == nil {
+		return nil
+	}
+
+	return expected(keyword, schemaConstraints(sch), ve)
+}
+
+// expected returns the constraint that has been violated.
+func expected(keyword string, c constraints, ve *errors.Validation) interface{} {
+	switch keyword {
+	case KeywordType:
+		return c.typ
+	case KeywordFormat:
+		return c.format
+	case KeywordRequired:
+		return true
+	case KeywordEnum:
+		if ve.Values != nil {
+			return ve.Values
+		}
+		return c.Enum
+	case KeywordMaximum:
+		return derefFloat(c.Maximum)
+	case KeywordMinimum:
+		return derefFloat(c.Minimum)
+	case KeywordMultipleOf:
+		return derefFloat(c.MultipleOf)
+	case KeywordMaxLength:
+		return derefInt(c.MaxLength)
+	case KeywordMinLength:
+		return derefInt(c.MinLength)
+	case KeywordPattern:
+		return c.Pattern
+	case KeywordMaxItems:
+		return derefInt(c.MaxItems)
+	case KeywordMinItems:
+		return derefInt(c.MinItems)
+	case KeywordUniqueItems:
+		return true
+	default:
+		return nil
+	}
+}
+
+func derefFloat(f *float64) interface{} {
+	if f == nil {
+		return nil
+	}
+	return *f
+}
+
+func derefInt(i *int64) interface{} {
+	if i == nil {
+		return nil
+	}
+	return *i
+}
+
+// splitPath splits go-openapi validation path like "tags.0.name" into
+// tokens. Property names may contain dots, so the path is matched against
+// the data and the schema rather than split on every dot.
+func splitPath(path string, sch *spec.Schema, data interface{}) []string {
+	if path == "" {
+		return nil
+	}
+	if tokens, ok := matchPath(path, sch, data); ok {
+		return tokens
+	}
+	return strings.Split(path, ".")
+}
+
+// matchPath splits the path into tokens that denote existing properties or
+// items, preferring the longest token at each step.
+func matchPath(path string, sch *spec.Schema, data interface{}) ([]string, bool) {
+	for i := len(path); i > 0; i = strings.LastIndex(path[:i], ".") {
+		token := path[:i]
+		if !hasToken(sch, data, token) {
+			continue
+		}
+		if i == len(path) {
+			return []string{token}, true
+		}
+
+		var sub *spec.Schema
+		if sch != nil {
+			sub = schemaStep(sch, token)
+		}
+		value, _ := lookupValue(data, []string{token})
+		if rest, ok := matchPath(path[i+1:], sub, value); ok {
+			return append([]string{token}, rest...), true
+		}
+	}
+	return nil, false
+}
+
+// hasToken returns true if the token denotes a property or an item of the
+// data, or a property declared by the schema.
+func hasToken(sch *spec.Schema, data interface{}, token string) bool {
+	if _, ok := lookupValue(data, []string{token}); ok {
+		return true
+	}
+	return hasProperty(sch, token)
+}
+
+func hasProperty(sch *spec.Schema, name string) bool {
+	if sch == nil {
+		return false
+	}
+	if _, ok := sch.Properties[name]; ok {
+		return true
+	}
+	for i := range sch.AllOf {
+		if hasProperty(&sch.AllOf[i], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// pointer returns a JSON Pointer (RFC 6901) built from the tokens.
+func pointer(tokens []string) string {
+	p := ""
+	for _, t := range tokens {
+		t = strings.Replace(t, "~", "~0", -1)
+		t = strings.Replace(t, "/", "~1", -1)
+		p += "/" + t
+	}
+	return p
+}
+
+// pointerTokens splits JSON Pointer (RFC 6901) into unescaped tokens.
+func pointerTokens(p string) []string {
+	if p == "" {
+		return nil
+	}
+
+	tokens := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	for i, t := range tokens {
+		t = strings.Replace(t, "~1", "/", -1)
+		tokens[i] = strings.Replace(t, "~0", "~", -1)
+	}
+	return tokens
+}
+
+// lookupValue returns the value located by the tokens in the data.
+func lookupValue(data interface{}, tokens []string) (interface{}, bool) {
+	for _, t := range tokens {
+		switch v := data.(type) {
+		case map[string]interface{}:
+			val, ok := v[t]
+			if !ok {
+				return nil, false
+			}
+			data = val
+		case []interface{}:
+			i, err := strconv.Atoi(t)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			data = v[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return data, true
+}
+
+// lookupSchema returns the schema that applies to the value located by the
+// tokens. It returns nil if the schema cannot be found.
+func lookupSchema(sch *spec.Schema, tokens []string) *spec.Schema {
+	for _, t := range tokens {
+		if sch == nil {
+			return nil
+		}
+		sch = schemaStep(sch, t)
+	}
+
+	return sch
+}
+
+// schemaStep returns the schema of the property or item denoted by the token.
+func schemaStep(sch *spec.Schema, token string) *spec.Schema {
+	if prop, ok := sch.Properties[token]; ok {
+		return &prop
+	}
+
+	if sch.Items != nil {
+		if i, err := strconv.Atoi(token); err == nil {
+			if sch.Items.Schema != nil {
+				return sch.Items.Schema
+			}
+			if i >= 0 && i < len(sch.Items.Schemas) {
+				return &sch.Items.Schemas[i]
+			}
+		}
+	}
+
+	for i := range sch.AllOf {
+		if found := schemaStep(&sch.AllOf[i], token); found != nil {
+			return found
+		}
+	}
+
+	if sch.AdditionalProperties != nil && sch.AdditionalProperties.Schema != nil {
+		return sch.AdditionalProperties.Schema
+	}
+
+	return nil
+}
+
+// unindexedArray walks the data by the tokens and checks if there is an array
+// that is not followed by an item index. If so, it returns path to the array,
+// its items schema and the array itself.
+func unindexedArray(sch *spec.Schema, data interface{}, tokens []string) ([]string, *spec.Schema, []interface{}, bool) {
+	for i, t := range tokens {
+		if sch == nil {
+			return nil, nil, nil, false
+		}
+
+		switch v := data.(type) {
+		case map[string]interface{}:
+			val, ok := v[t]
+			if !ok {
+				return nil, nil, nil, false
+			}
+			data = val
+		case []interface{}:
+			idx, err := strconv.Atoi(t)
+			if err != nil {
+				if sch.Items == nil || sch.Items.Schema == nil {
+					return nil, nil, nil, false
+				}
+				return tokens[:i:i], sch.Items.Schema, v, true
+			}
+			if idx < 0 || idx >= len(v) {
+				return nil, nil, nil, false
+			}
+			data = v[idx]
+		default:
+			return nil, nil, nil, false
+		}
+
+		sch = schemaStep(sch, t)
+	}
+
+	return nil, nil, nil, false
+}
@@ -0,0 +1,91 @@
+package validate
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/go-openapi/spec"
+)
+
+// normalizeNumbers returns data with json.Number values converted to float64,
+// except for integers described by integer schemas.
+//
+// Data decoded using json.Decoder.UseNumber keeps large integers exact. The
+// underlying validator converts json.Number to int64 if the schema expects
+// an integer, but otherwise handles it as a string, so numbers are converted
+// to float64, as if data were decoded without UseNumber, everywhere else.
+// Data is copied only if it contains json.Number values.
+func normalizeNumbers(sch *spec.Schema, data interface{}) interface{} {
+	if !hasNumbers(data) {
+		return data
+	}
+	return convertNumbers(sch, data)
+}
+
+func hasNumbers(data interface{}) bool {
+	switch v := data.(type) {
+	case json.Number:
+		return true
+	case map[string]interface{}:
+		for _, value := range v {
+			if hasNumbers(value) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if hasNumbers(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func convertNumbers(sch *spec.Schema, data interface{}) interface{} {
+	switch v := data.(type) {
+	case json.Number:
+		if _, err := v.Int64(); err == nil && isInteger(sch) {
+			return v
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			obj[key] = convertNumbers(numberStep(sch, key), value)
+		}
+		return obj
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for i, value := range v {
+			arr[i] = convertNumbers(numberStep(sch, strconv.Itoa(i)), value)
+		}
+		return arr
+	}
+	return data
+}
+
+func numberStep(sch *spec.Schema, token string) *spec.Schema {
+	if sch == nil {
+		return nil
+	}
+	return schemaStep(sch, token)
+}
+
+// isInteger checks if the schema, or any of its allOf schemas, expects
+// an integer.
+func isInteger(sch *spec.Schema) bool {
+	if sch == nil {
+		return false
+	}
+	if sch.Type.Contains("integer") {
+		return true
+	}
+	for i := range sch.AllOf {
+		if isInteger(&sch.AllOf[i]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// Validation keywords that control property access.
+const (
+	// KeywordReadOnly is reported when a property marked as readOnly is
+	// sent in the request body.
+	KeywordReadOnly = "readOnly"
+
+	// KeywordWriteOnly is reported when a property marked as write-only
+	// leaks into the response body. OpenAPI 2.0 does not support writeOnly
+	// keyword, so properties are marked with "x-writeOnly: true" extension.
+	KeywordWriteOnly = "writeOnly"
+)
+
+// ExtWriteOnly is a vendor extension that marks schema property as write-only.
+const ExtWriteOnly = "x-writeOnly"
+
+// IsWriteOnly checks if the schema is marked as write-only. Schema extension
+// names keep their case, so they are matched case-insensitively.
+func IsWriteOnly(sch *spec.Schema) bool {
+	for k, v := range sch.Extensions {
+		if strings.EqualFold(k, ExtWriteOnly) {
+			b, ok := v.(bool)
+			return ok && b
+		}
+	}
+	return false
+}
+
+// checkReadOnly returns errors for every readOnly property present in data.
+func checkReadOnly(sch *spec.Schema, data interface{}) (errs ValidationErrors) {
+	walkProperties(sch, data, nil, func(prop *spec.Schema, value interface{}, tokens []string) {
+		if !prop.ReadOnly {
+			return
+		}
+		errs = append(errs, accessError(KeywordReadOnly, tokens, value, "%s in body is read-only"))
+	})
+	return errs
+}
+
+// checkWriteOnly returns errors for every write-only property present in data.
+func checkWriteOnly(sch *spec.Schema, data interface{}) (errs ValidationErrors) {
+	walkProperties(sch, data, nil, func(prop *spec.Schema, value interface{}, tokens []string) {
+		if !IsWriteOnly(prop) {
+			return
+		}
+		errs = append(errs, accessError(KeywordWriteOnly, tokens, value, "%s in body is write-only"))
+	})
+	return errs
+}
+
+func accessError(keyword string, tokens []string, value interface{}, format string) valErr {
+	field := strings.Join(tokens, ".")
+	return valErr{
+		message:  fmt.Sprintf(format, field),
+		field:    field,
+		value:    value,
+		in:       "body",
+		pointer:  pointer(tokens),
+		keyword:  keyword,
+		expected: false,
+	}
+}
+
+// skipReadOnlyRequired removes errors about missing required properties that
+// are marked as readOnly, because such properties are never sent in requests.
+func skipReadOnlyRequired(errs ValidationErrors, sch *spec.Schema) ValidationErrors {
+	var filtered ValidationErrors
+	for _, e := range errs {
+		if ve, ok := e.(valErr); ok && ve.keyword == KeywordRequired {
+			if prop := lookupSchema(sch, pointerTokens(ve.pointer)); prop != nil && prop.ReadOnly {
+				continue
+			}
+		}
+		filtered = append(filtered, e)
+	}
+	return filtered
+}
+
+// stripReadOnly removes readOnly properties from data in place. It reports
+// whether any property has been removed.
+func stripReadOnly(sch *spec.Schema, data interface{}) bool {
+	type location struct {
+		obj map[string]interface{}
+		key string
+	}
+
+	var found []location
+	walkObjects(sch, data, func(props map[string]*spec.Schema, obj map[string]interface{}) {
+		for key, prop := range props {
+			if _, ok := obj[key]; ok && prop.ReadOnly {
+				found = append(found, location{obj, key})
+			}
+		}
+	})
+
+	for _, loc := range found {
+		delete(loc.obj, loc.key)
+	}
+
+	return len(found) > 0
+}
+
+// walkProperties calls fn for every property present in data that is described
+// by the schema, including nested ones.
+func walkProperties(sch *spec.Schema, data interface{}, tokens []string, fn func(prop *spec.Schema, value interface{}, tokens []string)) {
+	if sch == nil {
+		return
+	}
+
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			prop := schemaStep(sch, key)
+			if prop == nil {
+				continue
+			}
+			propTokens := append(tokens[:len(tokens):len(tokens)], key)
+			fn(prop, value, propTokens)
+			walkProperties(prop, value, propTokens, fn)
+		}
+	case []interface{}:
+		for i, value := range v {
+			idx := fmt.Sprintf("%d", i)
+			walkProperties(schemaStep(sch, idx), value, append(tokens[:len(tokens):len(tokens)], idx), fn)
+		}
+	}
+}
+
+// walkObjects calls fn for every object in data with the schemas of
+// the object properties.
+func walkObjects(sch *spec.Schema, data interface{}, fn func(props map[string]*spec.Schema, obj map[string]interface{})) {
+	if sch == nil {
+		return
+	}
+
+	switch v := data.(type) {
+	case map[string]interface{}:
+		props := make(map[string]*spec.Schema)
+		for key, value := range v {
+			prop := schemaStep(sch, key)
+			if prop == nil {
+				continue
+			}
+			props[key] = prop
+			walkObjects(prop, value, fn)
+		}
+		fn(props, v)
+	case []interface{}:
+		for i, value := range v {
+			walkObjects(schemaStep(sch, fmt.Sprintf("%d", i)), value, fn)
+		}
+	}
+}
@@ -2,23 +2,26 @@
 // response data against OpenAPI Specification parameter and schema definitions.
 //
 // Note that errors returned from validation functions are generally of type
-// Error, so they can be asserted to corresponding interface(s) to retrieve
-// error's field and value.
+// ValidationError, so they can be asserted to corresponding interface(s) to
+// retrieve error's field and value, or to DetailedValidationError to retrieve
+// error's location, violated keyword, etc.
 //  errs := validate.Query(ps, q)
 //  for _, err := range errs {
-//      if e, ok := err.(validate.Error) {
+//      if e, ok := err.(validate.ValidationError) {
 //          field, value := e.Field(), e.Value()
 //          // ...
 //      }
 //  }
+//
+// Validation errors are also JSON-marshalable, so they can be written to the
+// response as is.
 package validate
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
-	"strings"
 
-	"github.com/go-openapi/errors"
 	"github.com/go-openapi/spec"
 	"github.com/go-openapi/validate"
 
@@ -44,13 +47,79 @@ func Query(ps []spec.Parameter, q url.Values) []error {
 
 	// Check that no additional parameters passed.
 	for name := range q {
-		errs = append(errs, ValidationErrorf(name, q.Get(name), "parameter %s is unknown", name))
+		errs = append(errs, valErr{
+			message:  fmt.Sprintf("parameter %s is unknown", name),
+			field:    name,
+			value:    q.Get(name),
+			in:       "query",
+			keyword:  KeywordAdditionalProperties,
+			expected: false,
+		})
+	}
+
+	return errs.Errors()
+}
+
+// Path validates request path parameters by spec and returns errors
+// if any. The params map holds unescaped path parameter values by name.
+func Path(ps []spec.Parameter, params map[string]string) []error {
+	errs := make(ValidationErrors, 0)
+
+	for _, p := range ps {
+		if p.In != "path" {
+			continue
+		}
+
+		v, ok := params[p.Name]
+		var vals []string
+		if ok {
+			vals = []string{v}
+		}
+		errs = append(errs, validateParam(p, vals, ok)...)
+	}
+
+	return errs.Errors()
+}
+
+// Header validates request header parameters by spec and returns errors
+// if any. Headers not described in the spec are allowed.
+func Header(ps []spec.Parameter, h http.Header) []error {
+	errs := make(ValidationErrors, 0)
+
+	for _, p := range ps {
+		if p.In != "header" {
+			continue
+		}
+
+		vals, ok := h[http.CanonicalHeaderKey(p.Name)]
+		errs = append(errs, validateParam(p, vals, ok)...)
+	}
+
+	return errs.Errors()
+}
+
+// FormData validates request formData parameters by spec and returns errors
+// if any. Parameters of type file are not validated, as form holds only
+// the values of the form fields.
+func FormData(ps []spec.Parameter, form url.Values) []error {
+	errs := make(ValidationErrors, 0)
+
+	for _, p := range ps {
+		if p.In != "formData" || p.Type == "file" {
+			continue
+		}
+
+		vals, ok := form[p.Name]
+		errs = append(errs, validateParam(p, vals, ok)...)
 	}
 
 	return errs.Errors()
 }
 
 // Body validates request body by spec and returns errors if any.
+//
+// Properties marked as readOnly are not allowed in the request body, and
+// they are not considered required.
 func Body(ps []spec.Parameter, data interface{}) []error {
 	errs := make(ValidationErrors, 0)
 
@@ -66,11 +135,134 @@ func Body(ps []spec.Parameter, data interface{}) []error {
 	return errs.Errors()
 }
 
+// Response validates response body by schema and returns errors if any.
+//
+// Properties marked as write-only with "x-writeOnly: true" vendor extension
+// are not allowed in the response body.
+func Response(sch *spec.Schema, data interface{}) []error {
+	return NewResponseValidator(sch).Validate(data)
+}
+
 // BySchema validates data by spec and returns errors if any.
+//
+// BySchema builds a new schema validator on each call. To validate data
+// against the same schema repeatedly, use SchemaValidator instead.
 func BySchema(sch *spec.Schema, data interface{}) []error {
 	return validatebySchema(sch, data).Errors()
 }
 
+// NewSchemaValidator returns a new validator for the schema, which can be
+// reused to validate any number of values. Only the top-level validator is
+// built once: validators of nested properties and items are still built by
+// go-openapi on every call, so reusing it saves little compared to BySchema.
+// If sch is nil, the returned validator accepts any data.
+func NewSchemaValidator(sch *spec.Schema, opts ...ValidatorOption) *SchemaValidator {
+	return newSchemaValidator(sch, directionNone, opts)
+}
+
+// NewBodyValidator returns a new validator for the body parameter from ps.
+// If there is no body parameter, nil is returned. Note that nil validator
+// is valid and accepts any data.
+//
+// The validator does not allow properties marked as readOnly, and
+// does not consider them required. See Body.
+func NewBodyValidator(ps []spec.Parameter, opts ...ValidatorOption) *SchemaValidator {
+	for _, p := range ps {
+		if p.In == "body" {
+			return newSchemaValidator(p.Schema, directionRequest, opts)
+		}
+	}
+
+	return nil
+}
+
+// NewResponseValidator returns a new validator for the response schema.
+// The validator does not allow properties marked as write-only with
+// "x-writeOnly: true" vendor extension. See Response.
+func NewResponseValidator(sch *spec.Schema, opts ...ValidatorOption) *SchemaValidator {
+	return newSchemaValidator(sch, directionResponse, opts)
+}
+
+func newSchemaValidator(sch *spec.Schema, dir direction, opts []ValidatorOption) *SchemaValidator {
+	if sch == nil {
+		return nil
+	}
+
+	v := &SchemaValidator{
+		schema:    sch,
+		validator: validate.NewSchemaValidator(sch, nil, "", formatRegistry),
+		direction: dir,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// ValidatorOption is an option for schema validators.
+type ValidatorOption func(*SchemaValidator)
+
+// direction defines whether data is sent in the request or in the response.
+type direction int
+
+const (
+	directionNone direction = iota
+	directionRequest
+	directionResponse
+)
+
+// SchemaValidator validates data against a schema.
+// It is safe for concurrent use by multiple goroutines.
+type SchemaValidator struct {
+	schema    *spec.Schema
+	validator *validate.SchemaValidator
+	direction direction
+	subtypes  *Subtypes
+}
+
+// Validate validates data by the schema and returns errors if any.
+//
+// Numbers in data may be json.Number, e.g. decoded using
+// json.Decoder.UseNumber, so that integers beyond 2^53 are validated exactly.
+func (v *SchemaValidator) Validate(data interface{}) []error {
+	if v == nil {
+		return nil
+	}
+
+	return v.validate(normalizeNumbers(v.schema, data), false).Errors()
+}
+
+// validate validates data by the schema. If skipRoot is true, the data
+// itself is not resolved to a subtype, which is the case when the validator
+// is already the one for the concrete definition.
+func (v *SchemaValidator) validate(data interface{}, skipRoot bool) ValidationErrors {
+	errs := convertSchemaResult(v.validator.Validate(data), v.schema, data)
+
+	switch v.direction {
+	case directionRequest:
+		errs = skipReadOnlyRequired(errs, v.schema)
+		errs = append(errs, checkReadOnly(v.schema, data)...)
+	case directionResponse:
+		errs = append(errs, checkWriteOnly(v.schema, data)...)
+	}
+
+	if v.subtypes != nil {
+		errs = append(errs, v.checkSubtypes(data, skipRoot)...).unique()
+	}
+
+	return errs
+}
+
+// StripReadOnly removes properties marked as readOnly from data in place.
+// It reports whether any property has been removed.
+func (v *SchemaValidator) StripReadOnly(data interface{}) bool {
+	if v == nil {
+		return false
+	}
+
+	return stripReadOnly(v.schema, data)
+}
+
 // ValidationError describes validation error.
 type ValidationError interface {
 	error
@@ -83,6 +275,35 @@ type ValidationError interface {
 	Value() interface{}
 }
 
+// DetailedValidationError is a ValidationError that also describes the
+// location of the error and the violated constraint. Errors returned by
+// validators of this package implement it, check with a type assertion:
+//  if e, ok := err.(validate.DetailedValidationError); ok {
+//      pointer, keyword := e.Pointer(), e.Keyword()
+//      // ...
+//  }
+type DetailedValidationError interface {
+	ValidationError
+
+	// In returns location of the parameter where error occurred,
+	// e.g. "query" or "body".
+	In() string
+
+	// Pointer returns a JSON Pointer (RFC 6901) to the body element where
+	// error occurred, e.g. "/tags/0/name". It is empty for errors that
+	// occurred outside of the body, as well as for the body root.
+	Pointer() string
+
+	// Keyword returns the validation keyword that has been violated,
+	// e.g. "required", "maxLength" or "enum". See Keyword* constants.
+	// It is empty if error is not bound to any keyword.
+	Keyword() string
+
+	// Expected returns the expected constraint of the violated keyword,
+	// e.g. 10 for "maxLength" or a list of allowed values for "enum".
+	Expected() interface{}
+}
+
 // ValidationErrorf returns a new formatted ValidationError.
 func ValidationErrorf(field string, value interface{}, format string, args ...interface{}) ValidationError {
 	return valErr{
@@ -108,62 +329,59 @@ func (es ValidationErrors) Errors() []error {
 	return errs
 }
 
-func validateQueryParam(p spec.Parameter, q url.Values) (errs ValidationErrors) {
-	_, ok := q[p.Name]
+func validateQueryParam(p spec.Parameter, q url.Values) ValidationErrors {
+	vals, ok := q[p.Name]
+	return validateParam(p, vals, ok)
+}
+
+// validateParam validates values of the non-body parameter p. The ok flag
+// reports whether the parameter is present in the request.
+func validateParam(p spec.Parameter, vals []string, ok bool) (errs ValidationErrors) {
 	if !ok {
 		if p.Required {
-			errs = append(errs, ValidationErrorf(p.Name, nil, "param %s is required", p.Name))
+			errs = append(errs, valErr{
+				message:  fmt.Sprintf("param %s is required", p.Name),
+				field:    p.Name,
+				in:       p.In,
+				keyword:  KeywordRequired,
+				expected: true,
+			})
 		}
 		return errs
 	}
 
-	value, err := convert.Parameter(q[p.Name], &p)
+	value, err := convert.Parameter(vals, &p)
 	if err != nil {
-		// TODO: q.Get(p.Name) relies on type that is not array/file.
-		return append(errs, ValidationErrorf(p.Name, q.Get(p.Name), "param %s: %s", p.Name, err))
-	}
-
-	if result := validate.NewParamValidator(&p, formatRegistry).Validate(value); result != nil {
-		for _, e := range result.Errors {
-			errs = append(errs, ValidationErrorf(p.Name, value, e.Error()))
+		var raw string
+		if len(vals) > 0 {
+			// TODO: this relies on type that is not array/file.
+			raw = vals[0]
 		}
+		return append(errs, valErr{
+			message:  fmt.Sprintf("param %s: %s", p.Name, err),
+			field:    p.Name,
+			value:    raw,
+			in:       p.In,
+			keyword:  KeywordType,
+			expected: p.Type,
+		})
 	}
 
-	return errs
+	result := validate.NewParamValidator(&p, formatRegistry).Validate(value)
+	return append(errs, convertParamResult(result, &p, value)...)
 }
 
 func validateBodyParam(p spec.Parameter, data interface{}) (errs ValidationErrors) {
-	return validatebySchema(p.Schema, data)
-}
-
-func validatebySchema(sch *spec.Schema, data interface{}) (errs ValidationErrors) {
-	err := validate.AgainstSchema(sch, data, formatRegistry)
-	ves, ok := err.(*errors.CompositeError)
-	if ok && len(ves.Errors) > 0 {
-		for _, e := range ves.Errors {
-			ve := e.(*errors.Validation)
-			errs = append(errs, ValidationErrorf(strings.TrimPrefix(ve.Name, "."), nil, strings.TrimPrefix(ve.Error(), ".")))
-		}
+	if p.Schema == nil {
+		return nil
 	}
 
-	return errs
-}
-
-// valErr implements ValidationError.
-type valErr struct {
-	message string
-	field   string
-	value   interface{}
-}
-
-func (v valErr) Error() string {
-	return v.message
+	errs = skipReadOnlyRequired(validatebySchema(p.Schema, data), p.Schema)
+	return append(errs, checkReadOnly(p.Schema, data)...)
 }
 
-func (v valErr) Field() string {
-	return v.field
-}
-
-func (v valErr) Value() interface{} {
-	return v.value
+func validatebySchema(sch *spec.Schema, data interface{}) (errs ValidationErrors) {
+	data = normalizeNumbers(sch, data)
+	res := validate.NewSchemaValidator(sch, nil, "", formatRegistry).Validate(data)
+	return convertSchemaResult(res, sch, data)
 }
@@ -3,8 +3,11 @@ package validate
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/go-openapi/spec"
@@ -49,7 +52,14 @@ func TestQuery(t *testing.T) {
 			},
 			q: url.Values{"name": {"johnhoe"}, "age": {"27"}},
 			expectedErrors: []error{
-				ValidationErrorf("age", "27", "parameter age is unknown"),
+				valErr{
+					message:  "parameter age is unknown",
+					field:    "age",
+					value:    "27",
+					in:       "query",
+					keyword:  KeywordAdditionalProperties,
+					expected: false,
+				},
 			},
 		},
 		// error on parameter conversion
@@ -69,7 +79,14 @@ func TestQuery(t *testing.T) {
 			},
 			q: url.Values{"age": {"johndoe"}},
 			expectedErrors: []error{
-				ValidationErrorf("age", "johndoe", "param age: cannot convert johndoe to int32"),
+				valErr{
+					message:  "param age: cannot convert johndoe to int32",
+					field:    "age",
+					value:    "johndoe",
+					in:       "query",
+					keyword:  KeywordType,
+					expected: "integer",
+				},
 			},
 		},
 		// error on parameter validation
@@ -92,7 +109,14 @@ func TestQuery(t *testing.T) {
 			},
 			q: url.Values{"age": {"17"}},
 			expectedErrors: []error{
-				ValidationErrorf("age", int32(17), "age in query should be greater than or equal to 18"),
+				valErr{
+					message:  "age in query should be greater than or equal to 18",
+					field:    "age",
+					value:    int32(17),
+					in:       "query",
+					keyword:  KeywordMinimum,
+					expected: float64(18),
+				},
 			},
 		},
 		// required parameter is missing
@@ -112,7 +136,13 @@ func TestQuery(t *testing.T) {
 			},
 			q: url.Values{},
 			expectedErrors: []error{
-				ValidationErrorf("age", nil, "param age is required"),
+				valErr{
+					message:  "param age is required",
+					field:    "age",
+					in:       "query",
+					keyword:  KeywordRequired,
+					expected: true,
+				},
 			},
 		},
 	}
@@ -125,6 +155,118 @@ func TestQuery(t *testing.T) {
 	}
 }
 
+func TestPath_Header_FormData(t *testing.T) {
+	var minLimit float64 = 1
+
+	param := func(in string) []spec.Parameter {
+		return []spec.Parameter{
+			{
+				ParamProps: spec.ParamProps{
+					Name:     "limit",
+					In:       in,
+					Required: true,
+				},
+				SimpleSchema: spec.SimpleSchema{
+					Type:   "integer",
+					Format: "int32",
+				},
+				CommonValidations: spec.CommonValidations{
+					Minimum: &minLimit,
+				},
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		in       string
+		validate func(ps []spec.Parameter, vals []string) []error
+	}{
+		"path": {
+			in: "path",
+			validate: func(ps []spec.Parameter, vals []string) []error {
+				params := map[string]string{}
+				if vals != nil {
+					params["limit"] = vals[0]
+				}
+				return Path(ps, params)
+			},
+		},
+		"header": {
+			in: "header",
+			validate: func(ps []spec.Parameter, vals []string) []error {
+				h := http.Header{}
+				if vals != nil {
+					h["Limit"] = vals
+				}
+				return Header(ps, h)
+			},
+		},
+		"formData": {
+			in: "formData",
+			validate: func(ps []spec.Parameter, vals []string) []error {
+				form := url.Values{}
+				if vals != nil {
+					form["limit"] = vals
+				}
+				return FormData(ps, form)
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			errs := c.validate(param(c.in), []string{"10"})
+			if len(errs) != 0 {
+				t.Errorf("Expected no errors but got %v", errs)
+			}
+
+			errs = c.validate(param(c.in), []string{"0"})
+			expected := []error{
+				valErr{
+					message:  fmt.Sprintf("limit in %s should be greater than or equal to 1", c.in),
+					field:    "limit",
+					value:    int32(0),
+					in:       c.in,
+					keyword:  KeywordMinimum,
+					expected: float64(1),
+				},
+			}
+			if !reflect.DeepEqual(expected, errs) {
+				t.Errorf("Expected errors to be\n%#v\n but got\n%#v", expected, errs)
+			}
+
+			errs = c.validate(param(c.in), []string{"ten"})
+			expected = []error{
+				valErr{
+					message:  "param limit: cannot convert ten to int32",
+					field:    "limit",
+					value:    "ten",
+					in:       c.in,
+					keyword:  KeywordType,
+					expected: "integer",
+				},
+			}
+			if !reflect.DeepEqual(expected, errs) {
+				t.Errorf("Expected errors to be\n%#v\n but got\n%#v", expected, errs)
+			}
+
+			errs = c.validate(param(c.in), nil)
+			expected = []error{
+				valErr{
+					message:  "param limit is required",
+					field:    "limit",
+					in:       c.in,
+					keyword:  KeywordRequired,
+					expected: true,
+				},
+			}
+			if !reflect.DeepEqual(expected, errs) {
+				t.Errorf("Expected errors to be\n%#v\n but got\n%#v", expected, errs)
+			}
+		})
+	}
+}
+
 func TestBody(t *testing.T) {
 	cases := []struct {
 		ps             []spec.Parameter
@@ -183,6 +325,111 @@ func TestBody(t *testing.T) {
 	}
 }
 
+func TestBody_readOnly(t *testing.T) {
+	sch := testhelperPetSchema()
+	id := sch.Properties["id"]
+	id.ReadOnly = true
+	sch.Properties["id"] = id
+	sch.Required = append(sch.Required, "id")
+
+	ps := []spec.Parameter{
+		{
+			ParamProps: spec.ParamProps{
+				Name:   "pet",
+				In:     "body",
+				Schema: sch,
+			},
+		},
+	}
+
+	t.Run("readOnly property is not required", func(t *testing.T) {
+		var data interface{}
+		js := `{"name": "doggie", "photoUrls": []}`
+		if err := json.Unmarshal([]byte(js), &data); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if errs := Body(ps, data); errs != nil {
+			t.Errorf("Expected no errors but got %v", errs)
+		}
+		if errs := NewBodyValidator(ps).Validate(data); errs != nil {
+			t.Errorf("Expected no errors but got %v", errs)
+		}
+	})
+
+	t.Run("readOnly property is not allowed", func(t *testing.T) {
+		data := testhelperMakePetData()
+		expectedErrors := []error{
+			valErr{
+				message:  "id in body is read-only",
+				field:    "id",
+				value:    float64(10),
+				in:       "body",
+				pointer:  "/id",
+				keyword:  KeywordReadOnly,
+				expected: false,
+			},
+		}
+
+		if errs := Body(ps, data); !reflect.DeepEqual(expectedErrors, errs) {
+			t.Errorf("Expected errors to be %#v but got %#v", expectedErrors, errs)
+		}
+		if errs := NewBodyValidator(ps).Validate(data); !reflect.DeepEqual(expectedErrors, errs) {
+			t.Errorf("Expected errors to be %#v but got %#v", expectedErrors, errs)
+		}
+	})
+
+	t.Run("readOnly property is stripped", func(t *testing.T) {
+		data := testhelperMakePetData()
+
+		if !NewBodyValidator(ps).StripReadOnly(data) {
+			t.Fatalf("Expected readOnly property to be stripped")
+		}
+		if _, ok := data.(map[string]interface{})["id"]; ok {
+			t.Errorf("Expected id to be removed from data")
+		}
+		if errs := Body(ps, data); errs != nil {
+			t.Errorf("Expected no errors but got %v", errs)
+		}
+	})
+}
+
+func TestResponse_writeOnly(t *testing.T) {
+	sch := testhelperPetSchema()
+	tags := sch.Properties["tags"]
+	name := tags.Items.Schema.Properties["name"]
+	name.AddExtension("x-writeOnly", true)
+	tags.Items.Schema.Properties["name"] = name
+	sch.Properties["tags"] = tags
+
+	expectedErrors := []error{
+		valErr{
+			message:  "tags.1.name in body is write-only",
+			field:    "tags.1.name",
+			value:    "boy",
+			in:       "body",
+			pointer:  "/tags/1/name",
+			keyword:  KeywordWriteOnly,
+			expected: false,
+		},
+	}
+
+	var data interface{}
+	js := `{"name": "doggie", "photoUrls": [], "tags": [{"id": 1}, {"id": 2, "name": "boy"}]}`
+	if err := json.Unmarshal([]byte(js), &data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if errs := Response(sch, data); !reflect.DeepEqual(expectedErrors, errs) {
+		t.Errorf("Expected errors to be %#v but got %#v", expectedErrors, errs)
+	}
+
+	// Generic schema validation does not care about write-only properties.
+	if errs := BySchema(sch, data); errs != nil {
+		t.Errorf("Expected no errors but got %v", errs)
+	}
+}
+
 func TestBySchema(t *testing.T) {
 	cases := []struct {
 		sch            *spec.Schema
@@ -223,7 +470,17 @@ func TestBySchema(t *testing.T) {
 				},
 			},
 			data:           testhelperMakeUserData("Max"),
-			expectedErrors: []error{ValidationErrorf("name", nil, "name in body should be at least 4 chars long")},
+			expectedErrors: []error{
+				valErr{
+					message:  "name in body should be at least 4 chars long",
+					field:    "name",
+					value:    "Max",
+					in:       "body",
+					pointer:  "/name",
+					keyword:  KeywordMinLength,
+					expected: int64(4),
+				},
+			},
 		},
 	}
 
@@ -235,6 +492,658 @@ func TestBySchema(t *testing.T) {
 	}
 }
 
+func TestSchemaValidator(t *testing.T) {
+	v := NewSchemaValidator(testhelperPetSchema())
+
+	t.Run("valid data", func(t *testing.T) {
+		if errs := v.Validate(testhelperMakePetData()); errs != nil {
+			t.Errorf("Expected no errors but got %v", errs)
+		}
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		expectedErrors := []error{
+			valErr{
+				message:  "photoUrls in body is required",
+				field:    "photoUrls",
+				in:       "body",
+				pointer:  "/photoUrls",
+				keyword:  KeywordRequired,
+				expected: true,
+			},
+		}
+		errs := v.Validate(testhelperMakeUserData("doggie"))
+		if !reflect.DeepEqual(expectedErrors, errs) {
+			t.Errorf("Expected errors to be %#v but got %#v", expectedErrors, errs)
+		}
+	})
+
+	t.Run("concurrent use", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if errs := v.Validate(testhelperMakePetData()); errs != nil {
+					t.Errorf("Expected no errors but got %v", errs)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("nil validator accepts any data", func(t *testing.T) {
+		var v *SchemaValidator
+		if errs := v.Validate(testhelperMakeUserData("")); errs != nil {
+			t.Errorf("Expected no errors but got %v", errs)
+		}
+	})
+}
+
+func TestNewBodyValidator(t *testing.T) {
+	ps := []spec.Parameter{
+		{
+			ParamProps: spec.ParamProps{
+				Name: "debug",
+				In:   "query",
+			},
+		},
+	}
+	if v := NewBodyValidator(ps); v != nil {
+		t.Errorf("Expected nil validator when there is no body parameter")
+	}
+
+	ps = append(ps, spec.Parameter{
+		ParamProps: spec.ParamProps{
+			Name:   "pet",
+			In:     "body",
+			Schema: testhelperPetSchema(),
+		},
+	})
+	if v := NewBodyValidator(ps); v == nil {
+		t.Errorf("Expected non-nil validator for body parameter")
+	}
+}
+
+func BenchmarkBySchema(b *testing.B) {
+	sch := testhelperPetSchema()
+	data := testhelperMakePetData()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BySchema(sch, data)
+	}
+}
+
+func BenchmarkSchemaValidator_Validate(b *testing.B) {
+	v := NewSchemaValidator(testhelperPetSchema())
+	data := testhelperMakePetData()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.Validate(data)
+	}
+}
+
+func BenchmarkSchemaValidator_Validate_parallel(b *testing.B) {
+	v := NewSchemaValidator(testhelperPetSchema())
+	data := testhelperMakePetData()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			v.Validate(data)
+		}
+	})
+}
+
+func TestBySchema_structuredErrors(t *testing.T) {
+	sch := testhelperPetSchema()
+	sch.AdditionalProperties = &spec.SchemaOrBool{Allows: false}
+
+	var data interface{}
+	js := `{
+	  "name": "doggie",
+	  "photoUrls": ["https://example.com/doggie.png"],
+	  "tags": [{"id": 1, "name": "good"}, {"id": "two", "name": "boy"}],
+	  "status": "lost",
+	  "color": "brown"
+	}`
+	if err := json.Unmarshal([]byte(js), &data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	type result struct {
+		pointer  string
+		keyword  string
+		expected interface{}
+		value    interface{}
+	}
+
+	expected := map[string]result{
+		"/tags/1/id": {"/tags/1/id", KeywordType, "integer", "two"},
+		"/status":    {"/status", KeywordEnum, []interface{}{"available", "pending", "sold"}, "lost"},
+		"/color":     {"/color", KeywordAdditionalProperties, false, "brown"},
+	}
+
+	errs := BySchema(sch, data)
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors but got %v", len(expected), errs)
+	}
+
+	for _, err := range errs {
+		ve, ok := err.(DetailedValidationError)
+		if !ok {
+			t.Fatalf("Expected error to be DetailedValidationError but got %T", err)
+		}
+		exp, ok := expected[ve.Pointer()]
+		if !ok {
+			t.Errorf("Unexpected error %v with pointer %q", ve, ve.Pointer())
+			continue
+		}
+		actual := result{ve.Pointer(), ve.Keyword(), ve.Expected(), ve.Value()}
+		if !reflect.DeepEqual(exp, actual) {
+			t.Errorf("Expected error to be %#v but got %#v", exp, actual)
+		}
+		if ve.In() != "body" {
+			t.Errorf("Expected error location to be body but got %q", ve.In())
+		}
+	}
+}
+
+func TestBySchema_dottedProperty(t *testing.T) {
+	var sch spec.Schema
+	js := `{
+	  "type": "object",
+	  "required": ["app.name", "app.version"],
+	  "properties": {
+	    "app.name": {"type": "string", "maxLength": 3},
+	    "app.version": {"type": "string"},
+	    "labels": {
+	      "type": "object",
+	      "additionalProperties": {"type": "integer"}
+	    }
+	  }
+	}`
+	if err := json.Unmarshal([]byte(js), &sch); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var data interface{}
+	if err := json.Unmarshal([]byte(`{"app.name": "petstore", "labels": {"k8s.io/name": "pets"}}`), &data); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"/app.name":            KeywordMaxLength,
+		"/app.version":         KeywordRequired,
+		"/labels/k8s.io~1name": KeywordType,
+	}
+
+	errs := BySchema(&sch, data)
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors but got %v", len(expected), errs)
+	}
+	for _, err := range errs {
+		ve := err.(DetailedValidationError)
+		keyword, ok := expected[ve.Pointer()]
+		if !ok {
+			t.Errorf("Unexpected error %v with pointer %q", ve, ve.Pointer())
+			continue
+		}
+		if ve.Keyword() != keyword {
+			t.Errorf("Expected keyword of %q to be %q but got %q", ve.Pointer(), keyword, ve.Keyword())
+		}
+	}
+}
+
+func TestSchemaValidator_jsonNumber(t *testing.T) {
+	var sch spec.Schema
+	js := `{
+	  "type": "object",
+	  "properties": {
+	    "id": {"type": "integer", "format": "int64", "minimum": 1},
+	    "price": {"type": "number", "maximum": 10},
+	    "age": {"type": "integer"},
+	    "born": {"type": "string", "format": "date"}
+	  }
+	}`
+	if err := json.Unmarshal([]byte(js), &sch); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testCases := map[string]struct {
+		data     string
+		expected map[string]string
+	}{
+		"valid": {
+			data: `{"id": 9007199254740993, "price": 9.5, "age": 2.0}`,
+		},
+		"invalid": {
+			data: `{"id": 0, "price": 1e3, "age": 1.5, "born": 2018}`,
+			expected: map[string]string{
+				"/id":    KeywordMinimum,
+				"/price": KeywordMaximum,
+				"/age":   KeywordType,
+				"/born":  KeywordType,
+			},
+		},
+	}
+
+	v := NewSchemaValidator(&sch)
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dec := json.NewDecoder(strings.NewReader(tc.data))
+			dec.UseNumber()
+			var data interface{}
+			if err := dec.Decode(&data); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			errs := v.Validate(data)
+			if len(errs) != len(tc.expected) {
+				t.Fatalf("Expected %d errors but got %v", len(tc.expected), errs)
+			}
+			for _, err := range errs {
+				ve := err.(DetailedValidationError)
+				if keyword := tc.expected[ve.Pointer()]; ve.Keyword() != keyword {
+					t.Errorf("Expected keyword of %q to be %q but got %q", ve.Pointer(), keyword, ve.Keyword())
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaValidator_subtypes(t *testing.T) {
+	js := `{
+	  "Animal": {
+	    "type": "object",
+	    "discriminator": "kind",
+	    "required": ["kind", "name"],
+	    "properties": {
+	      "kind": {"type": "string"},
+	      "name": {"type": "string"}
+	    }
+	  },
+	  "Cat": {
+	    "allOf": [
+	      {"$ref": "#/definitions/Animal"},
+	      {
+	        "type": "object",
+	        "required": ["huntingSkill"],
+	        "properties": {
+	          "huntingSkill": {"type": "string", "enum": ["lazy", "aggressive"]}
+	        }
+	      }
+	    ]
+	  },
+	  "Dog": {
+	    "x-discriminator-value": "dog",
+	    "allOf": [
+	      {"$ref": "#/definitions/Animal"},
+	      {
+	        "type": "object",
+	        "properties": {
+	          "packSize": {"type": "integer", "minimum": 0}
+	        }
+	      }
+	    ]
+	  },
+	  "Puppy": {
+	    "allOf": [
+	      {"$ref": "#/definitions/Dog"},
+	      {
+	        "type": "object",
+	        "required": ["ageMonths"],
+	        "properties": {
+	          "ageMonths": {"type": "integer", "maximum": 12}
+	        }
+	      }
+	    ]
+	  },
+	  "Vehicle": {
+	    "type": "object",
+	    "discriminator": "kind",
+	    "required": ["kind", "wheels"],
+	    "properties": {
+	      "kind": {"type": "string"},
+	      "wheels": {"type": "integer"}
+	    }
+	  },
+	  "Car": {
+	    "allOf": [
+	      {"$ref": "#/definitions/Vehicle"},
+	      {
+	        "type": "object",
+	        "properties": {
+	          "seats": {"type": "integer", "minimum": 1}
+	        }
+	      }
+	    ]
+	  },
+	  "Owner": {
+	    "type": "object",
+	    "properties": {
+	      "name": {"type": "string"}
+	    }
+	  }
+	}`
+
+	var orig spec.Definitions
+	if err := json.Unmarshal([]byte(js), &orig); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sw := &spec.Swagger{}
+	if err := json.Unmarshal([]byte(`{"definitions": `+js+`}`), sw); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := spec.ExpandSpec(sw, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defs := sw.Definitions
+
+	base := defs["Animal"]
+	vehicle := defs["Vehicle"]
+	shelter := spec.Schema{}
+	shelter.Typed("object", "")
+	shelter.SetProperty("animals", *spec.ArrayProperty(&base))
+
+	type result struct {
+		keyword  string
+		expected interface{}
+	}
+
+	testCases := map[string]struct {
+		schema   spec.Schema
+		data     string
+		expected map[string]result
+	}{
+		"valid cat": {
+			schema: base,
+			data:   `{"kind": "Cat", "name": "Tom", "huntingSkill": "lazy"}`,
+		},
+		"valid dog with custom discriminator value": {
+			schema: base,
+			data:   `{"kind": "dog", "name": "Rex", "packSize": 3}`,
+		},
+		"invalid cat": {
+			schema: base,
+			data:   `{"kind": "Cat", "name": "Tom", "huntingSkill": "sleepy"}`,
+			expected: map[string]result{
+				"/huntingSkill": {KeywordEnum, []interface{}{"lazy", "aggressive"}},
+			},
+		},
+		"missing subtype property": {
+			schema: base,
+			data:   `{"kind": "Cat", "name": "Tom"}`,
+			expected: map[string]result{
+				"/huntingSkill": {KeywordRequired, true},
+			},
+		},
+		"unknown discriminator value": {
+			schema: base,
+			data:   `{"kind": "Cow", "name": "Milka"}`,
+			expected: map[string]result{
+				"/kind": {KeywordDiscriminator, []string{"Animal", "Cat", "Puppy", "dog"}},
+			},
+		},
+		"definition without discriminator": {
+			schema: base,
+			data:   `{"kind": "Owner", "name": "John"}`,
+			expected: map[string]result{
+				"/kind": {KeywordDiscriminator, []string{"Animal", "Cat", "Puppy", "dog"}},
+			},
+		},
+		"valid puppy": {
+			schema: base,
+			data:   `{"kind": "Puppy", "name": "Rex", "packSize": 1, "ageMonths": 3}`,
+		},
+		"invalid puppy": {
+			schema: base,
+			data:   `{"kind": "Puppy", "name": "Rex", "packSize": -1, "ageMonths": 13}`,
+			expected: map[string]result{
+				"/packSize":  {KeywordMinimum, float64(0)},
+				"/ageMonths": {KeywordMaximum, float64(12)},
+			},
+		},
+		"subtype of another base with the same discriminator": {
+			schema: base,
+			data:   `{"kind": "Car", "name": "Herbie", "wheels": 4}`,
+			expected: map[string]result{
+				"/kind": {KeywordDiscriminator, []string{"Animal", "Cat", "Puppy", "dog"}},
+			},
+		},
+		"valid car": {
+			schema: vehicle,
+			data:   `{"kind": "Car", "wheels": 4, "seats": 2}`,
+		},
+		"invalid car": {
+			schema: vehicle,
+			data:   `{"kind": "Car", "wheels": 4, "seats": 0}`,
+			expected: map[string]result{
+				"/seats": {KeywordMinimum, float64(1)},
+			},
+		},
+		"vehicle of another base with the same discriminator": {
+			schema: vehicle,
+			data:   `{"kind": "Cat", "wheels": 4}`,
+			expected: map[string]result{
+				"/kind": {KeywordDiscriminator, []string{"Car", "Vehicle"}},
+			},
+		},
+		"nested invalid dog": {
+			schema: shelter,
+			data: `{"animals": [
+			  {"kind": "Cat", "name": "Tom", "huntingSkill": "lazy"},
+			  {"kind": "dog", "name": "Rex", "packSize": -1}
+			]}`,
+			expected: map[string]result{
+				"/animals/1/packSize": {KeywordMinimum, float64(0)},
+			},
+		},
+	}
+
+	subtypes := NewSubtypes(defs, orig)
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(tc.data), &data); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			sch := tc.schema
+			errs := NewSchemaValidator(&sch, WithSubtypes(subtypes)).Validate(data)
+			if len(errs) != len(tc.expected) {
+				t.Fatalf("Expected %d errors but got %v", len(tc.expected), errs)
+			}
+
+			for _, err := range errs {
+				ve := err.(DetailedValidationError)
+				exp, ok := tc.expected[ve.Pointer()]
+				if !ok {
+					t.Errorf("Unexpected error %v with pointer %q", ve, ve.Pointer())
+					continue
+				}
+				actual := result{ve.Keyword(), ve.Expected()}
+				if !reflect.DeepEqual(exp, actual) {
+					t.Errorf("Expected error to be %#v but got %#v", exp, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyQueryDefaults(t *testing.T) {
+	ps := []spec.Parameter{
+		*spec.QueryParam("limit").Typed("integer", "int32").WithDefault(float64(20)),
+		*spec.QueryParam("sort").Typed("string", "").WithDefault("name"),
+		*spec.QueryParam("tags").CollectionOf(spec.NewItems().Typed("string", ""), "pipes").WithDefault([]interface{}{"a", "b"}),
+		*spec.QueryParam("ids").CollectionOf(spec.NewItems().Typed("integer", ""), "multi").WithDefault([]interface{}{float64(1), float64(2)}),
+		*spec.QueryParam("debug").Typed("boolean", ""),
+		*spec.PathParam("id").Typed("integer", "").WithDefault(float64(1)),
+	}
+
+	testCases := map[string]struct {
+		query    string
+		applied  bool
+		expected url.Values
+	}{
+		"all defaults": {
+			query:   "",
+			applied: true,
+			expected: url.Values{
+				"limit": {"20"},
+				"sort":  {"name"},
+				"tags":  {"a|b"},
+				"ids":   {"1", "2"},
+			},
+		},
+		"present values are kept": {
+			query:   "limit=5&sort=age&tags=c&ids=3",
+			applied: false,
+			expected: url.Values{
+				"limit": {"5"},
+				"sort":  {"age"},
+				"tags":  {"c"},
+				"ids":   {"3"},
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			q, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			applied := ApplyQueryDefaults(ps, q)
+			if applied != tc.applied {
+				t.Errorf("Expected applied to be %v but got %v", tc.applied, applied)
+			}
+			if !reflect.DeepEqual(tc.expected, q) {
+				t.Errorf("Expected query to be %v but got %v", tc.expected, q)
+			}
+		})
+	}
+}
+
+func TestSchemaValidator_ApplyDefaults(t *testing.T) {
+	var sch spec.Schema
+	js := `{
+	  "type": "object",
+	  "properties": {
+	    "id": {"type": "integer", "readOnly": true, "default": 0},
+	    "status": {"type": "string", "default": "available"},
+	    "category": {
+	      "type": "object",
+	      "default": {"name": "none"},
+	      "properties": {
+	        "name": {"type": "string"}
+	      }
+	    },
+	    "tags": {
+	      "type": "array",
+	      "items": {
+	        "type": "object",
+	        "properties": {
+	          "name": {"type": "string"},
+	          "weight": {"type": "integer", "default": 1}
+	        }
+	      }
+	    }
+	  }
+	}`
+	if err := json.Unmarshal([]byte(js), &sch); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testCases := map[string]struct {
+		validator *SchemaValidator
+		data      string
+		applied   bool
+		expected  string
+	}{
+		"request body": {
+			validator: NewBodyValidator([]spec.Parameter{*spec.BodyParam("pet", &sch)}),
+			data:      `{"tags": [{"name": "good"}, {"name": "boy", "weight": 5}]}`,
+			applied:   true,
+			expected: `{
+			  "status": "available",
+			  "category": {"name": "none"},
+			  "tags": [{"name": "good", "weight": 1}, {"name": "boy", "weight": 5}]
+			}`,
+		},
+		"readOnly defaults are applied outside of requests": {
+			validator: NewSchemaValidator(&sch),
+			data:      `{"status": "sold", "category": {}}`,
+			applied:   true,
+			expected:  `{"id": 0, "status": "sold", "category": {}}`,
+		},
+		"nothing to apply": {
+			validator: NewBodyValidator([]spec.Parameter{*spec.BodyParam("pet", &sch)}),
+			data:      `{"status": "sold", "category": {"name": "cats"}}`,
+			applied:   false,
+			expected:  `{"status": "sold", "category": {"name": "cats"}}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var data, expected interface{}
+			if err := json.Unmarshal([]byte(tc.data), &data); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tc.expected), &expected); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			applied := tc.validator.ApplyDefaults(data)
+			if applied != tc.applied {
+				t.Errorf("Expected applied to be %v but got %v", tc.applied, applied)
+			}
+			if !reflect.DeepEqual(expected, data) {
+				t.Errorf("Expected data to be %v but got %v", expected, data)
+			}
+
+			// Default values must not be shared with the data.
+			if obj, ok := data.(map[string]interface{})["category"].(map[string]interface{}); ok {
+				obj["name"] = "changed"
+			}
+			if name := sch.Properties["category"].Default.(map[string]interface{})["name"]; name != "none" {
+				t.Errorf("Expected schema default to be intact but got %v", name)
+			}
+		})
+	}
+}
+
+func TestValidationError_MarshalJSON(t *testing.T) {
+	ve := valErr{
+		message:  "name in body should be at most 4 chars long",
+		field:    "tags.0.name",
+		value:    "doggie",
+		in:       "body",
+		pointer:  "/tags/0/name",
+		keyword:  KeywordMaxLength,
+		expected: int64(4),
+	}
+
+	b, err := json.Marshal(ve)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `{"message":"name in body should be at most 4 chars long","field":"tags.0.name","in":"body","pointer":"/tags/0/name","keyword":"maxLength","expected":4,"value":"doggie"}`
+	if string(b) != expected {
+		t.Errorf("Expected JSON to be\n%s\nbut got\n%s", expected, b)
+	}
+}
+
 func TestValidationError(t *testing.T) {
 	ve := ValidationErrorf("name", nil, "name cannot be empty")
 
@@ -260,6 +1169,59 @@ func testhelperMakeUserData(name string) interface{} {
 	return v
 }
 
+// testhelperPetSchema returns a schema of the Pet definition from the
+// petstore example.
+func testhelperPetSchema() *spec.Schema {
+	var sch spec.Schema
+	js := `{
+	  "type": "object",
+	  "required": ["name", "photoUrls"],
+	  "properties": {
+	    "id": {"type": "integer", "format": "int64"},
+	    "category": {
+	      "type": "object",
+	      "properties": {
+	        "id": {"type": "integer", "format": "int64"},
+	        "name": {"type": "string"}
+	      }
+	    },
+	    "name": {"type": "string", "example": "doggie"},
+	    "photoUrls": {"type": "array", "items": {"type": "string"}},
+	    "tags": {
+	      "type": "array",
+	      "items": {
+	        "type": "object",
+	        "properties": {
+	          "id": {"type": "integer", "format": "int64"},
+	          "name": {"type": "string"}
+	        }
+	      }
+	    },
+	    "status": {"type": "string", "enum": ["available", "pending", "sold"]}
+	  }
+	}`
+	if err := json.Unmarshal([]byte(js), &sch); err != nil {
+		panic(err)
+	}
+	return &sch
+}
+
+func testhelperMakePetData() interface{} {
+	var v interface{}
+	js := `{
+	  "id": 10,
+	  "category": {"id": 1, "name": "Dogs"},
+	  "name": "doggie",
+	  "photoUrls": ["https://example.com/doggie.png"],
+	  "tags": [{"id": 1, "name": "good"}, {"id": 2, "name": "boy"}],
+	  "status": "available"
+	}`
+	if err := json.Unmarshal([]byte(js), &v); err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func int64Ptr(f int64) *int64 {
 	return &f
 }